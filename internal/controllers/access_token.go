@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"server/internal/models"
+	"server/internal/services"
+	"server/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// accessTokenError 将个人访问令牌相关错误转换为响应
+func accessTokenError(ctx *gin.Context, err error) {
+	switch err {
+	case services.ErrInvalidTokenName, services.ErrInvalidTokenScopes, services.ErrInvalidTokenExpiry, services.ErrTooManyAccessTokens:
+		ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+	case services.ErrIncorrectPassword:
+		ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
+	case services.ErrAccessTokenNotFound:
+		ctx.JSON(404, response.Error(response.StatusNotFound, err.Error()))
+	default:
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+	}
+}
+
+// ListAccessTokens 个人访问令牌列表
+func (c *UserController) ListAccessTokens(ctx *gin.Context) {
+	data, err := c.userService.ListAccessTokens(ctx.GetInt("user_id"))
+	if err != nil {
+		accessTokenError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.Success(data))
+}
+
+// CreateAccessToken 创建个人访问令牌
+func (c *UserController) CreateAccessToken(ctx *gin.Context) {
+	var request models.CreateAccessTokenRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	data, err := c.userService.CreateAccessToken(ctx.GetInt("user_id"), &request)
+	if err != nil {
+		accessTokenError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Create access token successfully", data))
+}
+
+// DeleteAccessToken 删除个人访问令牌
+func (c *UserController) DeleteAccessToken(ctx *gin.Context) {
+	tokenId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid token ID"))
+		return
+	}
+
+	if err := c.userService.DeleteAccessToken(ctx.GetInt("user_id"), tokenId); err != nil {
+		accessTokenError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Delete access token successfully", nil))
+}
@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"server/internal/models"
+	"server/internal/services"
+	"server/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AdminController struct {
+	adminService *services.AdminService
+}
+
+func NewAdminController(db *gorm.DB) *AdminController {
+	return &AdminController{
+		adminService: services.NewAdminService(db),
+	}
+}
+
+// adminError 将用户管理相关错误转换为响应
+func adminError(ctx *gin.Context, err error) {
+	switch err {
+	case services.ErrPermissionDenied:
+		ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
+	case services.ErrCannotManageSelf, services.ErrInvalidRole, services.ErrMfaNotEnabled:
+		ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+	case gorm.ErrRecordNotFound:
+		ctx.JSON(404, response.Error(response.StatusNotFound, "User not found"))
+	default:
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+	}
+}
+
+// userIdParam 解析路径中的用户ID
+func userIdParam(ctx *gin.Context) (int, bool) {
+	userId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid user ID"))
+		return 0, false
+	}
+	return userId, true
+}
+
+// ListUsers 用户列表
+func (c *AdminController) ListUsers(ctx *gin.Context) {
+	var request models.AdminUserListRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	// 设置默认值
+	if request.Page <= 0 {
+		request.Page = 1
+	}
+	if request.Size <= 0 {
+		request.Size = 20
+	}
+
+	data, err := c.adminService.ListUsers(ctx.GetInt("user_id"), &request)
+	if err != nil {
+		adminError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Get users successfully", data))
+}
+
+// GetUser 用户详情
+func (c *AdminController) GetUser(ctx *gin.Context) {
+	userId, ok := userIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	user, err := c.adminService.GetUser(ctx.GetInt("user_id"), userId)
+	if err != nil {
+		adminError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Get user successfully", user))
+}
+
+// ListUserArticles 用户的文章
+func (c *AdminController) ListUserArticles(ctx *gin.Context) {
+	userId, ok := userIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	var request models.AdminPageRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+	if request.Page <= 0 {
+		request.Page = 1
+	}
+	if request.Size <= 0 {
+		request.Size = 10
+	}
+
+	data, err := c.adminService.ListUserArticles(ctx.GetInt("user_id"), userId, &request)
+	if err != nil {
+		adminError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Get articles successfully", data))
+}
+
+// ListUserSessions 用户的登录会话
+func (c *AdminController) ListUserSessions(ctx *gin.Context) {
+	userId, ok := userIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	sessions, err := c.adminService.ListUserSessions(ctx.GetInt("user_id"), userId)
+	if err != nil {
+		adminError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Get sessions successfully", sessions))
+}
+
+// Suspend 停用账号
+func (c *AdminController) Suspend(ctx *gin.Context) {
+	userId, ok := userIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	var request models.SuspendUserRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	user, err := c.adminService.Suspend(ctx.GetInt("user_id"), userId, request.Reason)
+	if err != nil {
+		adminError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Suspend user successfully", user))
+}
+
+// Unsuspend 恢复账号
+func (c *AdminController) Unsuspend(ctx *gin.Context) {
+	userId, ok := userIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	user, err := c.adminService.Unsuspend(ctx.GetInt("user_id"), userId)
+	if err != nil {
+		adminError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Unsuspend user successfully", user))
+}
+
+// UpdateRole 修改用户角色
+func (c *AdminController) UpdateRole(ctx *gin.Context) {
+	userId, ok := userIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	var request models.UpdateUserRoleRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	user, err := c.adminService.UpdateRole(ctx.GetInt("user_id"), userId, request.Role)
+	if err != nil {
+		adminError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Update role successfully", user))
+}
+
+// ForcePasswordReset 强制用户重置密码
+func (c *AdminController) ForcePasswordReset(ctx *gin.Context) {
+	userId, ok := userIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.adminService.ForcePasswordReset(ctx.GetInt("user_id"), userId); err != nil {
+		adminError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Password reset email sent", nil))
+}
+
+// ResetMfa 关闭用户的两步验证
+func (c *AdminController) ResetMfa(ctx *gin.Context) {
+	userId, ok := userIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.adminService.ResetMfa(ctx.GetInt("user_id"), userId); err != nil {
+		adminError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Two-factor authentication disabled", nil))
+}
+
+// DeleteUser 删除账号
+func (c *AdminController) DeleteUser(ctx *gin.Context) {
+	userId, ok := userIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.adminService.DeleteUser(ctx.GetInt("user_id"), userId); err != nil {
+		adminError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Delete user successfully", nil))
+}
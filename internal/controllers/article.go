@@ -31,6 +31,14 @@ func (c *ArticleController) Create(ctx *gin.Context) {
 	userId := ctx.GetInt("user_id")
 	article, err := c.articleService.Create(userId, &request)
 	if err != nil {
+		if err == services.ErrContentRejected || err == services.ErrInvalidVisibility {
+			ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+			return
+		}
+		if err == services.ErrEmailNotVerified || err == services.ErrPermissionDenied {
+			ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
+			return
+		}
 		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
 		return
 	}
@@ -59,6 +67,10 @@ func (c *ArticleController) Update(ctx *gin.Context) {
 			ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
 			return
 		}
+		if err == services.ErrContentRejected || err == services.ErrInvalidVisibility {
+			ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+			return
+		}
 		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
 		return
 	}
@@ -96,7 +108,8 @@ func (c *ArticleController) GetById(ctx *gin.Context) {
 		return
 	}
 
-	article, err := c.articleService.GetById(articleId)
+	viewerId := ctx.GetInt("user_id")
+	article, err := c.articleService.GetById(viewerId, articleId, articleAccessToken(ctx))
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			ctx.JSON(404, response.Error(response.StatusNotFound, "Article not found"))
@@ -109,6 +122,46 @@ func (c *ArticleController) GetById(ctx *gin.Context) {
 	ctx.JSON(200, response.SuccessWithMessage("Get article successfully", article))
 }
 
+// articleAccessToken 获取受密码保护帖子的访问令牌，支持请求头 X-Article-Token 或查询参数 article_token
+func articleAccessToken(ctx *gin.Context) string {
+	if token := ctx.GetHeader("X-Article-Token"); token != "" {
+		return token
+	}
+	return ctx.Query("article_token")
+}
+
+// Unlock 使用密码解锁帖子
+func (c *ArticleController) Unlock(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid article ID"))
+		return
+	}
+
+	var request models.UnlockArticleRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	data, err := c.articleService.Unlock(articleId, request.Password)
+	if err != nil {
+		switch err {
+		case gorm.ErrRecordNotFound:
+			ctx.JSON(404, response.Error(response.StatusNotFound, "Article not found"))
+		case services.ErrArticleNotProtected:
+			ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+		case services.ErrIncorrectArticlePassword:
+			ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
+		default:
+			ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+		}
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Unlock article successfully", data))
+}
+
 // List 获取帖子列表
 func (c *ArticleController) List(ctx *gin.Context) {
 	var request models.ArticleListRequest
@@ -125,7 +178,8 @@ func (c *ArticleController) List(ctx *gin.Context) {
 		request.Size = 10
 	}
 
-	data, err := c.articleService.List(&request)
+	viewerId := ctx.GetInt("user_id")
+	data, err := c.articleService.List(viewerId, &request)
 	if err != nil {
 		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
 		return
@@ -144,3 +198,66 @@ func (c *ArticleController) GetStats(ctx *gin.Context) {
 
 	ctx.JSON(200, response.SuccessWithMessage("Get stats successfully", stats))
 }
+
+// Archive 按年月统计文章数
+func (c *ArticleController) Archive(ctx *gin.Context) {
+	var request models.ArchiveRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	viewerId := ctx.GetInt("user_id")
+	data, err := c.articleService.Archive(viewerId, &request)
+	if err != nil {
+		if err == services.ErrInvalidTimezone {
+			ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+			return
+		}
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Get archive successfully", data))
+}
+
+// ArchiveArticles 获取某年某月的文章列表
+func (c *ArticleController) ArchiveArticles(ctx *gin.Context) {
+	year, err := strconv.Atoi(ctx.Param("year"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid year"))
+		return
+	}
+	month, err := strconv.Atoi(ctx.Param("month"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid month"))
+		return
+	}
+
+	var request models.ArchiveArticlesRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	// 设置默认值
+	if request.Page <= 0 {
+		request.Page = 1
+	}
+	if request.Size <= 0 {
+		request.Size = 10
+	}
+
+	viewerId := ctx.GetInt("user_id")
+	data, err := c.articleService.ArchiveArticles(viewerId, year, month, &request)
+	if err != nil {
+		if err == services.ErrInvalidTimezone || err == services.ErrInvalidArchiveMonth {
+			ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+			return
+		}
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Get articles successfully", data))
+}
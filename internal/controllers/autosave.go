@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"server/internal/models"
+	"server/internal/services"
+	"server/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AutosaveController struct {
+	autosaveService *services.AutosaveService
+}
+
+func NewAutosaveController(db *gorm.DB) *AutosaveController {
+	return &AutosaveController{
+		autosaveService: services.NewAutosaveService(db),
+	}
+}
+
+// autosaveError 将自动保存相关错误转换为响应
+func autosaveError(ctx *gin.Context, err error, notFound string) {
+	switch err {
+	case services.ErrPermissionDenied:
+		ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
+	case services.ErrContentRejected, services.ErrInvalidVisibility:
+		ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+	case gorm.ErrRecordNotFound:
+		ctx.JSON(404, response.Error(response.StatusNotFound, notFound))
+	default:
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+	}
+}
+
+// Save 自动保存工作副本
+func (c *AutosaveController) Save(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid article ID"))
+		return
+	}
+
+	var request models.AutosaveRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	autosave, err := c.autosaveService.Save(userId, articleId, &request)
+	if err != nil {
+		autosaveError(ctx, err, "Article not found")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Autosave successfully", autosave))
+}
+
+// Get 查看工作副本
+func (c *AutosaveController) Get(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid article ID"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	data, err := c.autosaveService.Get(userId, articleId)
+	if err != nil {
+		autosaveError(ctx, err, "Autosave not found")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Get autosave successfully", data))
+}
+
+// Discard 丢弃工作副本
+func (c *AutosaveController) Discard(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid article ID"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	if err := c.autosaveService.Discard(userId, articleId); err != nil {
+		autosaveError(ctx, err, "Article not found")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Discard autosave successfully", nil))
+}
+
+// Publish 发布工作副本
+func (c *AutosaveController) Publish(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid article ID"))
+		return
+	}
+
+	var request models.PublishAutosaveRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	article, err := c.autosaveService.Publish(userId, articleId, request.AutosaveId)
+	if err != nil {
+		autosaveError(ctx, err, "Autosave not found")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Publish autosave successfully", article))
+}
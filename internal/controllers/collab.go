@@ -0,0 +1,257 @@
+package controllers
+
+import (
+	"encoding/json"
+	"server/internal/models"
+	"server/internal/services"
+	"server/pkg/middleware"
+	"server/pkg/response"
+	"server/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"gorm.io/gorm"
+)
+
+const (
+	collabWriteWait      = 10 * time.Second
+	collabPongWait       = 60 * time.Second
+	collabPingPeriod     = collabPongWait * 9 / 10
+	collabMaxMessageSize = 1 << 20
+	// 连接期间重新检查登录令牌的间隔，退出登录、会话被移除或账号被停用后断开连接
+	collabRecheckPeriod = 30 * time.Second
+)
+
+var collabUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	CheckOrigin:     middleware.CheckOrigin,
+}
+
+type CollabController struct {
+	collabService *services.CollabService
+	checkers      []middleware.TokenChecker
+}
+
+// NewCollabController checkers 与认证中间件使用的检查相同，用于在连接期间重新检查登录令牌
+func NewCollabController(db *gorm.DB, checkers ...middleware.TokenChecker) *CollabController {
+	return &CollabController{
+		collabService: services.NewCollabService(db),
+		checkers:      checkers,
+	}
+}
+
+// collabError 将协同编辑相关错误转换为响应
+func collabError(ctx *gin.Context, err error, notFound string) {
+	switch err {
+	case services.ErrPermissionDenied:
+		ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
+	case services.ErrInvalidCollaborator:
+		ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+	case gorm.ErrRecordNotFound:
+		ctx.JSON(404, response.Error(response.StatusNotFound, notFound))
+	default:
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+	}
+}
+
+// Ticket 签发协同编辑 WebSocket 连接票据，30 秒内有效，只能使用一次
+func (c *CollabController) Ticket(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid article ID"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	if _, err := c.collabService.CheckAccess(userId, articleId); err != nil {
+		collabError(ctx, err, "Article not found")
+		return
+	}
+
+	path := strings.TrimSuffix(ctx.Request.URL.Path, "/ticket")
+	ticket, expiresAt, err := middleware.IssueWebSocketTicket(ctx, path)
+	if err != nil {
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+		return
+	}
+
+	ctx.JSON(200, response.Success(models.CollabTicketResponse{
+		Ticket:    ticket,
+		ExpiresAt: expiresAt,
+	}))
+}
+
+// Connect 建立协同编辑 WebSocket 连接
+func (c *CollabController) Connect(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid article ID"))
+		return
+	}
+
+	// 升级连接前检查权限，以便返回普通的HTTP错误
+	userId := ctx.GetInt("user_id")
+	user, err := c.collabService.CheckAccess(userId, articleId)
+	if err != nil {
+		collabError(ctx, err, "Article not found")
+		return
+	}
+
+	conn, err := collabUpgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		// Upgrade 已经写入了错误响应
+		return
+	}
+	defer conn.Close()
+
+	client, err := c.collabService.Join(articleId, user)
+	if err != nil {
+		conn.WriteJSON(models.CollabMessage{Type: models.CollabMessageError, Message: err.Error()})
+		return
+	}
+	defer client.Leave()
+
+	done := make(chan struct{})
+	defer close(done)
+	go c.watchToken(middleware.GetClaims(ctx), client, done)
+	go collabWritePump(conn, client)
+
+	conn.SetReadLimit(collabMaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(collabPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(collabPongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var message models.CollabMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			client.SendError(err)
+			continue
+		}
+		if err := client.Handle(&message); err != nil {
+			client.SendError(err)
+		}
+	}
+}
+
+// collabWritePump 将发送队列中的消息写入连接，并定期发送 ping 保持连接；
+// 发送队列关闭（离开会话或被移除）时关闭连接，读循环随之退出
+func collabWritePump(conn *websocket.Conn, client *services.CollabClient) {
+	ticker := time.NewTicker(collabPingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case data, ok := <-client.Send:
+			conn.SetWriteDeadline(time.Now().Add(collabWriteWait))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(collabWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// watchToken 定期重新检查建立连接时使用的登录令牌，令牌失效时断开连接
+func (c *CollabController) watchToken(claims *utils.JwtClaims, client *services.CollabClient, done <-chan struct{}) {
+	ticker := time.NewTicker(collabRecheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, checker := range c.checkers {
+				if err := checker(claims); err != nil {
+					client.Disconnect(err)
+					return
+				}
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// AddCollaborator 添加协作者
+func (c *CollabController) AddCollaborator(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid article ID"))
+		return
+	}
+
+	var request models.AddCollaboratorRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	collaborator, err := c.collabService.AddCollaborator(userId, articleId, request.UserId)
+	if err != nil {
+		collabError(ctx, err, "Article not found")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Add collaborator successfully", collaborator))
+}
+
+// ListCollaborators 获取协作者列表
+func (c *CollabController) ListCollaborators(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid article ID"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	collaborators, err := c.collabService.ListCollaborators(userId, articleId)
+	if err != nil {
+		collabError(ctx, err, "Article not found")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Get collaborators successfully", collaborators))
+}
+
+// RemoveCollaborator 移除协作者
+func (c *CollabController) RemoveCollaborator(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid article ID"))
+		return
+	}
+	collaboratorId, err := strconv.Atoi(ctx.Param("userId"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid user ID"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	if err := c.collabService.RemoveCollaborator(userId, articleId, collaboratorId); err != nil {
+		collabError(ctx, err, "Collaborator not found")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Remove collaborator successfully", nil))
+}
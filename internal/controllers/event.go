@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"server/internal/models"
+	"server/internal/services"
+	"server/pkg/events"
+	"server/pkg/response"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// 心跳间隔，防止代理因连接空闲而断开
+	eventHeartbeatPeriod = 15 * time.Second
+	// 客户端断线后的重连等待时间（毫秒）
+	eventRetryMillis = 3000
+)
+
+type EventController struct {
+	eventService *services.EventService
+}
+
+func NewEventController() *EventController {
+	return &EventController{
+		eventService: services.NewEventService(),
+	}
+}
+
+// writeEvent 以 SSE 格式写入一个事件
+func writeEvent(w io.Writer, event *events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Id, event.Type, data)
+	return err
+}
+
+// Stream 站点动态事件流（Server-Sent Events），支持按事件类型、作者和文章过滤，
+// 以及通过 Last-Event-ID 断线续传
+func (c *EventController) Stream(ctx *gin.Context) {
+	var request models.EventStreamRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	// EventSource 重连时通过请求头携带，首次连接可以通过查询参数指定
+	lastEventId := ctx.GetHeader("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = ctx.Query("last_event_id")
+	}
+
+	sub, missed, complete, err := c.eventService.Subscribe(&request, lastEventId)
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+		return
+	}
+	defer sub.Unsubscribe()
+
+	header := ctx.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // 关闭 nginx 缓冲
+	ctx.Status(200)
+
+	w := ctx.Writer
+	fmt.Fprintf(w, "retry: %d\n\n", eventRetryMillis)
+	if !complete {
+		// 断线期间的部分事件已不在缓冲区中，客户端应重新加载数据
+		fmt.Fprint(w, "event: resync\ndata: {}\n\n")
+	}
+	for i := range missed {
+		if err := writeEvent(w, &missed[i]); err != nil {
+			return
+		}
+	}
+	w.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeatPeriod)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			// 订阅因积压过多被关闭，断开连接让客户端续传
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, &event); err != nil {
+				return
+			}
+			w.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			w.Flush()
+		}
+	}
+}
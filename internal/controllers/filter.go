@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"server/internal/models"
+	"server/internal/services"
+	"server/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type FilterController struct {
+	filterService *services.FilterService
+}
+
+func NewFilterController(db *gorm.DB) *FilterController {
+	return &FilterController{
+		filterService: services.NewFilterService(db),
+	}
+}
+
+// ListRules 获取内容过滤规则
+func (c *FilterController) ListRules(ctx *gin.Context) {
+	var request models.FilterRuleListRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	// 设置默认值
+	if request.Page <= 0 {
+		request.Page = 1
+	}
+	if request.Size <= 0 {
+		request.Size = 20
+	}
+
+	userId := ctx.GetInt("user_id")
+	data, err := c.filterService.ListRules(userId, &request)
+	if err != nil {
+		if err == services.ErrPermissionDenied {
+			ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
+			return
+		}
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Get filter rules successfully", data))
+}
+
+// CreateRules 批量添加内容过滤规则
+func (c *FilterController) CreateRules(ctx *gin.Context) {
+	var request models.CreateFilterRulesRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	rules, err := c.filterService.CreateRules(userId, &request)
+	if err != nil {
+		switch err {
+		case services.ErrPermissionDenied:
+			ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
+		case services.ErrInvalidFilterRule:
+			ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+		default:
+			ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+		}
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Create filter rules successfully", rules))
+}
+
+// DeleteRule 删除内容过滤规则
+func (c *FilterController) DeleteRule(ctx *gin.Context) {
+	ruleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid rule ID"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	if err := c.filterService.DeleteRule(userId, ruleId); err != nil {
+		switch err {
+		case services.ErrPermissionDenied:
+			ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
+		case gorm.ErrRecordNotFound:
+			ctx.JSON(404, response.Error(response.StatusNotFound, "Filter rule not found"))
+		default:
+			ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+		}
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Delete filter rule successfully", nil))
+}
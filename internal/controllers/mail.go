@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"server/internal/models"
+	"server/internal/services"
+	"server/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type MailController struct {
+	mailService *services.MailService
+}
+
+func NewMailController(db *gorm.DB) *MailController {
+	return &MailController{
+		mailService: services.NewMailService(db),
+	}
+}
+
+// mailError 将邮件相关错误转换为响应
+func mailError(ctx *gin.Context, err error, notFound string) {
+	switch err {
+	case services.ErrPermissionDenied:
+		ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
+	case services.ErrInvalidEmail, services.ErrEmailNotFailed:
+		ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+	case gorm.ErrRecordNotFound:
+		ctx.JSON(404, response.Error(response.StatusNotFound, notFound))
+	default:
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+	}
+}
+
+// SendTest 发送测试邮件
+func (c *MailController) SendTest(ctx *gin.Context) {
+	var request models.SendTestEmailRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	email, err := c.mailService.SendTest(userId, &request)
+	if err != nil {
+		mailError(ctx, err, "")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Test email queued successfully", email))
+}
+
+// ListOutbox 获取发件箱
+func (c *MailController) ListOutbox(ctx *gin.Context) {
+	var request models.EmailOutboxListRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	// 设置默认值
+	if request.Page <= 0 {
+		request.Page = 1
+	}
+	if request.Size <= 0 {
+		request.Size = 10
+	}
+
+	userId := ctx.GetInt("user_id")
+	data, err := c.mailService.ListOutbox(userId, &request)
+	if err != nil {
+		mailError(ctx, err, "")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Get outbox successfully", data))
+}
+
+// Retry 重新发送失败的邮件
+func (c *MailController) Retry(ctx *gin.Context) {
+	emailId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid email ID"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	email, err := c.mailService.Retry(userId, emailId)
+	if err != nil {
+		mailError(ctx, err, "Email not found")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Email queued successfully", email))
+}
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"server/internal/models"
+	"server/internal/services"
+	"server/pkg/middleware"
+	"server/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// mfaError 将两步验证相关错误转换为响应
+func mfaError(ctx *gin.Context, err error) {
+	switch err {
+	case services.ErrMfaAlreadyEnabled, services.ErrMfaNotEnabled, services.ErrMfaSetupRequired, services.ErrInvalidMfaCode:
+		ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+	case services.ErrInvalidMfaToken:
+		ctx.JSON(401, response.Error(response.StatusUnauthorized, err.Error()))
+	case services.ErrAccountSuspended, services.ErrPasswordResetRequired:
+		ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
+	case services.ErrMfaLocked:
+		ctx.JSON(429, response.Error(response.StatusTooManyRequests, err.Error()))
+	case gorm.ErrRecordNotFound:
+		ctx.JSON(404, response.Error(response.StatusNotFound, "User not found"))
+	default:
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+	}
+}
+
+// LoginMfa 两步验证登录，提交登录时返回的 mfa_token 和验证码
+func (c *UserController) LoginMfa(ctx *gin.Context) {
+	var request models.MfaLoginRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	data, err := c.userService.LoginMfa(&request, middleware.GetClientIP(ctx), ctx.Request.UserAgent())
+	if err != nil {
+		if err == services.ErrInvalidMfaCode {
+			ctx.JSON(401, response.Error(response.StatusUnauthorized, err.Error()))
+			return
+		}
+		mfaError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.Success(data))
+}
+
+// MfaStatus 两步验证状态
+func (c *UserController) MfaStatus(ctx *gin.Context) {
+	data, err := c.userService.MfaStatus(ctx.GetInt("user_id"))
+	if err != nil {
+		mfaError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.Success(data))
+}
+
+// SetupTotp 开始绑定身份验证器
+func (c *UserController) SetupTotp(ctx *gin.Context) {
+	data, err := c.userService.SetupTotp(ctx.GetInt("user_id"))
+	if err != nil {
+		mfaError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.Success(data))
+}
+
+// ConfirmTotp 确认绑定身份验证器，启用两步验证并返回恢复码
+func (c *UserController) ConfirmTotp(ctx *gin.Context) {
+	var request models.MfaCodeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	data, err := c.userService.ConfirmTotp(ctx.GetInt("user_id"), request.Code)
+	if err != nil {
+		mfaError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Two-factor authentication enabled", data))
+}
+
+// DisableTotp 关闭两步验证
+func (c *UserController) DisableTotp(ctx *gin.Context) {
+	var request models.MfaCodeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	if err := c.userService.DisableTotp(ctx.GetInt("user_id"), request.Code); err != nil {
+		mfaError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Two-factor authentication disabled", nil))
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+func (c *UserController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	var request models.MfaCodeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	data, err := c.userService.RegenerateRecoveryCodes(ctx.GetInt("user_id"), request.Code)
+	if err != nil {
+		mfaError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.Success(data))
+}
@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"server/internal/models"
+	"server/internal/services"
+	"server/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ModerationController struct {
+	moderationService *services.ModerationService
+}
+
+func NewModerationController(db *gorm.DB) *ModerationController {
+	return &ModerationController{
+		moderationService: services.NewModerationService(db),
+	}
+}
+
+// moderationError 将审核相关错误转换为响应
+func moderationError(ctx *gin.Context, err error, notFound string) {
+	switch err {
+	case services.ErrPermissionDenied:
+		ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
+	case services.ErrInvalidReportReason, services.ErrAlreadyReported, services.ErrInvalidModerationAction:
+		ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+	case gorm.ErrRecordNotFound:
+		ctx.JSON(404, response.Error(response.StatusNotFound, notFound))
+	default:
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+	}
+}
+
+// Report 举报文章
+func (c *ModerationController) Report(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid article ID"))
+		return
+	}
+
+	var request models.CreateReportRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	report, err := c.moderationService.Report(userId, articleId, &request)
+	if err != nil {
+		moderationError(ctx, err, "Article not found")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Report article successfully", report))
+}
+
+// ListReports 审核队列
+func (c *ModerationController) ListReports(ctx *gin.Context) {
+	var request models.ReportListRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	// 设置默认值
+	if request.Page <= 0 {
+		request.Page = 1
+	}
+	if request.Size <= 0 {
+		request.Size = 10
+	}
+
+	userId := ctx.GetInt("user_id")
+	data, err := c.moderationService.ListReports(userId, &request)
+	if err != nil {
+		moderationError(ctx, err, "")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Get reports successfully", data))
+}
+
+// DismissReport 驳回举报
+func (c *ModerationController) DismissReport(ctx *gin.Context) {
+	reportId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid report ID"))
+		return
+	}
+
+	var request models.DismissReportRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	log, err := c.moderationService.DismissReport(userId, reportId, request.Note)
+	if err != nil {
+		moderationError(ctx, err, "Report not found")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Dismiss report successfully", log))
+}
+
+// Moderate 对文章执行审核操作
+func (c *ModerationController) Moderate(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid article ID"))
+		return
+	}
+
+	var request models.ModerateArticleRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	log, err := c.moderationService.Moderate(userId, articleId, &request)
+	if err != nil {
+		moderationError(ctx, err, "Article not found")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Moderate article successfully", log))
+}
+
+// ListLogs 审核日志
+func (c *ModerationController) ListLogs(ctx *gin.Context) {
+	var request models.ModerationLogListRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	// 设置默认值
+	if request.Page <= 0 {
+		request.Page = 1
+	}
+	if request.Size <= 0 {
+		request.Size = 10
+	}
+
+	userId := ctx.GetInt("user_id")
+	data, err := c.moderationService.ListLogs(userId, &request)
+	if err != nil {
+		moderationError(ctx, err, "")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Get moderation logs successfully", data))
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"server/internal/models"
+	"server/internal/services"
+	"server/pkg/middleware"
+	"server/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type OidcController struct {
+	oidcService *services.OidcService
+}
+
+func NewOidcController(db *gorm.DB) *OidcController {
+	return &OidcController{
+		oidcService: services.NewOidcService(db),
+	}
+}
+
+// oidcError 将第三方登录相关错误转换为响应
+func oidcError(ctx *gin.Context, err error) {
+	switch err {
+	case services.ErrOidcProviderNotFound:
+		ctx.JSON(404, response.Error(response.StatusNotFound, err.Error()))
+	case services.ErrOidcInvalidState, services.ErrOidcEmailNotVerified, services.ErrOidcEmailConflict:
+		ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+	case services.ErrOidcLoginFailed:
+		ctx.JSON(401, response.Error(response.StatusUnauthorized, err.Error()))
+	case services.ErrAccountSuspended, services.ErrPasswordResetRequired:
+		ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
+	case services.ErrMfaLocked:
+		ctx.JSON(429, response.Error(response.StatusTooManyRequests, err.Error()))
+	default:
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+	}
+}
+
+// Providers 可用的第三方登录方式
+func (c *OidcController) Providers(ctx *gin.Context) {
+	ctx.JSON(200, response.Success(c.oidcService.Providers()))
+}
+
+// Authorize 发起第三方登录，返回身份提供方的授权地址
+func (c *OidcController) Authorize(ctx *gin.Context) {
+	data, err := c.oidcService.Authorize(ctx.Request.Context(), ctx.Param("provider"))
+	if err != nil {
+		oidcError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.Success(data))
+}
+
+// Callback 完成第三方登录，响应与密码登录相同
+func (c *OidcController) Callback(ctx *gin.Context) {
+	var request models.OidcCallbackRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	data, err := c.oidcService.Callback(ctx.Request.Context(), ctx.Param("provider"), &request, middleware.GetClientIP(ctx), ctx.Request.UserAgent())
+	if err != nil {
+		oidcError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.Success(data))
+}
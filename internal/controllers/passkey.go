@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"server/internal/models"
+	"server/internal/services"
+	"server/pkg/middleware"
+	"server/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// passkeyError 将通行密钥相关错误转换为响应
+func passkeyError(ctx *gin.Context, err error) {
+	switch err {
+	case services.ErrInvalidPasskeyName, services.ErrTooManyPasskeys, services.ErrPasskeyVerification, services.ErrPasskeyExists:
+		ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+	case services.ErrPasskeyLoginFailed:
+		ctx.JSON(401, response.Error(response.StatusUnauthorized, err.Error()))
+	case services.ErrAccountSuspended, services.ErrPasswordResetRequired, services.ErrIncorrectPassword:
+		ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
+	case services.ErrPasskeyNotFound:
+		ctx.JSON(404, response.Error(response.StatusNotFound, err.Error()))
+	case gorm.ErrRecordNotFound:
+		ctx.JSON(404, response.Error(response.StatusNotFound, "User not found"))
+	default:
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+	}
+}
+
+// PasskeyLoginOptions 通行密钥登录选项，传给 navigator.credentials.get
+func (c *UserController) PasskeyLoginOptions(ctx *gin.Context) {
+	data, err := c.userService.PasskeyLoginOptions()
+	if err != nil {
+		passkeyError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.Success(data))
+}
+
+// LoginWithPasskey 使用通行密钥登录，响应与密码登录相同
+func (c *UserController) LoginWithPasskey(ctx *gin.Context) {
+	var request models.PasskeyLoginRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	data, err := c.userService.LoginWithPasskey(&request, middleware.GetClientIP(ctx), ctx.Request.UserAgent())
+	if err != nil {
+		passkeyError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.Success(data))
+}
+
+// ListPasskeys 当前用户的通行密钥列表
+func (c *UserController) ListPasskeys(ctx *gin.Context) {
+	data, err := c.userService.ListPasskeys(ctx.GetInt("user_id"))
+	if err != nil {
+		passkeyError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.Success(data))
+}
+
+// PasskeyRegisterOptions 注册通行密钥的选项，传给 navigator.credentials.create
+func (c *UserController) PasskeyRegisterOptions(ctx *gin.Context) {
+	data, err := c.userService.PasskeyRegisterOptions(ctx.GetInt("user_id"))
+	if err != nil {
+		passkeyError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.Success(data))
+}
+
+// RegisterPasskey 保存认证器创建的通行密钥
+func (c *UserController) RegisterPasskey(ctx *gin.Context) {
+	var request models.RegisterPasskeyRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	data, err := c.userService.RegisterPasskey(ctx.GetInt("user_id"), &request)
+	if err != nil {
+		passkeyError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Register passkey successfully", data))
+}
+
+// RenamePasskey 重命名通行密钥
+func (c *UserController) RenamePasskey(ctx *gin.Context) {
+	passkeyId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid passkey ID"))
+		return
+	}
+	var request models.RenamePasskeyRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	data, err := c.userService.RenamePasskey(ctx.GetInt("user_id"), passkeyId, request.Name)
+	if err != nil {
+		passkeyError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Rename passkey successfully", data))
+}
+
+// DeletePasskey 删除通行密钥
+func (c *UserController) DeletePasskey(ctx *gin.Context) {
+	passkeyId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid passkey ID"))
+		return
+	}
+
+	if err := c.userService.DeletePasskey(ctx.GetInt("user_id"), passkeyId); err != nil {
+		passkeyError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Delete passkey successfully", nil))
+}
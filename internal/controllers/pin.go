@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"server/internal/models"
+	"server/internal/services"
+	"server/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PinController struct {
+	pinService *services.PinService
+}
+
+func NewPinController(db *gorm.DB) *PinController {
+	return &PinController{
+		pinService: services.NewPinService(db),
+	}
+}
+
+// Pin 置顶文章
+func (c *PinController) Pin(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid article ID"))
+		return
+	}
+
+	var request models.PinArticleRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	pin, err := c.pinService.Pin(userId, articleId, &request)
+	if err != nil {
+		switch err {
+		case services.ErrPermissionDenied:
+			ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
+		case gorm.ErrRecordNotFound:
+			ctx.JSON(404, response.Error(response.StatusNotFound, "Article not found"))
+		default:
+			ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+		}
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Pin article successfully", pin))
+}
+
+// Unpin 取消置顶
+func (c *PinController) Unpin(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid article ID"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	err = c.pinService.Unpin(userId, articleId, ctx.Query("tag"))
+	if err != nil {
+		switch err {
+		case services.ErrPermissionDenied:
+			ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
+		case gorm.ErrRecordNotFound:
+			ctx.JSON(404, response.Error(response.StatusNotFound, "Pin not found"))
+		default:
+			ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+		}
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Unpin article successfully", nil))
+}
+
+// List 获取置顶列表
+func (c *PinController) List(ctx *gin.Context) {
+	var request models.PinListRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	pins, err := c.pinService.List(userId, request.Tag)
+	if err != nil {
+		if err == services.ErrPermissionDenied {
+			ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
+			return
+		}
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Get pins successfully", pins))
+}
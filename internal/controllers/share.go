@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"server/internal/models"
+	"server/internal/services"
+	"server/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ShareController struct {
+	shareService *services.ShareService
+}
+
+func NewShareController(db *gorm.DB) *ShareController {
+	return &ShareController{
+		shareService: services.NewShareService(db),
+	}
+}
+
+// shareError 将分享链接相关错误转换为响应
+func shareError(ctx *gin.Context, err error, notFound string) {
+	switch err {
+	case services.ErrPermissionDenied, services.ErrIncorrectArticlePassword:
+		ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
+	case services.ErrInvalidShareExpiry, services.ErrArticleNotProtected:
+		ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+	case gorm.ErrRecordNotFound:
+		ctx.JSON(404, response.Error(response.StatusNotFound, notFound))
+	default:
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+	}
+}
+
+// Create 创建分享链接
+func (c *ShareController) Create(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid article ID"))
+		return
+	}
+
+	var request models.CreateShareRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	share, err := c.shareService.Create(userId, articleId, &request)
+	if err != nil {
+		shareError(ctx, err, "Article not found")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Create share link successfully", share))
+}
+
+// List 获取文章的分享链接
+func (c *ShareController) List(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid article ID"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	shares, err := c.shareService.List(userId, articleId)
+	if err != nil {
+		shareError(ctx, err, "Article not found")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Get share links successfully", shares))
+}
+
+// Revoke 撤销分享链接
+func (c *ShareController) Revoke(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid article ID"))
+		return
+	}
+	shareId, err := strconv.Atoi(ctx.Param("shareId"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid share ID"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	if err := c.shareService.Revoke(userId, articleId, shareId); err != nil {
+		shareError(ctx, err, "Share link not found")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Revoke share link successfully", nil))
+}
+
+// GetShared 通过分享链接获取文章
+func (c *ShareController) GetShared(ctx *gin.Context) {
+	article, err := c.shareService.GetByToken(ctx.Param("token"), articleAccessToken(ctx))
+	if err != nil {
+		shareError(ctx, err, "Article not found")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Get article successfully", article))
+}
+
+// Unlock 通过分享链接使用密码解锁文章
+func (c *ShareController) Unlock(ctx *gin.Context) {
+	var request models.UnlockArticleRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	data, err := c.shareService.Unlock(ctx.Param("token"), request.Password)
+	if err != nil {
+		shareError(ctx, err, "Article not found")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Unlock article successfully", data))
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"server/internal/models"
+	"server/internal/services"
+	"server/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SpamController struct {
+	spamService *services.SpamService
+}
+
+func NewSpamController(db *gorm.DB) *SpamController {
+	return &SpamController{
+		spamService: services.NewSpamService(db),
+	}
+}
+
+// spamError 将垃圾检测相关错误转换为响应
+func spamError(ctx *gin.Context, err error, notFound string) {
+	switch err {
+	case services.ErrPermissionDenied:
+		ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
+	case services.ErrInvalidSpamLabel:
+		ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+	case gorm.ErrRecordNotFound:
+		ctx.JSON(404, response.Error(response.StatusNotFound, notFound))
+	default:
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+	}
+}
+
+// mark 标注文章或用户
+func (c *SpamController) mark(ctx *gin.Context, kind string, notFound string) {
+	targetId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid ID"))
+		return
+	}
+
+	var request models.MarkSpamRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	sample, err := c.spamService.Mark(userId, kind, targetId, request.Label)
+	if err != nil {
+		spamError(ctx, err, notFound)
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Mark successfully", sample))
+}
+
+// MarkArticle 标注文章为正常/垃圾
+func (c *SpamController) MarkArticle(ctx *gin.Context) {
+	c.mark(ctx, models.SpamKindArticle, "Article not found")
+}
+
+// MarkUser 标注用户为正常/垃圾
+func (c *SpamController) MarkUser(ctx *gin.Context) {
+	c.mark(ctx, models.SpamKindUser, "User not found")
+}
+
+// ListChecks 获取垃圾检测记录
+func (c *SpamController) ListChecks(ctx *gin.Context) {
+	var request models.SpamCheckListRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	// 设置默认值
+	if request.Page <= 0 {
+		request.Page = 1
+	}
+	if request.Size <= 0 {
+		request.Size = 10
+	}
+
+	userId := ctx.GetInt("user_id")
+	data, err := c.spamService.ListChecks(userId, &request)
+	if err != nil {
+		spamError(ctx, err, "")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Get spam checks successfully", data))
+}
+
+// Stats 获取分类器状态
+func (c *SpamController) Stats(ctx *gin.Context) {
+	userId := ctx.GetInt("user_id")
+	stats, err := c.spamService.Stats(userId)
+	if err != nil {
+		spamError(ctx, err, "")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Get spam stats successfully", stats))
+}
+
+// Retrain 重新训练分类器
+func (c *SpamController) Retrain(ctx *gin.Context) {
+	userId := ctx.GetInt("user_id")
+	stats, err := c.spamService.Retrain(userId)
+	if err != nil {
+		spamError(ctx, err, "")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Retrain successfully", stats))
+}
@@ -1,8 +1,11 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"server/internal/models"
 	"server/internal/services"
+	"server/pkg/middleware"
 	"server/pkg/response"
 	"strconv"
 
@@ -28,7 +31,7 @@ func (c *UserController) Register(ctx *gin.Context) {
 		return
 	}
 
-	baseUser, err := c.userService.Register(&request)
+	baseUser, err := c.userService.Register(&request, middleware.GetClientIP(ctx))
 	if err != nil {
 		ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
 		return
@@ -45,8 +48,16 @@ func (c *UserController) Login(ctx *gin.Context) {
 		return
 	}
 
-	data, err := c.userService.Login(&request)
+	data, err := c.userService.Login(&request, middleware.GetClientIP(ctx), ctx.Request.UserAgent())
 	if err != nil {
+		if err == services.ErrAccountSuspended || err == services.ErrPasswordResetRequired {
+			ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
+			return
+		}
+		if err == services.ErrMfaLocked {
+			ctx.JSON(429, response.Error(response.StatusTooManyRequests, err.Error()))
+			return
+		}
 		ctx.JSON(401, response.Error(response.StatusUnauthorized, err.Error()))
 		return
 	}
@@ -54,6 +65,292 @@ func (c *UserController) Login(ctx *gin.Context) {
 	ctx.JSON(200, response.Success(data))
 }
 
+// Refresh 使用刷新令牌换取新的登录令牌
+func (c *UserController) Refresh(ctx *gin.Context) {
+	var request models.RefreshTokenRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	data, err := c.userService.Refresh(request.RefreshToken)
+	if err != nil {
+		if err == services.ErrInvalidRefreshToken || err == services.ErrRefreshTokenReused || err == services.ErrAccountSuspended || err == services.ErrPasswordResetRequired {
+			ctx.JSON(401, response.Error(response.StatusUnauthorized, err.Error()))
+			return
+		}
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+		return
+	}
+
+	ctx.JSON(200, response.Success(data))
+}
+
+// Logout 退出登录，当前令牌立即失效
+func (c *UserController) Logout(ctx *gin.Context) {
+	var request models.LogoutRequest
+	// 请求体可选
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+			return
+		}
+	}
+
+	if err := c.userService.Logout(middleware.GetClaims(ctx), request.RefreshToken); err != nil {
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Logout successfully", nil))
+}
+
+// LogoutAll 退出所有设备
+func (c *UserController) LogoutAll(ctx *gin.Context) {
+	if err := c.userService.LogoutAll(ctx.GetInt("user_id")); err != nil {
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Logged out from all devices", nil))
+}
+
+// ListSessions 当前用户的登录会话（设备）列表
+func (c *UserController) ListSessions(ctx *gin.Context) {
+	sessions, err := c.userService.ListSessions(ctx.GetInt("user_id"), middleware.GetClaims(ctx).SessionId)
+	if err != nil {
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+		return
+	}
+
+	ctx.JSON(200, response.Success(sessions))
+}
+
+// RevokeSession 移除某个登录会话，该设备上的登录状态立即失效
+func (c *UserController) RevokeSession(ctx *gin.Context) {
+	sessionId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid session ID"))
+		return
+	}
+
+	if err := c.userService.RevokeSession(ctx.GetInt("user_id"), sessionId); err != nil {
+		if err == services.ErrSessionNotFound {
+			ctx.JSON(404, response.Error(response.StatusNotFound, err.Error()))
+			return
+		}
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Revoke session successfully", nil))
+}
+
+// VerifyEmail 验证邮箱
+func (c *UserController) VerifyEmail(ctx *gin.Context) {
+	var request models.VerifyEmailRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	user, err := c.userService.VerifyEmail(request.Token)
+	if err != nil {
+		if err == services.ErrInvalidUserToken {
+			ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+			return
+		}
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Verify email successfully", user))
+}
+
+// ResendVerification 重新发送验证邮件
+func (c *UserController) ResendVerification(ctx *gin.Context) {
+	userId := ctx.GetInt("user_id")
+	if err := c.userService.ResendVerification(userId); err != nil {
+		switch err {
+		case services.ErrEmailAlreadyVerified:
+			ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+		case services.ErrVerificationTooFrequent:
+			ctx.JSON(429, response.Error(response.StatusTooManyRequests, err.Error()))
+		default:
+			ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+		}
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Verification email sent successfully", nil))
+}
+
+// ForgotPassword 忘记密码，无论邮箱是否注册都返回相同的结果
+func (c *UserController) ForgotPassword(ctx *gin.Context) {
+	var request models.ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	c.userService.ForgotPassword(request.Email)
+
+	ctx.JSON(200, response.SuccessWithMessage("If the email is registered, a password reset email has been sent", nil))
+}
+
+// ResetPassword 重置密码
+func (c *UserController) ResetPassword(ctx *gin.Context) {
+	var request models.ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	if err := c.userService.ResetPassword(&request); err != nil {
+		if err == services.ErrInvalidUserToken || err == services.ErrPasswordMismatch {
+			ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+			return
+		}
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Reset password successfully", nil))
+}
+
+// accountError 将账号设置相关错误转换为响应
+func accountError(ctx *gin.Context, err error) {
+	switch err {
+	case services.ErrPasswordMismatch, services.ErrInvalidEmail, services.ErrSameEmail, services.ErrEmailExists, services.ErrInvalidUserToken:
+		ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+	case services.ErrIncorrectPassword:
+		ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
+	case services.ErrVerificationTooFrequent:
+		ctx.JSON(429, response.Error(response.StatusTooManyRequests, err.Error()))
+	case gorm.ErrRecordNotFound:
+		ctx.JSON(404, response.Error(response.StatusNotFound, "User not found"))
+	default:
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+	}
+}
+
+// ChangePassword 修改密码，返回新的 token，其他设备上的登录状态失效
+func (c *UserController) ChangePassword(ctx *gin.Context) {
+	var request models.ChangePasswordRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	data, err := c.userService.ChangePassword(userId, middleware.GetClaims(ctx).SessionId, &request)
+	if err != nil {
+		accountError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Change password successfully", data))
+}
+
+// ChangeEmail 申请修改邮箱，确认邮件发送到新邮箱
+func (c *UserController) ChangeEmail(ctx *gin.Context) {
+	var request models.ChangeEmailRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	if err := c.userService.ChangeEmail(userId, &request); err != nil {
+		accountError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Confirmation email sent to the new address", nil))
+}
+
+// ConfirmEmailChange 确认修改邮箱
+func (c *UserController) ConfirmEmailChange(ctx *gin.Context) {
+	var request models.ConfirmEmailChangeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	user, err := c.userService.ConfirmEmailChange(request.Token)
+	if err != nil {
+		accountError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Change email successfully", user))
+}
+
+// GetProfile 获取当前登录用户的资料
+func (c *UserController) GetProfile(ctx *gin.Context) {
+	user, err := c.userService.GetUserById(ctx.GetInt("user_id"))
+	if err != nil {
+		if err.Error() == "user not found" {
+			ctx.JSON(404, response.Error(response.StatusNotFound, err.Error()))
+			return
+		}
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Get profile successfully", user))
+}
+
+// UpdateProfile 编辑个人资料
+func (c *UserController) UpdateProfile(ctx *gin.Context) {
+	var request models.UpdateProfileRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	user, err := c.userService.UpdateProfile(ctx.GetInt("user_id"), &request)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidProfile) {
+			ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+			return
+		}
+		accountError(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Update profile successfully", user))
+}
+
+// Avatar 用户默认头像（PNG），内容只和用户ID有关，可以长期缓存
+func (c *UserController) Avatar(ctx *gin.Context) {
+	userId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid user ID"))
+		return
+	}
+
+	etag := fmt.Sprintf(`"identicon-%d"`, userId)
+	if ctx.GetHeader("If-None-Match") == etag {
+		ctx.Status(304)
+		return
+	}
+
+	data, err := c.userService.Avatar(userId)
+	if err != nil {
+		if err.Error() == "user not found" {
+			ctx.JSON(404, response.Error(response.StatusNotFound, err.Error()))
+			return
+		}
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+		return
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=86400")
+	ctx.Header("ETag", etag)
+	ctx.Data(200, "image/png", data)
+}
+
 // GetUserById 根据ID获取用户信息
 func (c *UserController) GetUserById(ctx *gin.Context) {
 	userId, err := strconv.Atoi(ctx.Param("id"))
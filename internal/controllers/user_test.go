@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"server/internal/models"
+	"server/pkg/utils"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB 创建只在当前测试中使用的 SQLite 数据库并迁移指定的表
+func newTestDB(t *testing.T, tables ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")+"?_busy_timeout=5000"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("database handle: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func TestLoginRecordsClientIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	tests := []struct {
+		name       string
+		trusted    []string
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{name: "direct connection", remoteAddr: "203.0.113.7:52100", want: "203.0.113.7"},
+		{name: "spoofed header without trusted proxy", remoteAddr: "203.0.113.7:52100", forwarded: "198.51.100.1", want: "203.0.113.7"},
+		{name: "behind trusted proxy", trusted: []string{"10.0.0.0/8"}, remoteAddr: "10.0.0.2:40000", forwarded: "198.51.100.1, 203.0.113.7", want: "203.0.113.7"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := newTestDB(t, &models.User{}, &models.Session{}, &models.RefreshToken{}, &models.MfaChallenge{})
+			password, err := utils.HashPassword("secret-password")
+			if err != nil {
+				t.Fatal(err)
+			}
+			user := models.User{Username: "alice", Email: "alice@example.com", Password: password, Role: models.RoleAuthor}
+			if err := db.Create(&user).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			router := gin.New()
+			if err := router.SetTrustedProxies(test.trusted); err != nil {
+				t.Fatal(err)
+			}
+			router.POST("/login", NewUserController(db).Login)
+
+			request := httptest.NewRequest("POST", "/login", strings.NewReader(`{"email":"alice@example.com","password":"secret-password"}`))
+			request.Header.Set("Content-Type", "application/json")
+			request.Header.Set("User-Agent", "test-agent")
+			request.RemoteAddr = test.remoteAddr
+			if test.forwarded != "" {
+				request.Header.Set("X-Forwarded-For", test.forwarded)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			if recorder.Code != 200 {
+				t.Fatalf("login status %d: %s", recorder.Code, recorder.Body.String())
+			}
+
+			var session models.Session
+			if err := db.Where("user_id = ?", user.Id).First(&session).Error; err != nil {
+				t.Fatalf("session: %v", err)
+			}
+			if session.Ip != test.want || session.UserAgent != "test-agent" {
+				t.Errorf("session ip = %q, user agent = %q, want ip %q", session.Ip, session.UserAgent, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"server/internal/models"
+	"server/internal/services"
+	"server/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WebhookController struct {
+	webhookService *services.WebhookService
+}
+
+func NewWebhookController(db *gorm.DB) *WebhookController {
+	return &WebhookController{
+		webhookService: services.NewWebhookService(db),
+	}
+}
+
+// webhookError 将 webhook 相关错误转换为响应
+func webhookError(ctx *gin.Context, err error, notFound string) {
+	switch err {
+	case services.ErrPermissionDenied:
+		ctx.JSON(403, response.Error(response.StatusForbidden, err.Error()))
+	case services.ErrInvalidWebhookUrl, services.ErrInvalidWebhookEvent:
+		ctx.JSON(400, response.Error(response.StatusBadRequest, err.Error()))
+	case gorm.ErrRecordNotFound:
+		ctx.JSON(404, response.Error(response.StatusNotFound, notFound))
+	default:
+		ctx.JSON(500, response.Error(response.StatusInternalError, err.Error()))
+	}
+}
+
+// List 获取 webhook 列表
+func (c *WebhookController) List(ctx *gin.Context) {
+	userId := ctx.GetInt("user_id")
+	webhooks, err := c.webhookService.List(userId)
+	if err != nil {
+		webhookError(ctx, err, "")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Get webhooks successfully", webhooks))
+}
+
+// Create 创建 webhook
+func (c *WebhookController) Create(ctx *gin.Context) {
+	var request models.CreateWebhookRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	webhook, err := c.webhookService.Create(userId, &request)
+	if err != nil {
+		webhookError(ctx, err, "")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Create webhook successfully", webhook))
+}
+
+// Update 更新 webhook
+func (c *WebhookController) Update(ctx *gin.Context) {
+	webhookId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid webhook ID"))
+		return
+	}
+
+	var request models.UpdateWebhookRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	webhook, err := c.webhookService.Update(userId, webhookId, &request)
+	if err != nil {
+		webhookError(ctx, err, "Webhook not found")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Update webhook successfully", webhook))
+}
+
+// Delete 删除 webhook
+func (c *WebhookController) Delete(ctx *gin.Context) {
+	webhookId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid webhook ID"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	if err := c.webhookService.Delete(userId, webhookId); err != nil {
+		webhookError(ctx, err, "Webhook not found")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Delete webhook successfully", nil))
+}
+
+// ListDeliveries 获取投递记录
+func (c *WebhookController) ListDeliveries(ctx *gin.Context) {
+	webhookId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid webhook ID"))
+		return
+	}
+
+	var request models.WebhookDeliveryListRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid request format"))
+		return
+	}
+
+	// 设置默认值
+	if request.Page <= 0 {
+		request.Page = 1
+	}
+	if request.Size <= 0 {
+		request.Size = 10
+	}
+
+	userId := ctx.GetInt("user_id")
+	data, err := c.webhookService.ListDeliveries(userId, webhookId, &request)
+	if err != nil {
+		webhookError(ctx, err, "Webhook not found")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Get webhook deliveries successfully", data))
+}
+
+// Redeliver 重新投递
+func (c *WebhookController) Redeliver(ctx *gin.Context) {
+	webhookId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid webhook ID"))
+		return
+	}
+	deliveryId, err := strconv.Atoi(ctx.Param("deliveryId"))
+	if err != nil {
+		ctx.JSON(400, response.Error(response.StatusBadRequest, "Invalid delivery ID"))
+		return
+	}
+
+	userId := ctx.GetInt("user_id")
+	delivery, err := c.webhookService.Redeliver(userId, webhookId, deliveryId)
+	if err != nil {
+		webhookError(ctx, err, "Delivery not found")
+		return
+	}
+
+	ctx.JSON(200, response.SuccessWithMessage("Redeliver webhook successfully", delivery))
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// 个人访问令牌的权限范围
+const (
+	ScopeArticlesRead  = "articles:read"  // 读取文章（包括自己隐藏和不公开的文章）
+	ScopeArticlesWrite = "articles:write" // 发布、修改、删除文章和自动保存
+	ScopeProfileRead   = "profile:read"   // 读取个人资料
+	ScopeProfileWrite  = "profile:write"  // 编辑个人资料
+)
+
+var AccessTokenScopes = []string{ScopeArticlesRead, ScopeArticlesWrite, ScopeProfileRead, ScopeProfileWrite}
+
+// 个人访问令牌，用于脚本和 CI 调用接口，只保存令牌的哈希
+type PersonalAccessToken struct {
+	Id          int        `gorm:"primarykey;column:id" json:"id"`
+	UserId      int        `gorm:"column:user_id;index" json:"user_id"`
+	Name        string     `gorm:"column:name;type:varchar(100)" json:"name"`
+	TokenHash   string     `gorm:"column:token_hash;type:char(64);uniqueIndex" json:"-"`
+	TokenPrefix string     `gorm:"column:token_prefix;type:varchar(20)" json:"token_prefix"` // 令牌开头的几位，用于在列表中辨认
+	Scopes      []string   `gorm:"column:scopes;type:varchar(255);serializer:json" json:"scopes"`
+	ExpiresAt   *time.Time `gorm:"column:expires_at" json:"expires_at"` // 为空表示永不过期
+	LastUsedAt  *time.Time `gorm:"column:last_used_at" json:"last_used_at"`
+	LastUsedIp  string     `gorm:"column:last_used_ip;type:varchar(64)" json:"last_used_ip"`
+	CreatedAt   time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+// 创建个人访问令牌request
+type CreateAccessTokenRequest struct {
+	Password      string   `json:"password"` // 当前密码
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"` // 有效天数，0 表示永不过期
+}
+
+// 个人访问令牌列表项
+type AccessTokenResponse struct {
+	Id          int        `json:"id"`
+	Name        string     `json:"name"`
+	TokenPrefix string     `json:"token_prefix"`
+	Scopes      []string   `json:"scopes"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	LastUsedIp  string     `json:"last_used_ip"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// 创建个人访问令牌response，令牌只在创建时返回一次
+type CreateAccessTokenResponse struct {
+	AccessTokenResponse
+	Token string `json:"token"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// 管理后台用户信息
+type AdminUser struct {
+	BaseUser
+	Quarantined           bool       `json:"quarantined"`
+	SuspendedAt           *time.Time `json:"suspended_at"`
+	SuspendReason         string     `json:"suspend_reason"`
+	PasswordResetRequired bool       `json:"password_reset_required"`
+	ArticleCount          int        `json:"article_count"`
+	CreatedAt             time.Time  `json:"created_at"`
+}
+
+// 管理后台用户列表request
+type AdminUserListRequest struct {
+	Page   int    `form:"page"`
+	Size   int    `form:"size"`
+	Search string `form:"search"` // 按用户名或邮箱搜索
+	Role   string `form:"role"`
+	Status string `form:"status"` // active 或 suspended，为空表示全部
+}
+
+// 管理后台用户列表response
+type AdminUserListResponse struct {
+	Users []AdminUser `json:"users"`
+	Total int         `json:"total"`
+	Page  int         `json:"page"`
+	Size  int         `json:"size"`
+}
+
+// 管理后台分页request
+type AdminPageRequest struct {
+	Page int `form:"page"`
+	Size int `form:"size"`
+}
+
+// 停用账号request
+type SuspendUserRequest struct {
+	Reason string `json:"reason"`
+}
+
+// 修改角色request
+type UpdateUserRoleRequest struct {
+	Role string `json:"role"`
+}
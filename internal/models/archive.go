@@ -0,0 +1,35 @@
+package models
+
+// 归档统计request
+type ArchiveRequest struct {
+	UserId int    `form:"user_id"` // 按用户ID过滤
+	Tz     string `form:"tz"`      // 时区，如 Asia/Shanghai，默认服务器时区
+}
+
+// 归档文章列表request
+type ArchiveArticlesRequest struct {
+	Page   int    `form:"page"`
+	Size   int    `form:"size"`
+	UserId int    `form:"user_id"`
+	Tz     string `form:"tz"`
+}
+
+// 某月的文章数
+type ArchiveMonth struct {
+	Month int `json:"month"`
+	Count int `json:"count"`
+}
+
+// 某年的文章数及按月明细
+type ArchiveYear struct {
+	Year   int            `json:"year"`
+	Count  int            `json:"count"`
+	Months []ArchiveMonth `json:"months"`
+}
+
+// 归档统计response
+type ArchiveResponse struct {
+	Timezone string        `json:"timezone"`
+	Total    int           `json:"total"`
+	Years    []ArchiveYear `json:"years"`
+}
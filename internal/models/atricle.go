@@ -2,45 +2,81 @@ package models
 
 import "time"
 
+// 文章可见性
+const (
+	VisibilityPublic   = "public"   // 公开，出现在列表中
+	VisibilityUnlisted = "unlisted" // 不公开列出，通过分享链接访问
+	VisibilityPrivate  = "private"  // 私密，仅作者本人或通过分享链接访问
+)
+
+// ValidVisibility 判断可见性取值是否合法
+func ValidVisibility(visibility string) bool {
+	switch visibility {
+	case VisibilityPublic, VisibilityUnlisted, VisibilityPrivate:
+		return true
+	}
+	return false
+}
+
 // UserInfo 用户信息（不含密码）
 type UserInfo struct {
-	Id       int    `json:"id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
+	Id          int          `json:"id"`
+	Username    string       `json:"username"`
+	Email       string       `json:"email"`
+	DisplayName string       `json:"display_name"`
+	Bio         string       `json:"bio"`
+	AvatarUrl   string       `json:"avatar_url"`
+	Website     string       `json:"website"`
+	SocialLinks []SocialLink `json:"social_links"`
 }
 
 type Article struct {
-	Id        int       `gorm:"primarykey;column:id" json:"id"`
-	Title     string    `gorm:"column:title" json:"title"`
-	Content   string    `gorm:"column:content" json:"content"`
-	UserId    int       `gorm:"column:user_id" json:"user_id"`
-	User      User      `gorm:"foreignKey:UserId" json:"-"`
-	UserInfo  UserInfo  `gorm:"-" json:"user"`
-	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
-	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+	Id         int       `gorm:"primarykey;column:id" json:"id"`
+	Title      string    `gorm:"column:title" json:"title"`
+	Content    string    `gorm:"column:content" json:"content"`
+	UserId     int       `gorm:"column:user_id" json:"user_id"`
+	User       User      `gorm:"foreignKey:UserId" json:"-"`
+	UserInfo   UserInfo  `gorm:"-" json:"user"`
+	Tags       []Tag     `gorm:"many2many:article_tags" json:"tags"`
+	Pinned     bool      `gorm:"-" json:"pinned"`
+	Hidden     bool      `gorm:"column:hidden;default:false" json:"hidden"` // 被审核隐藏，仅作者和审核人员可见
+	Visibility string    `gorm:"column:visibility;type:varchar(20);default:public;index" json:"visibility"`
+	Password   string    `gorm:"column:password" json:"-"`   // 文章访问密码哈希，为空表示不需要密码
+	Protected  bool      `gorm:"-" json:"protected"`         // 是否受密码保护
+	Locked     bool      `gorm:"-" json:"locked"`            // 是否未解锁（未解锁时不返回正文）
+	Excerpt    string    `gorm:"-" json:"excerpt,omitempty"` // 未解锁时返回的摘要
+	CreatedAt  time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"column:updated_at" json:"updated_at"`
 }
 
 // 创建帖子request
 type CreateArticleRequest struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
+	Title      string   `json:"title"`
+	Content    string   `json:"content"`
+	Tags       []string `json:"tags"`
+	Visibility string   `json:"visibility"` // 默认 public
+	Password   string   `json:"password"`   // 可选，设置后需要解锁才能查看正文
 }
 
 // 修改帖子request
 type UpdateArticleRequest struct {
-	Id      int    `json:"id"`
-	Title   string `json:"title"`
-	Content string `json:"content"`
+	Id         int      `json:"id"`
+	Title      string   `json:"title"`
+	Content    string   `json:"content"`
+	Tags       []string `json:"tags"`       // 为 nil 时保持原有标签不变
+	Visibility string   `json:"visibility"` // 为空时保持原有可见性不变
+	Password   *string  `json:"password"`   // 为 nil 时保持不变，为空字符串时取消密码
 }
 
 // 帖子列表request
 type ArticleListRequest struct {
-	Page     int    `form:"page"`
-	Size     int    `form:"size"`
-	Search   string `form:"search"`   // 搜索关键词（标题或内容）
-	UserId   int    `form:"user_id"`  // 按用户ID过滤
-	SortBy   string `form:"sort_by"`  // 排序字段: created_at, updated_at, title
-	Order    string `form:"order"`    // 排序方向: asc, desc
+	Page   int    `form:"page"`
+	Size   int    `form:"size"`
+	Search string `form:"search"`  // 搜索关键词（标题或内容）
+	UserId int    `form:"user_id"` // 按用户ID过滤
+	Tag    string `form:"tag"`     // 按标签过滤
+	SortBy string `form:"sort_by"` // 排序字段: created_at, updated_at, title
+	Order  string `form:"order"`   // 排序方向: asc, desc
 }
 
 // 帖子列表response
@@ -50,3 +86,14 @@ type ArticleListResponse struct {
 	Page     int       `json:"page"`
 	Size     int       `json:"size"`
 }
+
+// 解锁文章request
+type UnlockArticleRequest struct {
+	Password string `json:"password"`
+}
+
+// 解锁文章response
+type UnlockArticleResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// 文章自动保存的工作副本，不影响已发布的标题和内容
+type ArticleAutosave struct {
+	Id        int       `gorm:"primarykey;column:id" json:"id"`
+	ArticleId int       `gorm:"column:article_id;index:idx_autosave_article_user" json:"article_id"`
+	UserId    int       `gorm:"column:user_id;index:idx_autosave_article_user" json:"user_id"`
+	Title     string    `gorm:"column:title" json:"title"`
+	Content   string    `gorm:"column:content;type:longtext" json:"content"`
+	Saves     int       `gorm:"column:saves" json:"saves"` // 合并进该记录的自动保存次数
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// 自动保存request
+type AutosaveRequest struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+// 发布工作副本request
+type PublishAutosaveRequest struct {
+	AutosaveId int `form:"autosave_id"` // 可选，发布指定的历史记录，默认发布最新的工作副本
+}
+
+// 工作副本response
+type AutosaveResponse struct {
+	Current *ArticleAutosave  `json:"current"` // 最新的工作副本
+	History []ArticleAutosave `json:"history"` // 历史自动保存（不含正文），按时间倒序
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"server/pkg/ot"
+	"time"
+)
+
+// 协同编辑消息类型
+const (
+	CollabMessageInit      = "init"      // 服务端 -> 客户端：初始文档、版本号和在线协作者
+	CollabMessageOperation = "operation" // 双向：编辑操作
+	CollabMessageAck       = "ack"       // 服务端 -> 客户端：确认收到的操作已应用
+	CollabMessageCursor    = "cursor"    // 双向：光标和选区
+	CollabMessageJoin      = "join"      // 服务端 -> 客户端：协作者加入
+	CollabMessageLeave     = "leave"     // 服务端 -> 客户端：协作者离开
+	CollabMessageSaved     = "saved"     // 服务端 -> 客户端：文档已保存到数据库
+	CollabMessageError     = "error"     // 服务端 -> 客户端：错误
+)
+
+// 文章协作者
+type ArticleCollaborator struct {
+	Id        int       `gorm:"primarykey;column:id" json:"id"`
+	ArticleId int       `gorm:"column:article_id;uniqueIndex:idx_collaborator_article_user" json:"article_id"`
+	UserId    int       `gorm:"column:user_id;uniqueIndex:idx_collaborator_article_user" json:"user_id"`
+	User      User      `gorm:"foreignKey:UserId" json:"-"`
+	UserInfo  UserInfo  `gorm:"-" json:"user"`
+	AddedBy   int       `gorm:"column:added_by" json:"added_by"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+// 添加协作者request
+type AddCollaboratorRequest struct {
+	UserId int `json:"user_id"`
+}
+
+// 协同编辑连接票据response，作为 WebSocket 连接的查询参数 ticket
+type CollabTicketResponse struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// 光标位置，长度按 UTF-16 计算
+type CollabCursor struct {
+	Position     int `json:"position"`
+	SelectionEnd int `json:"selection_end"`
+}
+
+// 在线协作者
+type CollabClientInfo struct {
+	ClientId string        `json:"client_id"`
+	UserId   int           `json:"user_id"`
+	Username string        `json:"username"`
+	Cursor   *CollabCursor `json:"cursor"`
+}
+
+// 协同编辑消息
+type CollabMessage struct {
+	Type      string             `json:"type"`
+	Revision  int                `json:"revision"`
+	Operation *ot.Operation      `json:"operation,omitempty"`
+	Cursor    *CollabCursor      `json:"cursor,omitempty"`
+	ClientId  string             `json:"client_id,omitempty"`
+	UserId    int                `json:"user_id,omitempty"`
+	Username  string             `json:"username,omitempty"`
+	Document  *string            `json:"document,omitempty"`
+	Clients   []CollabClientInfo `json:"clients,omitempty"`
+	Message   string             `json:"message,omitempty"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// 站点事件类型
+const (
+	EventArticleCreated = "article.created"
+	EventArticleUpdated = "article.updated"
+	EventArticleDeleted = "article.deleted"
+	EventUserRegistered = "user.registered"
+)
+
+// 文章事件内容，不包含正文
+type ArticleEvent struct {
+	Id         int       `json:"id"`
+	Title      string    `json:"title"`
+	UserId     int       `json:"user_id"`
+	Username   string    `json:"username"`
+	Tags       []string  `json:"tags"`
+	Visibility string    `json:"visibility"`
+	Protected  bool      `json:"protected"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// 用户事件内容
+type UserEvent struct {
+	Id        int       `json:"id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// 事件流request，多个值用逗号分隔
+type EventStreamRequest struct {
+	Types      string `form:"types"`      // 事件类型，为空表示所有类型
+	AuthorIds  string `form:"author_id"`  // 只接收这些作者的文章事件
+	ArticleIds string `form:"article_id"` // 只接收这些文章的事件
+}
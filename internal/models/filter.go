@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// 过滤规则类型
+const (
+	FilterTypeWord   = "word"   // 违禁词
+	FilterTypeDomain = "domain" // 链接域名黑名单
+)
+
+// 过滤规则动作，优先级 reject > moderate > mask
+const (
+	FilterActionReject   = "reject"   // 拒绝提交
+	FilterActionMask     = "mask"     // 使用 * 替换命中内容
+	FilterActionModerate = "moderate" // 隐藏文章并送入审核队列
+)
+
+// 内容过滤规则
+type FilterRule struct {
+	Id        int       `gorm:"primarykey;column:id" json:"id"`
+	Type      string    `gorm:"column:type;type:varchar(20);uniqueIndex:idx_filter_type_pattern" json:"type"`
+	Pattern   string    `gorm:"column:pattern;type:varchar(255);uniqueIndex:idx_filter_type_pattern" json:"pattern"`
+	Action    string    `gorm:"column:action;type:varchar(20)" json:"action"`
+	CreatedBy int       `gorm:"column:created_by" json:"created_by"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+// 批量添加过滤规则request
+type CreateFilterRulesRequest struct {
+	Type     string   `json:"type"`
+	Action   string   `json:"action"`
+	Patterns []string `json:"patterns"`
+}
+
+// 过滤规则列表request
+type FilterRuleListRequest struct {
+	Page   int    `form:"page"`
+	Size   int    `form:"size"`
+	Type   string `form:"type"`
+	Search string `form:"search"`
+}
+
+// 过滤规则列表response
+type FilterRuleListResponse struct {
+	Rules []FilterRule `json:"rules"`
+	Total int          `json:"total"`
+	Page  int          `json:"page"`
+	Size  int          `json:"size"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// 邮件发送状态
+const (
+	EmailStatusPending = "pending" // 等待发送或重试
+	EmailStatusSent    = "sent"    // 已发送
+	EmailStatusFailed  = "failed"  // 重试次数用尽
+)
+
+// 邮件发件箱，邮件先写入发件箱再由后台协程异步发送，失败时自动重试
+type EmailOutbox struct {
+	Id            int        `gorm:"primarykey;column:id" json:"id"`
+	UserId        int        `gorm:"column:user_id;index" json:"user_id"` // 收件用户，系统邮件为0
+	To            string     `gorm:"column:to_address;type:varchar(255)" json:"to"`
+	Template      string     `gorm:"column:template;type:varchar(50)" json:"template"`
+	Locale        string     `gorm:"column:locale;type:varchar(20)" json:"locale"`
+	Subject       string     `gorm:"column:subject;type:varchar(255)" json:"subject"`
+	TextBody      string     `gorm:"column:text_body;type:text" json:"-"`
+	HtmlBody      string     `gorm:"column:html_body;type:mediumtext" json:"-"`
+	Status        string     `gorm:"column:status;type:varchar(20);index:idx_email_outbox_due" json:"status"`
+	Attempts      int        `gorm:"column:attempts" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;index:idx_email_outbox_due" json:"next_attempt_at"`
+	LastError     string     `gorm:"column:last_error;type:varchar(1024)" json:"last_error"`
+	SentAt        *time.Time `gorm:"column:sent_at" json:"sent_at"`
+	CreatedAt     time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// 发送测试邮件request
+type SendTestEmailRequest struct {
+	Email  string `json:"email"`
+	Locale string `json:"locale"`
+}
+
+// 发件箱列表request
+type EmailOutboxListRequest struct {
+	Page     int    `form:"page"`
+	Size     int    `form:"size"`
+	Status   string `form:"status"`
+	Template string `form:"template"`
+	UserId   int    `form:"user_id"`
+}
+
+// 发件箱列表response
+type EmailOutboxListResponse struct {
+	Emails []EmailOutbox `json:"emails"`
+	Total  int           `json:"total"`
+	Page   int           `json:"page"`
+	Size   int           `json:"size"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// 两步验证的恢复码，只保存哈希，每个只能使用一次。重新生成时旧的恢复码全部删除
+type MfaRecoveryCode struct {
+	Id        int        `gorm:"primarykey;column:id" json:"id"`
+	UserId    int        `gorm:"column:user_id;index" json:"user_id"`
+	CodeHash  string     `gorm:"column:code_hash;type:char(64)" json:"-"`
+	UsedAt    *time.Time `gorm:"column:used_at" json:"used_at"`
+	CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+// 密码或第三方登录成功后等待两步验证的登录，mfa_token 只保存哈希，验证成功后作废
+type MfaChallenge struct {
+	Id           int        `gorm:"primarykey;column:id" json:"id"`
+	UserId       int        `gorm:"column:user_id;index" json:"user_id"`
+	TokenHash    string     `gorm:"column:token_hash;type:char(64);uniqueIndex" json:"-"`
+	TokenVersion int        `gorm:"column:token_version" json:"-"`             // 签发时的令牌版本，期间重置密码等操作后作废
+	Attempts     int        `gorm:"column:attempts;default:0" json:"attempts"` // 已尝试验证的次数，达到上限后作废
+	ExpiresAt    time.Time  `gorm:"column:expires_at;index" json:"expires_at"`
+	UsedAt       *time.Time `gorm:"column:used_at" json:"used_at"`
+	CreatedAt    time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+// 两步验证登录request，code 为身份验证器中的验证码或恢复码
+type MfaLoginRequest struct {
+	MfaToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+// 两步验证状态
+type MfaStatusResponse struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// 开始绑定身份验证器response，前端将 uri 渲染为二维码，无法扫码时手动输入 secret
+type TotpSetupResponse struct {
+	Secret string `json:"secret"`
+	Uri    string `json:"uri"`
+}
+
+// 确认绑定、关闭两步验证、重新生成恢复码时提交的验证码
+type MfaCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// 恢复码response，恢复码只在生成时返回一次
+type MfaRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -0,0 +1,117 @@
+package models
+
+import "time"
+
+// 举报原因
+const (
+	ReportReasonSpam      = "spam"      // 垃圾广告
+	ReportReasonAbuse     = "abuse"     // 辱骂攻击
+	ReportReasonIllegal   = "illegal"   // 违法违规
+	ReportReasonCopyright = "copyright" // 侵权
+	ReportReasonOther     = "other"     // 其他
+	ReportReasonFilter    = "filter"    // 内容过滤自动送审（系统生成，用户不可提交）
+)
+
+// 举报状态
+const (
+	ReportStatusPending   = "pending"   // 待处理
+	ReportStatusResolved  = "resolved"  // 已处理
+	ReportStatusDismissed = "dismissed" // 已驳回
+)
+
+// 审核操作
+const (
+	ModerationActionHide    = "hide"    // 隐藏文章
+	ModerationActionUnhide  = "unhide"  // 取消隐藏
+	ModerationActionDelete  = "delete"  // 删除文章
+	ModerationActionWarn    = "warn"    // 警告作者
+	ModerationActionDismiss = "dismiss" // 驳回举报
+	ModerationActionEdit    = "edit"    // 编辑/管理员修改他人的文章
+)
+
+// ValidReportReason 判断举报原因是否合法
+func ValidReportReason(reason string) bool {
+	switch reason {
+	case ReportReasonSpam, ReportReasonAbuse, ReportReasonIllegal, ReportReasonCopyright, ReportReasonOther:
+		return true
+	}
+	return false
+}
+
+// 文章举报
+type Report struct {
+	Id         int        `gorm:"primarykey;column:id" json:"id"`
+	ArticleId  int        `gorm:"column:article_id;index" json:"article_id"`
+	ReporterId int        `gorm:"column:reporter_id;index" json:"reporter_id"`
+	Reason     string     `gorm:"column:reason;type:varchar(20)" json:"reason"`
+	Detail     string     `gorm:"column:detail;type:text" json:"detail"`
+	Status     string     `gorm:"column:status;type:varchar(20);default:pending;index" json:"status"`
+	ResolvedBy int        `gorm:"column:resolved_by" json:"resolved_by"`
+	ResolvedAt *time.Time `gorm:"column:resolved_at" json:"resolved_at"`
+	CreatedAt  time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+// 审核日志
+type ModerationLog struct {
+	Id          int       `gorm:"primarykey;column:id" json:"id"`
+	ModeratorId int       `gorm:"column:moderator_id;index" json:"moderator_id"`
+	ArticleId   int       `gorm:"column:article_id;index" json:"article_id"`
+	AuthorId    int       `gorm:"column:author_id;index" json:"author_id"`
+	ReportId    int       `gorm:"column:report_id" json:"report_id"`
+	Action      string    `gorm:"column:action;type:varchar(20)" json:"action"`
+	Note        string    `gorm:"column:note;type:text" json:"note"`
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+// 举报文章request
+type CreateReportRequest struct {
+	Reason string `json:"reason"`
+	Detail string `json:"detail"`
+}
+
+// 举报列表request
+type ReportListRequest struct {
+	Page      int    `form:"page"`
+	Size      int    `form:"size"`
+	Status    string `form:"status"`     // 按状态过滤，默认 pending
+	Reason    string `form:"reason"`     // 按原因过滤
+	ArticleId int    `form:"article_id"` // 按文章过滤
+}
+
+// 举报列表response
+type ReportListResponse struct {
+	Reports []Report `json:"reports"`
+	Total   int      `json:"total"`
+	Page    int      `json:"page"`
+	Size    int      `json:"size"`
+}
+
+// 审核操作request
+type ModerateArticleRequest struct {
+	Action   string `json:"action"`    // hide, unhide, delete, warn
+	Note     string `json:"note"`      // 审核备注，警告时会作为警告内容
+	ReportId int    `json:"report_id"` // 可选，关联的举报
+}
+
+// 审核日志列表request
+type ModerationLogListRequest struct {
+	Page        int    `form:"page"`
+	Size        int    `form:"size"`
+	ArticleId   int    `form:"article_id"`
+	AuthorId    int    `form:"author_id"`
+	ModeratorId int    `form:"moderator_id"`
+	Action      string `form:"action"`
+}
+
+// 审核日志列表response
+type ModerationLogListResponse struct {
+	Logs  []ModerationLog `json:"logs"`
+	Total int             `json:"total"`
+	Page  int             `json:"page"`
+	Size  int             `json:"size"`
+}
+
+// 驳回举报request
+type DismissReportRequest struct {
+	Note string `json:"note"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// 第三方登录身份，一个用户可以关联多个身份提供方的账号
+type UserIdentity struct {
+	Id        int       `gorm:"primarykey;column:id" json:"id"`
+	UserId    int       `gorm:"column:user_id;index" json:"user_id"`
+	Provider  string    `gorm:"column:provider;type:varchar(50);uniqueIndex:idx_identity_provider_subject" json:"provider"`
+	Subject   string    `gorm:"column:subject;type:varchar(255);uniqueIndex:idx_identity_provider_subject" json:"subject"` // 身份提供方的用户ID（sub）
+	Email     string    `gorm:"column:email;type:varchar(255)" json:"email"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+// 进行中的第三方登录，保存 state 对应的 nonce 和 PKCE code_verifier，回调时使用一次后删除
+type OidcLoginState struct {
+	Id           int       `gorm:"primarykey;column:id" json:"id"`
+	StateHash    string    `gorm:"column:state_hash;type:char(64);uniqueIndex" json:"-"`
+	Provider     string    `gorm:"column:provider;type:varchar(50)" json:"provider"`
+	Nonce        string    `gorm:"column:nonce;type:varchar(64)" json:"-"`
+	CodeVerifier string    `gorm:"column:code_verifier;type:varchar(128)" json:"-"`
+	ExpiresAt    time.Time `gorm:"column:expires_at;index" json:"expires_at"`
+	CreatedAt    time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+// 可用的身份提供方
+type OidcProviderInfo struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// 发起第三方登录response
+type OidcAuthorizeResponse struct {
+	AuthorizationUrl string `json:"authorization_url"`
+	State            string `json:"state"`
+}
+
+// 第三方登录回调request，code 和 state 从身份提供方重定向到前端的地址中取出
+type OidcCallbackRequest struct {
+	Code  string `json:"code"`
+	State string `json:"state"`
+}
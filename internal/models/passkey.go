@@ -0,0 +1,67 @@
+package models
+
+import (
+	"server/pkg/webauthn"
+	"time"
+)
+
+// WebAuthn challenge 用途
+const (
+	WebauthnPurposeRegister = "register" // 注册通行密钥
+	WebauthnPurposeLogin    = "login"    // 使用通行密钥登录
+)
+
+// 通行密钥（WebAuthn 凭据），可以代替邮箱和密码登录。凭据ID可能很长，按其哈希建立唯一索引
+type Passkey struct {
+	Id             int        `gorm:"primarykey;column:id" json:"id"`
+	UserId         int        `gorm:"column:user_id;index" json:"user_id"`
+	Name           string     `gorm:"column:name;type:varchar(100)" json:"name"`
+	CredentialId   string     `gorm:"column:credential_id;type:text" json:"-"` // base64url
+	CredentialHash string     `gorm:"column:credential_hash;type:char(64);uniqueIndex" json:"-"`
+	PublicKey      []byte     `gorm:"column:public_key;type:blob" json:"-"` // COSE_Key
+	Algorithm      int        `gorm:"column:algorithm" json:"algorithm"`
+	SignCount      int64      `gorm:"column:sign_count;default:0" json:"-"` // 签名计数器，不为 0 时每次登录必须递增
+	Aaguid         string     `gorm:"column:aaguid;type:varchar(36)" json:"aaguid"`
+	Transports     []string   `gorm:"column:transports;type:varchar(255);serializer:json" json:"transports"`
+	BackupEligible bool       `gorm:"column:backup_eligible;default:false" json:"backup_eligible"`
+	BackupState    bool       `gorm:"column:backup_state;default:false" json:"backup_state"`
+	LastUsedAt     *time.Time `gorm:"column:last_used_at" json:"last_used_at"`
+	CreatedAt      time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+// 进行中的通行密钥注册或登录，challenge 只保存哈希，使用一次后删除。登录时 UserId 为 0
+type WebauthnChallenge struct {
+	Id            int       `gorm:"primarykey;column:id" json:"id"`
+	UserId        int       `gorm:"column:user_id;index" json:"user_id"`
+	Purpose       string    `gorm:"column:purpose;type:varchar(20)" json:"purpose"`
+	ChallengeHash string    `gorm:"column:challenge_hash;type:char(64);uniqueIndex" json:"-"`
+	ExpiresAt     time.Time `gorm:"column:expires_at;index" json:"expires_at"`
+	CreatedAt     time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+// 通行密钥列表项
+type PasskeyResponse struct {
+	Id         int        `json:"id"`
+	Name       string     `json:"name"`
+	Synced     bool       `json:"synced"` // 是否已同步备份（如 iCloud 钥匙串、Google 密码管理器）
+	Transports []string   `json:"transports"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// 注册通行密钥request，credential 为 navigator.credentials.create 返回的凭据（toJSON 格式）
+type RegisterPasskeyRequest struct {
+	Password   string                        `json:"password"` // 当前密码
+	Name       string                        `json:"name"`
+	Credential webauthn.RegistrationResponse `json:"credential"`
+}
+
+// 通行密钥登录request，credential 为 navigator.credentials.get 返回的凭据（toJSON 格式）
+type PasskeyLoginRequest struct {
+	Credential webauthn.AssertionResponse `json:"credential"`
+}
+
+// 重命名通行密钥request
+type RenamePasskeyRequest struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// 文章置顶记录，Tag 为空表示全站置顶，否则表示在该标签下置顶
+type ArticlePin struct {
+	Id        int        `gorm:"primarykey;column:id" json:"id"`
+	ArticleId int        `gorm:"column:article_id;uniqueIndex:idx_pin_article_tag" json:"article_id"`
+	Tag       string     `gorm:"column:tag;type:varchar(50);uniqueIndex:idx_pin_article_tag" json:"tag"`
+	Position  int        `gorm:"column:position" json:"position"` // 越小越靠前
+	ExpiresAt *time.Time `gorm:"column:expires_at" json:"expires_at"`
+	PinnedBy  int        `gorm:"column:pinned_by" json:"pinned_by"`
+	CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// 置顶文章request
+type PinArticleRequest struct {
+	Tag       string     `json:"tag"`
+	Position  int        `json:"position"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// 置顶列表request
+type PinListRequest struct {
+	Tag string `form:"tag"`
+}
@@ -0,0 +1,16 @@
+package models
+
+// 社交链接
+type SocialLink struct {
+	Label string `json:"label"` // 如 GitHub、微博
+	Url   string `json:"url"`
+}
+
+// 编辑个人资料request，PUT 语义：未传的字段会被清空
+type UpdateProfileRequest struct {
+	DisplayName string       `json:"display_name"`
+	Bio         string       `json:"bio"`
+	AvatarUrl   string       `json:"avatar_url"` // 为空时使用自动生成的头像
+	Website     string       `json:"website"`
+	SocialLinks []SocialLink `json:"social_links"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// 刷新令牌，只保存 SHA-256 哈希。每次刷新都会换发新的刷新令牌，
+// 同一次登录换发出的令牌属于同一个会话，旧令牌被重复使用时整个会话作废
+type RefreshToken struct {
+	Id           int        `gorm:"primarykey;column:id" json:"id"`
+	UserId       int        `gorm:"column:user_id;index" json:"user_id"`
+	SessionId    int        `gorm:"column:session_id;index" json:"session_id"`
+	TokenHash    string     `gorm:"column:token_hash;type:char(64);uniqueIndex" json:"-"`
+	TokenVersion int        `gorm:"column:token_version" json:"-"` // 签发时用户的令牌版本，重置密码后失效
+	ExpiresAt    time.Time  `gorm:"column:expires_at" json:"expires_at"`
+	UsedAt       *time.Time `gorm:"column:used_at" json:"used_at"`       // 已换发新令牌
+	RevokedAt    *time.Time `gorm:"column:revoked_at" json:"revoked_at"` // 已作废
+	CreatedAt    time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+// 刷新令牌request
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
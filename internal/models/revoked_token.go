@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// 已注销的登录令牌，令牌过期后记录即可删除
+type RevokedToken struct {
+	Jti       string    `gorm:"primarykey;column:jti;type:varchar(64)" json:"jti"`
+	ExpiresAt time.Time `gorm:"column:expires_at;index" json:"expires_at"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+// 退出登录request
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"` // 可选，同时作废该刷新令牌所属的登录
+}
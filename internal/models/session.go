@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// 登录会话，每次登录创建一条。会话内的登录令牌和刷新令牌都带有会话ID，会话作废后全部失效
+type Session struct {
+	Id         int        `gorm:"primarykey;column:id" json:"id"`
+	UserId     int        `gorm:"column:user_id;index" json:"user_id"`
+	UserAgent  string     `gorm:"column:user_agent;type:varchar(512)" json:"user_agent"`
+	Ip         string     `gorm:"column:ip;type:varchar(64)" json:"ip"`
+	CreatedAt  time.Time  `gorm:"column:created_at" json:"created_at"`
+	LastSeenAt time.Time  `gorm:"column:last_seen_at" json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at" json:"expires_at"` // 最新刷新令牌的过期时间
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"revoked_at"`
+}
+
+// 会话列表项
+type SessionResponse struct {
+	Id         int       `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	Ip         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"` // 是否为当前请求所在的会话
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// 文章分享链接，用于在不登录的情况下访问不公开的文章
+type ArticleShare struct {
+	Id         int        `gorm:"primarykey;column:id" json:"id"`
+	ArticleId  int        `gorm:"column:article_id;index" json:"article_id"`
+	Token      string     `gorm:"column:token;type:varchar(64);uniqueIndex" json:"token"`
+	ExpiresAt  *time.Time `gorm:"column:expires_at" json:"expires_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"revoked_at"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at" json:"last_used_at"`
+	CreatedBy  int        `gorm:"column:created_by" json:"created_by"`
+	CreatedAt  time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+// 创建分享链接request
+type CreateShareRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"` // 可选，为空表示永不过期
+}
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// 垃圾检测对象类型
+const (
+	SpamKindArticle = "article" // 文章
+	SpamKindUser    = "user"    // 注册用户
+)
+
+// 样本标注
+const (
+	SpamLabelHam  = "ham"  // 正常
+	SpamLabelSpam = "spam" // 垃圾
+)
+
+// 垃圾检测记录，每篇新文章和每次注册都会生成一条
+type SpamCheck struct {
+	Id          int       `gorm:"primarykey;column:id" json:"id"`
+	Kind        string    `gorm:"column:kind;type:varchar(20);index:idx_spam_check_target" json:"kind"`
+	TargetId    int       `gorm:"column:target_id;index:idx_spam_check_target" json:"target_id"`
+	Ip          string    `gorm:"column:ip;type:varchar(64);index" json:"ip"`
+	Score       float64   `gorm:"column:score" json:"score"`                  // 垃圾概率 0~1
+	Quarantined bool      `gorm:"column:quarantined" json:"quarantined"`      // 是否被自动隔离
+	Label       string    `gorm:"column:label;type:varchar(10)" json:"label"` // 人工标注结果，为空表示未标注
+	Features    string    `gorm:"column:features;type:text" json:"-"`         // 打分时的特征，空格分隔
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+// 训练样本
+type SpamSample struct {
+	Id        int       `gorm:"primarykey;column:id" json:"id"`
+	Kind      string    `gorm:"column:kind;type:varchar(20);uniqueIndex:idx_spam_sample_target" json:"kind"`
+	TargetId  int       `gorm:"column:target_id;uniqueIndex:idx_spam_sample_target" json:"target_id"`
+	Label     string    `gorm:"column:label;type:varchar(10)" json:"label"`
+	Features  string    `gorm:"column:features;type:text" json:"-"`
+	LabeledBy int       `gorm:"column:labeled_by" json:"labeled_by"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// 标注样本request
+type MarkSpamRequest struct {
+	Label string `json:"label"` // ham, spam
+}
+
+// 检测记录列表request
+type SpamCheckListRequest struct {
+	Page        int    `form:"page"`
+	Size        int    `form:"size"`
+	Kind        string `form:"kind"`
+	Quarantined *bool  `form:"quarantined"`
+	Unlabeled   bool   `form:"unlabeled"` // 只看未标注的记录
+}
+
+// 检测记录列表response
+type SpamCheckListResponse struct {
+	Checks []SpamCheck `json:"checks"`
+	Total  int         `json:"total"`
+	Page   int         `json:"page"`
+	Size   int         `json:"size"`
+}
+
+// 分类器状态response
+type SpamStatsResponse struct {
+	HamSamples  int     `json:"ham_samples"`
+	SpamSamples int     `json:"spam_samples"`
+	Threshold   float64 `json:"threshold"`
+	MinSamples  int     `json:"min_samples"`
+	Active      bool    `json:"active"` // 样本数达到要求后才会自动隔离
+}
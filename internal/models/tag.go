@@ -0,0 +1,7 @@
+package models
+
+// 标签
+type Tag struct {
+	Id   int    `gorm:"primarykey;column:id" json:"id"`
+	Name string `gorm:"column:name;type:varchar(50);uniqueIndex" json:"name"`
+}
@@ -1,17 +1,74 @@
 package models
 
+import (
+	"server/pkg/rbac"
+	"time"
+)
+
+// 用户角色，权限定义见 rbac 包
+const (
+	RoleAdmin  = rbac.RoleAdmin  // 管理员
+	RoleEditor = rbac.RoleEditor // 编辑，可管理置顶、审核他人的内容
+	RoleAuthor = rbac.RoleAuthor // 普通作者（注册默认角色）
+	RoleReader = rbac.RoleReader // 读者，不能发布文章
+)
+
 type User struct {
 	Id       int    `gorm:"primarykey;column:id" json:"id"`
 	Username string `gorm:"column:username" json:"username"`
 	Email    string `gorm:"column:email" json:"email"`
 	Password string `gorm:"column:password" json:"password"`
+	Role     string `gorm:"column:role;type:varchar(20);default:author" json:"role"`
+	Locale   string `gorm:"column:locale;type:varchar(20)" json:"locale"` // 邮件语言，为空时使用默认语言
+
+	// 个人资料
+	DisplayName string       `gorm:"column:display_name;type:varchar(50)" json:"display_name"`
+	Bio         string       `gorm:"column:bio;type:varchar(500)" json:"bio"`
+	AvatarUrl   string       `gorm:"column:avatar_url;type:varchar(512)" json:"avatar_url"` // 为空表示使用自动生成的头像
+	Website     string       `gorm:"column:website;type:varchar(512)" json:"website"`
+	SocialLinks []SocialLink `gorm:"column:social_links;type:text;serializer:json" json:"social_links"`
+
+	// 令牌版本，递增后之前签发的登录令牌全部失效
+	TokenVersion int `gorm:"column:token_version;default:0" json:"-"`
+
+	// 被垃圾检测隔离的用户，其新发布的文章会先隐藏并进入审核队列
+	Quarantined bool `gorm:"column:quarantined;default:false" json:"quarantined"`
+
+	// 被管理员停用的账号不能登录，已签发的令牌也会失效
+	SuspendedAt   *time.Time `gorm:"column:suspended_at" json:"suspended_at"`
+	SuspendReason string     `gorm:"column:suspend_reason;type:varchar(255)" json:"suspend_reason"`
+
+	// 管理员要求重置密码，重置前不能使用密码登录
+	PasswordResetRequired bool `gorm:"column:password_reset_required;default:false" json:"password_reset_required"`
+
+	// 两步验证（TOTP）。TotpSecret 在确认前为待启用的密钥，TotpEnabledAt 为空表示未启用；
+	// TotpLastCounter 为最近一次使用的时间步，同一验证码不能重复使用
+	TotpSecret      string     `gorm:"column:totp_secret;type:varchar(64)" json:"-"`
+	TotpEnabledAt   *time.Time `gorm:"column:totp_enabled_at" json:"totp_enabled_at"`
+	TotpLastCounter int64      `gorm:"column:totp_last_counter;default:0" json:"-"`
+	// 两步验证登录连续失败的次数（不区分 mfa_token），达到上限后锁定到 MfaLockedUntil，防止换 IP 重新登录暴力破解验证码
+	MfaFailedAttempts int        `gorm:"column:mfa_failed_attempts;default:0" json:"-"`
+	MfaLockedUntil    *time.Time `gorm:"column:mfa_locked_until" json:"-"`
+
+	// 邮箱验证时间，为空表示未验证
+	EmailVerifiedAt *time.Time `gorm:"column:email_verified_at" json:"email_verified_at"`
+	CreatedAt       time.Time  `gorm:"column:created_at" json:"created_at"`
 }
 
 // 基础用户信息返回
 type BaseUser struct {
-	Id       int    `json:"id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
+	Id            int               `json:"id"`
+	Username      string            `json:"username"`
+	Email         string            `json:"email"`
+	Role          string            `json:"role"`
+	Permissions   []rbac.Permission `json:"permissions"`
+	EmailVerified bool              `json:"email_verified"`
+	MfaEnabled    bool              `json:"mfa_enabled"`
+	DisplayName   string            `json:"display_name"`
+	Bio           string            `json:"bio"`
+	AvatarUrl     string            `json:"avatar_url"`
+	Website       string            `json:"website"`
+	SocialLinks   []SocialLink      `json:"social_links"`
 }
 
 // 用户注册Request
@@ -20,6 +77,32 @@ type RegisterRequest struct {
 	Email          string `json:"email"`
 	Password       string `json:"password"`
 	RepeatPassword string `json:"re_password"`
+	Locale         string `json:"locale"` // 可选，如 zh-CN、en
+}
+
+// 忘记密码request
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// 重置密码request
+type ResetPasswordRequest struct {
+	Token          string `json:"token"`
+	Password       string `json:"password"`
+	RepeatPassword string `json:"re_password"`
+}
+
+// 修改密码request
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	Password        string `json:"password"`
+	RepeatPassword  string `json:"re_password"`
+}
+
+// 修改邮箱request
+type ChangeEmailRequest struct {
+	Password string `json:"password"` // 当前密码
+	Email    string `json:"email"`    // 新邮箱
 }
 
 // 用户登录Request
@@ -28,10 +111,14 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
-// 用户登录response
+// 用户登录response。开启两步验证的用户只返回 mfa_required 和 mfa_token，验证通过后才返回登录令牌
 type LoginResponse struct {
-	Token string   `json:"token"`
-	User  BaseUser `json:"user"`
+	Token        string    `json:"token,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	ExpiresIn    int       `json:"expires_in,omitempty"` // token 的有效期（秒）
+	User         *BaseUser `json:"user,omitempty"`
+	MfaRequired  bool      `json:"mfa_required,omitempty"`
+	MfaToken     string    `json:"mfa_token,omitempty"`
 }
 
 // 用户详情response（包含文章统计）
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// 一次性令牌用途
+const (
+	TokenPurposeVerifyEmail   = "verify_email"   // 邮箱验证
+	TokenPurposeResetPassword = "reset_password" // 重置密码
+	TokenPurposeChangeEmail   = "change_email"   // 确认修改邮箱
+)
+
+// 发给用户的一次性令牌，只保存令牌的 SHA-256 哈希，原始令牌只出现在邮件中
+type UserToken struct {
+	Id        int        `gorm:"primarykey;column:id" json:"id"`
+	UserId    int        `gorm:"column:user_id;index:idx_user_token_purpose" json:"user_id"`
+	Purpose   string     `gorm:"column:purpose;type:varchar(20);index:idx_user_token_purpose" json:"purpose"`
+	TokenHash string     `gorm:"column:token_hash;type:char(64);uniqueIndex" json:"-"`
+	Email     string     `gorm:"column:email;type:varchar(255)" json:"email"`         // 签发时的邮箱，邮箱变更后令牌失效
+	NewEmail  string     `gorm:"column:new_email;type:varchar(255)" json:"new_email"` // 修改邮箱时的新邮箱
+	ExpiresAt time.Time  `gorm:"column:expires_at" json:"expires_at"`
+	UsedAt    *time.Time `gorm:"column:used_at" json:"used_at"`
+	CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+// 邮箱验证request
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// 确认修改邮箱request
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token"`
+}
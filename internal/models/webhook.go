@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// 投递状态
+const (
+	WebhookDeliveryPending = "pending" // 等待投递或重试
+	WebhookDeliverySuccess = "success" // 接收方返回 2xx
+	WebhookDeliveryFailed  = "failed"  // 重试次数用尽
+)
+
+// Webhook 接收事件的外部地址。普通用户的 webhook 只接收自己文章的事件，
+// 管理员可以创建全站 webhook 接收所有事件（包括用户注册）
+type Webhook struct {
+	Id        int       `gorm:"primarykey;column:id" json:"id"`
+	UserId    int       `gorm:"column:user_id;index" json:"user_id"`
+	Url       string    `gorm:"column:url;type:varchar(2048)" json:"url"`
+	Secret    string    `gorm:"column:secret;type:varchar(64)" json:"secret,omitempty"` // 签名密钥，只在创建时返回
+	Events    string    `gorm:"column:events;type:varchar(255)" json:"-"`               // 订阅的事件类型，逗号分隔
+	EventList []string  `gorm:"-" json:"events"`
+	SiteWide  bool      `gorm:"column:site_wide;index" json:"site_wide"`
+	Active    bool      `gorm:"column:active" json:"active"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// Webhook 投递记录，同时作为持久化的投递队列
+type WebhookDelivery struct {
+	Id            int        `gorm:"primarykey;column:id" json:"id"`
+	WebhookId     int        `gorm:"column:webhook_id;index" json:"webhook_id"`
+	EventId       string     `gorm:"column:event_id;type:varchar(64);index" json:"event_id"`
+	EventType     string     `gorm:"column:event_type;type:varchar(50)" json:"event_type"`
+	Payload       string     `gorm:"column:payload;type:text" json:"payload"`
+	Status        string     `gorm:"column:status;type:varchar(20);index:idx_webhook_delivery_due" json:"status"`
+	Attempts      int        `gorm:"column:attempts" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;index:idx_webhook_delivery_due" json:"next_attempt_at"`
+	LastAttemptAt *time.Time `gorm:"column:last_attempt_at" json:"last_attempt_at"`
+	ResponseCode  int        `gorm:"column:response_code" json:"response_code"`
+	ResponseBody  string     `gorm:"column:response_body;type:text" json:"response_body"` // 截断保存
+	Error         string     `gorm:"column:error;type:varchar(1024)" json:"error"`
+	RedeliveryOf  int        `gorm:"column:redelivery_of" json:"redelivery_of"` // 手动重新投递时为原投递记录ID
+	CreatedAt     time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// Webhook 投递内容
+type WebhookPayload struct {
+	Id        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// 创建webhook request
+type CreateWebhookRequest struct {
+	Url      string   `json:"url"`
+	Events   []string `json:"events"`    // 为空表示订阅所有可用的事件
+	SiteWide bool     `json:"site_wide"` // 仅管理员可用
+}
+
+// 更新webhook request，字段为空表示保持不变
+type UpdateWebhookRequest struct {
+	Url    string   `json:"url"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+// 投递记录列表request
+type WebhookDeliveryListRequest struct {
+	Page   int    `form:"page"`
+	Size   int    `form:"size"`
+	Status string `form:"status"`
+}
+
+// 投递记录列表response
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	Total      int               `json:"total"`
+	Page       int               `json:"page"`
+	Size       int               `json:"size"`
+}
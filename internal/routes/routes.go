@@ -2,7 +2,10 @@ package routes
 
 import (
 	"server/internal/controllers"
+	"server/internal/models"
+	"server/internal/services"
 	"server/pkg/middleware"
+	"server/pkg/rbac"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -15,11 +18,54 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 	// 参数：每秒20个请求，突发30个请求，封禁30分钟，5次违规后封禁
 	ipLimiter := middleware.NewIPRateLimiter(
 		rate.Every(50*time.Millisecond), // 每50ms一个请求 = 每秒20个请求
-		30,                                // 突发请求数
-		10*time.Minute,                    // IP封禁时长
-		5,                                 // 最大违规次数
+		30,                              // 突发请求数
+		10*time.Minute,                  // IP封禁时长
+		5,                               // 最大违规次数
 	)
 
+	// 文章密码解锁限流器，按“文章ID + IP”计数，防止暴力破解
+	unlockLimiter := middleware.NewIPRateLimiter(
+		rate.Every(12*time.Second), // 每12秒一次尝试
+		5,                          // 突发尝试次数
+		15*time.Minute,             // 封禁时长
+		3,                          // 最大违规次数
+	)
+
+	// 文章密码解锁总次数限流器，只按文章ID计数，更换IP也无法绕过
+	unlockArticleLimiter := middleware.NewIPRateLimiter(
+		rate.Every(6*time.Second), // 每6秒一次尝试
+		20,                        // 突发尝试次数
+		30*time.Minute,            // 封禁时长
+		5,                         // 最大违规次数
+	)
+
+	// 重置密码限流器，按IP计数，防止批量发送邮件
+	passwordResetLimiter := middleware.NewIPRateLimiter(
+		rate.Every(20*time.Second), // 每20秒一次请求
+		5,                          // 突发请求数
+		30*time.Minute,             // 封禁时长
+		3,                          // 最大违规次数
+	)
+
+	// 两步验证限流器，按IP计数，配合每个 mfa_token 的尝试次数上限防止暴力破解验证码
+	mfaLimiter := middleware.NewIPRateLimiter(
+		rate.Every(6*time.Second), // 每6秒一次尝试
+		5,                         // 突发尝试次数
+		15*time.Minute,            // 封禁时长
+		3,                         // 最大违规次数
+	)
+
+	// 认证中间件，额外检查令牌是否已失效（如重置密码、退出登录、会话被移除后）
+	userService := services.NewUserService(db)
+	checkers := []middleware.TokenChecker{userService.CheckToken, userService.CheckRevoked, userService.CheckSession}
+	requireAuth := middleware.AuthMiddleware(checkers...)
+
+	// 同时接受个人访问令牌的认证中间件，令牌需要拥有指定的权限范围；其他接口只接受登录令牌
+	tokenAuth := func(scope string) gin.HandlerFunc {
+		return middleware.AccessTokenAuthMiddleware(scope, userService.CheckAccessToken, checkers...)
+	}
+	optionalTokenAuth := middleware.OptionalAccessTokenAuthMiddleware(models.ScopeArticlesRead, userService.CheckAccessToken, checkers...)
+
 	// 使用中间件
 	router.Use(gin.Recovery())
 	router.Use(middleware.CORS())
@@ -28,6 +74,18 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 	// 创建控制器实例
 	userController := controllers.NewUserController(db)
 	articleController := controllers.NewArticleController(db)
+	pinController := controllers.NewPinController(db)
+	moderationController := controllers.NewModerationController(db)
+	filterController := controllers.NewFilterController(db)
+	spamController := controllers.NewSpamController(db)
+	shareController := controllers.NewShareController(db)
+	autosaveController := controllers.NewAutosaveController(db)
+	collabController := controllers.NewCollabController(db, checkers...)
+	eventController := controllers.NewEventController()
+	webhookController := controllers.NewWebhookController(db)
+	mailController := controllers.NewMailController(db)
+	adminController := controllers.NewAdminController(db)
+	oidcController := controllers.NewOidcController(db)
 
 	// API 路由组
 	api := router.Group("/api")
@@ -47,29 +105,199 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 	{
 		user.POST("/register", userController.Register)
 		user.POST("/login", userController.Login)
+		user.POST("/login/mfa", mfaLimiter.RateLimitMiddleware(), userController.LoginMfa)                       // 两步验证登录
+		user.POST("/login/passkey/options", userController.PasskeyLoginOptions)                                  // 通行密钥登录选项
+		user.POST("/login/passkey", userController.LoginWithPasskey)                                             // 通行密钥登录
+		user.POST("/refresh", userController.Refresh)                                                            // 刷新登录令牌
+		user.POST("/logout", requireAuth, userController.Logout)                                                 // 退出登录
+		user.POST("/logout-all", requireAuth, userController.LogoutAll)                                          // 退出所有设备
+		user.GET("/sessions", requireAuth, userController.ListSessions)                                          // 登录会话列表
+		user.DELETE("/sessions/:id", requireAuth, userController.RevokeSession)                                  // 移除登录会话
+		user.POST("/forgot-password", passwordResetLimiter.RateLimitMiddleware(), userController.ForgotPassword) // 忘记密码
+		user.POST("/reset-password", passwordResetLimiter.RateLimitMiddleware(), userController.ResetPassword)   // 重置密码
+		user.POST("/verify-email", userController.VerifyEmail)                                                   // 验证邮箱
+		user.POST("/resend-verification", requireAuth, userController.ResendVerification)                        // 重新发送验证邮件
+		user.PUT("/password", requireAuth, userController.ChangePassword)                                        // 修改密码
+		user.POST("/email", requireAuth, userController.ChangeEmail)                                             // 申请修改邮箱
+		user.POST("/email/confirm", userController.ConfirmEmailChange)                                           // 确认修改邮箱
+
+		// 两步验证
+		mfa := user.Group("/mfa", requireAuth)
+		{
+			mfa.GET("", userController.MfaStatus)                               // 两步验证状态
+			mfa.POST("/totp", userController.SetupTotp)                         // 开始绑定身份验证器
+			mfa.POST("/totp/confirm", userController.ConfirmTotp)               // 确认绑定
+			mfa.DELETE("/totp", userController.DisableTotp)                     // 关闭两步验证
+			mfa.POST("/recovery-codes", userController.RegenerateRecoveryCodes) // 重新生成恢复码
+		}
+
+		// 个人访问令牌管理
+		tokens := user.Group("/tokens", requireAuth)
+		{
+			tokens.GET("", userController.ListAccessTokens)         // 令牌列表
+			tokens.POST("", userController.CreateAccessToken)       // 创建令牌
+			tokens.DELETE("/:id", userController.DeleteAccessToken) // 删除令牌
+		}
+
+		// 通行密钥管理
+		passkeys := user.Group("/passkeys", requireAuth)
+		{
+			passkeys.GET("", userController.ListPasskeys)                    // 通行密钥列表
+			passkeys.POST("/options", userController.PasskeyRegisterOptions) // 注册选项
+			passkeys.POST("", userController.RegisterPasskey)                // 注册通行密钥
+			passkeys.PUT("/:id", userController.RenamePasskey)               // 重命名
+			passkeys.DELETE("/:id", userController.DeletePasskey)            // 删除
+		}
+	}
+
+	// 第三方登录（OpenID Connect）
+	oidc := api.Group("/auth/oidc")
+	{
+		oidc.GET("/providers", oidcController.Providers)           // 可用的登录方式
+		oidc.GET("/:provider/authorize", oidcController.Authorize) // 发起登录
+		oidc.POST("/:provider/callback", oidcController.Callback)  // 完成登录
 	}
 
 	// 用户信息查询路由
 	users := api.Group("/users")
 	{
-		users.GET("/:id", userController.GetUserById)          // 获取用户基本信息
-		users.GET("/:id/detail", userController.GetUserDetail) // 获取用户详情（含统计）
+		users.GET("/me", tokenAuth(models.ScopeProfileRead), userController.GetProfile)     // 获取当前用户资料
+		users.PUT("/me", tokenAuth(models.ScopeProfileWrite), userController.UpdateProfile) // 编辑个人资料
+		users.GET("/:id", userController.GetUserById)                                       // 获取用户基本信息
+		users.GET("/:id/detail", userController.GetUserDetail)                              // 获取用户详情（含统计）
+		users.GET("/:id/avatar", userController.Avatar)                                     // 默认头像（identicon）
 	}
 
 	// 帖子相关路由
 	article := api.Group("/articles")
 	{
-		// 公开路由
-		article.GET("", articleController.List)           // 帖子列表（支持搜索、排序、过滤）
-		article.GET("/:id", articleController.GetById)    // 帖子详情
-		article.GET("/stats", articleController.GetStats) // 文章统计信息
+		// 公开路由（登录后可额外看到自己被隐藏的帖子）
+		public := article.Group("", optionalTokenAuth)
+		{
+			public.GET("", articleController.List)           // 帖子列表（支持搜索、排序、过滤）
+			public.GET("/:id", articleController.GetById)    // 帖子详情
+			public.GET("/stats", articleController.GetStats) // 文章统计信息
+		}
+		article.GET("/shared/:token", shareController.GetShared) // 通过分享链接访问帖子
+		article.POST("/:id/unlock", unlockLimiter.RateLimitByKey(func(c *gin.Context) string {
+			return c.Param("id") + "|" + middleware.GetClientIP(c)
+		}), unlockArticleLimiter.RateLimitByKey(func(c *gin.Context) string {
+			return c.Param("id")
+		}), articleController.Unlock) // 使用密码解锁帖子
+		article.POST("/shared/:token/unlock", unlockLimiter.RateLimitByKey(func(c *gin.Context) string {
+			return "share:" + c.Param("token") + "|" + middleware.GetClientIP(c)
+		}), unlockArticleLimiter.RateLimitByKey(func(c *gin.Context) string {
+			return "share:" + c.Param("token")
+		}), shareController.Unlock) // 通过分享链接解锁帖子
+		article.GET("/:id/collab", middleware.WebSocketTicketMiddleware(), requireAuth, collabController.Connect) // 协同编辑 WebSocket
+
+		// 发布文章，发布脚本可以使用带 articles:write 权限的个人访问令牌
+		write := article.Group("", tokenAuth(models.ScopeArticlesWrite))
+		{
+			write.POST("", articleController.Create)       // 创建帖子
+			write.PUT("/:id", articleController.Update)    // 更新帖子
+			write.DELETE("/:id", articleController.Delete) // 删除帖子
+
+			// 自动保存工作副本（作者）
+			write.GET("/:id/autosave", autosaveController.Get)              // 查看工作副本
+			write.PUT("/:id/autosave", autosaveController.Save)             // 自动保存
+			write.DELETE("/:id/autosave", autosaveController.Discard)       // 丢弃工作副本
+			write.POST("/:id/autosave/publish", autosaveController.Publish) // 发布工作副本
+		}
 
 		// 需要登录的路由
-		auth := article.Group("", middleware.AuthMiddleware())
+		auth := article.Group("", requireAuth)
+		{
+			// 置顶管理（编辑/管理员）
+			pins := auth.Group("", middleware.RequirePermission(rbac.PermArticlePin))
+			{
+				pins.GET("/pins", pinController.List)        // 置顶列表
+				pins.POST("/:id/pin", pinController.Pin)     // 置顶文章
+				pins.DELETE("/:id/pin", pinController.Unpin) // 取消置顶
+			}
+
+			auth.POST("/:id/report", moderationController.Report) // 举报帖子
+
+			// 分享链接管理（作者）
+			auth.GET("/:id/shares", shareController.List)               // 分享链接列表
+			auth.POST("/:id/shares", shareController.Create)            // 创建分享链接
+			auth.DELETE("/:id/shares/:shareId", shareController.Revoke) // 撤销分享链接
+
+			// 协作者管理（作者）
+			auth.GET("/:id/collaborators", collabController.ListCollaborators)             // 协作者列表
+			auth.POST("/:id/collaborators", collabController.AddCollaborator)              // 添加协作者
+			auth.DELETE("/:id/collaborators/:userId", collabController.RemoveCollaborator) // 移除协作者
+			auth.POST("/:id/collab/ticket", collabController.Ticket)                       // 协同编辑连接票据
+		}
+	}
+
+	// 站点动态事件流（SSE）
+	api.GET("/events", eventController.Stream)
+
+	// Webhook 管理（全站 webhook 需要管理员权限）
+	webhooks := api.Group("/webhooks", requireAuth)
+	{
+		webhooks.GET("", webhookController.List)                                            // webhook 列表
+		webhooks.POST("", webhookController.Create)                                         // 创建 webhook
+		webhooks.PUT("/:id", webhookController.Update)                                      // 更新 webhook
+		webhooks.DELETE("/:id", webhookController.Delete)                                   // 删除 webhook
+		webhooks.GET("/:id/deliveries", webhookController.ListDeliveries)                   // 投递记录
+		webhooks.POST("/:id/deliveries/:deliveryId/redeliver", webhookController.Redeliver) // 重新投递
+	}
+
+	// 归档路由
+	archive := api.Group("/archive", optionalTokenAuth)
+	{
+		archive.GET("", articleController.Archive)                      // 按年月统计文章数
+		archive.GET("/:year/:month", articleController.ArchiveArticles) // 某年某月的文章列表
+	}
+
+	// 管理后台路由，路由按权限分组，服务层会再次按数据库中的角色检查
+	admin := api.Group("/admin", requireAuth)
+	{
+		// 内容审核（编辑/管理员）
+		moderation := admin.Group("", middleware.RequirePermission(rbac.PermArticleModerate))
+		{
+			moderation.GET("/reports", moderationController.ListReports)                // 审核队列
+			moderation.POST("/reports/:id/dismiss", moderationController.DismissReport) // 驳回举报
+			moderation.POST("/articles/:id/moderate", moderationController.Moderate)    // 审核操作
+			moderation.GET("/moderation-logs", moderationController.ListLogs)           // 审核日志
+
+			// 垃圾检测
+			moderation.GET("/spam/stats", spamController.Stats)               // 分类器状态
+			moderation.GET("/spam/checks", spamController.ListChecks)         // 检测记录
+			moderation.POST("/spam/retrain", spamController.Retrain)          // 重新训练
+			moderation.POST("/spam/articles/:id", spamController.MarkArticle) // 标注文章
+			moderation.POST("/spam/users/:id", spamController.MarkUser)       // 标注用户
+		}
+
+		// 站点设置（管理员）
+		site := admin.Group("", middleware.RequirePermission(rbac.PermSiteManage))
+		{
+			// 内容过滤规则
+			site.GET("/filter-rules", filterController.ListRules)         // 规则列表
+			site.POST("/filter-rules", filterController.CreateRules)      // 批量添加规则
+			site.DELETE("/filter-rules/:id", filterController.DeleteRule) // 删除规则
+
+			// 邮件
+			site.POST("/mail/test", mailController.SendTest)          // 发送测试邮件
+			site.GET("/mail/outbox", mailController.ListOutbox)       // 发件箱
+			site.POST("/mail/outbox/:id/retry", mailController.Retry) // 重新发送失败的邮件
+		}
+
+		// 用户管理（管理员）
+		userAdmin := admin.Group("/users", middleware.RequirePermission(rbac.PermUserManage))
 		{
-			auth.POST("", articleController.Create)       // 创建帖子
-			auth.PUT("/:id", articleController.Update)    // 更新帖子
-			auth.DELETE("/:id", articleController.Delete) // 删除帖子
+			userAdmin.GET("", adminController.ListUsers)                                    // 用户列表
+			userAdmin.GET("/:id", adminController.GetUser)                                  // 用户详情
+			userAdmin.GET("/:id/articles", adminController.ListUserArticles)                // 用户的文章
+			userAdmin.GET("/:id/sessions", adminController.ListUserSessions)                // 用户的登录会话
+			userAdmin.POST("/:id/suspend", adminController.Suspend)                         // 停用账号
+			userAdmin.POST("/:id/unsuspend", adminController.Unsuspend)                     // 恢复账号
+			userAdmin.PUT("/:id/role", adminController.UpdateRole)                          // 修改角色
+			userAdmin.POST("/:id/force-password-reset", adminController.ForcePasswordReset) // 强制重置密码
+			userAdmin.DELETE("/:id/mfa", adminController.ResetMfa)                          // 关闭两步验证
+			userAdmin.DELETE("/:id", adminController.DeleteUser)                            // 删除账号
 		}
 	}
 }
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"errors"
+	"server/internal/models"
+	"server/pkg/utils"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAccessTokenNotFound = errors.New("access token not found")
+	ErrInvalidAccessToken  = errors.New("invalid or expired access token")
+	ErrInvalidTokenName    = errors.New("token name is required and must be at most 100 characters")
+	ErrInvalidTokenScopes  = errors.New("invalid token scopes")
+	ErrInvalidTokenExpiry  = errors.New("expires_in_days must be between 0 and 365")
+	ErrTooManyAccessTokens = errors.New("too many access tokens")
+)
+
+const (
+	maxAccessTokenNameLength = 100
+	maxAccessTokenDays       = 365
+	maxAccessTokensPerUser   = 50
+	// 最后使用时间的更新间隔，避免每个请求都写数据库
+	accessTokenTouchInterval = time.Minute
+)
+
+// accessTokenResponse 个人访问令牌列表项
+func accessTokenResponse(token *models.PersonalAccessToken) models.AccessTokenResponse {
+	return models.AccessTokenResponse{
+		Id:          token.Id,
+		Name:        token.Name,
+		TokenPrefix: token.TokenPrefix,
+		Scopes:      token.Scopes,
+		ExpiresAt:   token.ExpiresAt,
+		LastUsedAt:  token.LastUsedAt,
+		LastUsedIp:  token.LastUsedIp,
+		CreatedAt:   token.CreatedAt,
+	}
+}
+
+// normalizeScopes 校验权限范围并去重，按固定顺序排列
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, ErrInvalidTokenScopes
+	}
+	requested := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		requested[strings.TrimSpace(scope)] = true
+	}
+
+	normalized := make([]string, 0, len(requested))
+	for _, scope := range models.AccessTokenScopes {
+		if requested[scope] {
+			normalized = append(normalized, scope)
+			delete(requested, scope)
+		}
+	}
+	if len(requested) > 0 {
+		return nil, ErrInvalidTokenScopes
+	}
+	return normalized, nil
+}
+
+// ListAccessTokens 当前用户的个人访问令牌
+func (s *UserService) ListAccessTokens(userId int) ([]models.AccessTokenResponse, error) {
+	var tokens []models.PersonalAccessToken
+	if err := s.db.Where("user_id = ?", userId).Order("id DESC").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+
+	list := make([]models.AccessTokenResponse, len(tokens))
+	for i := range tokens {
+		list[i] = accessTokenResponse(&tokens[i])
+	}
+	return list, nil
+}
+
+// deleteAccessTokens 删除用户的全部个人访问令牌，在密码变更、退出所有设备等操作时调用
+func deleteAccessTokens(db *gorm.DB, userId int) error {
+	return db.Where("user_id = ?", userId).Delete(&models.PersonalAccessToken{}).Error
+}
+
+// CreateAccessToken 创建个人访问令牌，令牌只在创建时返回一次。令牌不受会话约束，需要验证当前密码
+func (s *UserService) CreateAccessToken(userId int, request *models.CreateAccessTokenRequest) (*models.CreateAccessTokenResponse, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxAccessTokenNameLength {
+		return nil, ErrInvalidTokenName
+	}
+	scopes, err := normalizeScopes(request.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if request.ExpiresInDays < 0 || request.ExpiresInDays > maxAccessTokenDays {
+		return nil, ErrInvalidTokenExpiry
+	}
+
+	var user models.User
+	if err := s.db.First(&user, userId).Error; err != nil {
+		return nil, err
+	}
+	if !utils.ValidatePassword(request.Password, user.Password) {
+		return nil, ErrIncorrectPassword
+	}
+
+	var count int64
+	if err := s.db.Model(&models.PersonalAccessToken{}).Where("user_id = ?", userId).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count >= maxAccessTokensPerUser {
+		return nil, ErrTooManyAccessTokens
+	}
+
+	random, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	token := utils.AccessTokenPrefix + random
+	accessToken := models.PersonalAccessToken{
+		UserId:      userId,
+		Name:        name,
+		TokenHash:   hashUserToken(token),
+		TokenPrefix: token[:len(utils.AccessTokenPrefix)+6],
+		Scopes:      scopes,
+	}
+	if request.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, request.ExpiresInDays)
+		accessToken.ExpiresAt = &expiresAt
+	}
+	if err := s.db.Create(&accessToken).Error; err != nil {
+		return nil, err
+	}
+
+	return &models.CreateAccessTokenResponse{
+		AccessTokenResponse: accessTokenResponse(&accessToken),
+		Token:               token,
+	}, nil
+}
+
+// DeleteAccessToken 删除个人访问令牌，令牌立即失效
+func (s *UserService) DeleteAccessToken(userId int, tokenId int) error {
+	result := s.db.Where("id = ? AND user_id = ?", tokenId, userId).Delete(&models.PersonalAccessToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAccessTokenNotFound
+	}
+	return nil
+}
+
+// CheckAccessToken 校验个人访问令牌，返回所属用户和权限范围，并记录最后使用时间和IP。
+// 用户被停用时令牌同样失效
+func (s *UserService) CheckAccessToken(token string, clientIP string) (int, []string, error) {
+	var accessToken models.PersonalAccessToken
+	if err := s.db.Where("token_hash = ?", hashUserToken(token)).First(&accessToken).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return 0, nil, ErrInvalidAccessToken
+		}
+		return 0, nil, err
+	}
+	if accessToken.ExpiresAt != nil && time.Now().After(*accessToken.ExpiresAt) {
+		return 0, nil, ErrInvalidAccessToken
+	}
+
+	var user models.User
+	if err := s.db.Select("id", "suspended_at").First(&user, accessToken.UserId).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return 0, nil, ErrInvalidAccessToken
+		}
+		return 0, nil, err
+	}
+	if user.SuspendedAt != nil {
+		return 0, nil, ErrAccountSuspended
+	}
+
+	if len(clientIP) > 64 {
+		clientIP = clientIP[:64]
+	}
+	if accessToken.LastUsedAt == nil || time.Since(*accessToken.LastUsedAt) >= accessTokenTouchInterval || accessToken.LastUsedIp != clientIP {
+		s.db.Model(&accessToken).Updates(map[string]interface{}{
+			"last_used_at": time.Now(),
+			"last_used_ip": clientIP,
+		})
+	}
+	return accessToken.UserId, accessToken.Scopes, nil
+}
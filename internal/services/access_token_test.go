@@ -0,0 +1,101 @@
+package services
+
+import (
+	"server/internal/models"
+	"server/pkg/utils"
+	"testing"
+)
+
+// newAccessTokenTest 创建一个密码为 old-password 的用户，并以该密码创建一个个人访问令牌
+func newAccessTokenTest(t *testing.T) (*UserService, *models.User) {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-secret")
+	db := newTestDB(t, appTables...)
+
+	password, err := utils.HashPassword("old-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{Username: "alice", Email: "alice@example.com", Password: password, Role: models.RoleAuthor}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	service := NewUserService(db)
+	if _, err := service.CreateAccessToken(user.Id, &models.CreateAccessTokenRequest{
+		Password: "old-password",
+		Name:     "sync",
+		Scopes:   []string{"articles:write"},
+	}); err != nil {
+		t.Fatalf("CreateAccessToken: %v", err)
+	}
+	return service, user
+}
+
+func countAccessTokens(t *testing.T, service *UserService, userId int) int {
+	t.Helper()
+	tokens, err := service.ListAccessTokens(userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(tokens)
+}
+
+func TestCreateAccessTokenRequiresPassword(t *testing.T) {
+	service, user := newAccessTokenTest(t)
+
+	for _, password := range []string{"", "wrong-password"} {
+		_, err := service.CreateAccessToken(user.Id, &models.CreateAccessTokenRequest{
+			Password: password,
+			Name:     "stolen",
+			Scopes:   []string{"articles:read"},
+		})
+		if err != ErrIncorrectPassword {
+			t.Errorf("CreateAccessToken with password %q: %v, want ErrIncorrectPassword", password, err)
+		}
+	}
+	if n := countAccessTokens(t, service, user.Id); n != 1 {
+		t.Errorf("%d access tokens, want 1", n)
+	}
+}
+
+func TestCredentialChangesDeleteAccessTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(t *testing.T, service *UserService, user *models.User) error
+	}{
+		{name: "change password", change: func(t *testing.T, service *UserService, user *models.User) error {
+			_, err := service.ChangePassword(user.Id, 0, &models.ChangePasswordRequest{
+				CurrentPassword: "old-password",
+				Password:        "new-password",
+				RepeatPassword:  "new-password",
+			})
+			return err
+		}},
+		{name: "reset password", change: func(t *testing.T, service *UserService, user *models.User) error {
+			token, err := issueUserToken(service.db, user, models.TokenPurposeResetPassword, "", defaultPasswordResetExpires)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return service.ResetPassword(&models.ResetPasswordRequest{
+				Token:          token,
+				Password:       "new-password",
+				RepeatPassword: "new-password",
+			})
+		}},
+		{name: "logout all", change: func(t *testing.T, service *UserService, user *models.User) error {
+			return service.LogoutAll(user.Id)
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service, user := newAccessTokenTest(t)
+			if err := test.change(t, service, user); err != nil {
+				t.Fatal(err)
+			}
+			if n := countAccessTokens(t, service, user.Id); n != 0 {
+				t.Errorf("%d access tokens left", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"server/internal/models"
+	"server/pkg/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrIncorrectPassword = errors.New("current password is incorrect")
+	ErrEmailExists       = errors.New("email already exists")
+	ErrSameEmail         = errors.New("new email is the same as the current one")
+)
+
+const defaultEmailChangeExpires = 24 * time.Hour
+
+// ChangePassword 修改密码，需要验证当前密码。修改后其他会话和个人访问令牌全部失效，返回当前会话使用的新令牌
+func (s *UserService) ChangePassword(userId int, sessionId int, request *models.ChangePasswordRequest) (*models.LoginResponse, error) {
+	if request.Password == "" || request.Password != request.RepeatPassword {
+		return nil, ErrPasswordMismatch
+	}
+
+	var user models.User
+	if err := s.db.First(&user, userId).Error; err != nil {
+		return nil, err
+	}
+	if !utils.ValidatePassword(request.CurrentPassword, user.Password) {
+		return nil, ErrIncorrectPassword
+	}
+
+	hashedPassword, err := utils.HashPassword(request.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	user.Password = hashedPassword
+	user.TokenVersion++
+	if err := s.db.Model(&user).Select("password", "token_version").Updates(&user).Error; err != nil {
+		return nil, err
+	}
+
+	if err := revokeSessions(s.db, "user_id = ? AND id <> ?", user.Id, sessionId); err != nil {
+		return nil, err
+	}
+	if err := deleteAccessTokens(s.db, user.Id); err != nil {
+		return nil, err
+	}
+
+	// 当前会话已失效（如令牌签发于会话功能之前）时创建新会话
+	session := &models.Session{}
+	if err := s.db.Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionId, user.Id).First(session).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		session = &models.Session{}
+	}
+	return issueLoginTokens(s.db, &user, session)
+}
+
+// normalizeEmail 校验邮箱格式，只接受不带显示名的地址
+func normalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return "", ErrInvalidEmail
+	}
+	return email, nil
+}
+
+// emailTaken 邮箱是否已被其他用户使用
+func emailTaken(db *gorm.DB, email string, exceptUserId int) (bool, error) {
+	var count int64
+	if err := db.Model(&models.User{}).Where("email = ? AND id <> ?", email, exceptUserId).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ChangeEmail 申请修改邮箱，需要验证当前密码。确认邮件发送到新邮箱，点击确认后才会生效
+func (s *UserService) ChangeEmail(userId int, request *models.ChangeEmailRequest) error {
+	email, err := normalizeEmail(request.Email)
+	if err != nil {
+		return err
+	}
+
+	var user models.User
+	if err := s.db.First(&user, userId).Error; err != nil {
+		return err
+	}
+	if !utils.ValidatePassword(request.Password, user.Password) {
+		return ErrIncorrectPassword
+	}
+	if strings.EqualFold(email, user.Email) {
+		return ErrSameEmail
+	}
+	taken, err := emailTaken(s.db, email, user.Id)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrEmailExists
+	}
+
+	// 与其他邮件共用发送频率限制
+	last, err := lastUserToken(s.db, user.Id, models.TokenPurposeChangeEmail)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+	if last != nil && time.Since(last.CreatedAt) < verificationResendInterval {
+		return ErrVerificationTooFrequent
+	}
+
+	expires := durationFromEnv("EMAIL_CHANGE_EXPIRES", defaultEmailChangeExpires)
+	token, err := issueUserToken(s.db, &user, models.TokenPurposeChangeEmail, email, expires)
+	if err != nil {
+		return err
+	}
+	_, err = queueEmail(s.db, user.Id, email, user.Locale, "confirm_email_change", map[string]interface{}{
+		"Username":     user.Username,
+		"OldEmail":     user.Email,
+		"NewEmail":     email,
+		"Link":         frontendLink("/confirm-email", token),
+		"ExpiresHours": int(expires.Hours()),
+	})
+	return err
+}
+
+// ConfirmEmailChange 使用新邮箱收到的令牌确认修改邮箱，修改后向原邮箱发送通知
+func (s *UserService) ConfirmEmailChange(token string) (*models.BaseUser, error) {
+	user, userToken, err := consumeUserToken(s.db, token, models.TokenPurposeChangeEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	// 申请之后新邮箱可能已被其他用户注册
+	taken, err := emailTaken(s.db, userToken.NewEmail, user.Id)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, ErrEmailExists
+	}
+
+	oldEmail := user.Email
+	now := time.Now()
+	user.Email = userToken.NewEmail
+	user.EmailVerifiedAt = &now
+	if err := s.db.Model(user).Select("email", "email_verified_at").Updates(user).Error; err != nil {
+		return nil, err
+	}
+
+	if _, err := queueEmail(s.db, user.Id, oldEmail, user.Locale, "email_changed", map[string]interface{}{
+		"Username": user.Username,
+		"OldEmail": oldEmail,
+		"NewEmail": user.Email,
+	}); err != nil {
+		log.Printf("failed to send email change notice to user %d: %v", user.Id, err)
+	}
+
+	base := baseUser(user)
+	return &base, nil
+}
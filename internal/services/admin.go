@@ -0,0 +1,371 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"server/internal/models"
+	"server/pkg/rbac"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAccountSuspended      = errors.New("account has been suspended")
+	ErrPasswordResetRequired = errors.New("password reset required, please check your email")
+	ErrCannotManageSelf      = errors.New("cannot perform this action on your own account")
+	ErrInvalidRole           = errors.New("invalid role")
+)
+
+// checkLoginAllowed 检查账号是否允许登录（签发新令牌），所有登录方式共用：账号被停用或被强制重置密码时不允许登录
+func checkLoginAllowed(user *models.User) error {
+	if user.SuspendedAt != nil {
+		return ErrAccountSuspended
+	}
+	if user.PasswordResetRequired {
+		return ErrPasswordResetRequired
+	}
+	return nil
+}
+
+type AdminService struct {
+	db *gorm.DB
+}
+
+func NewAdminService(db *gorm.DB) *AdminService {
+	return &AdminService{
+		db: db,
+	}
+}
+
+// checkAdmin 检查用户是否有用户管理权限（管理员）
+func (s *AdminService) checkAdmin(userId int) error {
+	return requirePermission(s.db, userId, rbac.PermUserManage)
+}
+
+// getTarget 获取被管理的用户，不允许对自己执行停用、删除等操作
+func (s *AdminService) getTarget(adminId int, userId int) (*models.User, error) {
+	if adminId == userId {
+		return nil, ErrCannotManageSelf
+	}
+	var user models.User
+	if err := s.db.First(&user, userId).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// adminUsers 转换为管理后台用户信息，并统计文章数
+func (s *AdminService) adminUsers(users []models.User) ([]models.AdminUser, error) {
+	ids := make([]int, len(users))
+	for i, user := range users {
+		ids[i] = user.Id
+	}
+	var counts []struct {
+		UserId int
+		Count  int
+	}
+	if len(ids) > 0 {
+		if err := s.db.Model(&models.Article{}).Select("user_id, COUNT(*) AS count").
+			Where("user_id IN ?", ids).Group("user_id").Scan(&counts).Error; err != nil {
+			return nil, err
+		}
+	}
+	articleCounts := make(map[int]int, len(counts))
+	for _, count := range counts {
+		articleCounts[count.UserId] = count.Count
+	}
+
+	list := make([]models.AdminUser, len(users))
+	for i := range users {
+		list[i] = models.AdminUser{
+			BaseUser:              baseUser(&users[i]),
+			Quarantined:           users[i].Quarantined,
+			SuspendedAt:           users[i].SuspendedAt,
+			SuspendReason:         users[i].SuspendReason,
+			PasswordResetRequired: users[i].PasswordResetRequired,
+			ArticleCount:          articleCounts[users[i].Id],
+			CreatedAt:             users[i].CreatedAt,
+		}
+	}
+	return list, nil
+}
+
+// ListUsers 用户列表，支持按用户名/邮箱搜索、按角色和状态过滤
+func (s *AdminService) ListUsers(adminId int, request *models.AdminUserListRequest) (*models.AdminUserListResponse, error) {
+	if err := s.checkAdmin(adminId); err != nil {
+		return nil, err
+	}
+
+	query := s.db.Model(&models.User{})
+	if search := strings.TrimSpace(request.Search); search != "" {
+		keyword := "%" + search + "%"
+		query = query.Where("username LIKE ? OR email LIKE ?", keyword, keyword)
+	}
+	if request.Role != "" {
+		query = query.Where("role = ?", request.Role)
+	}
+	switch request.Status {
+	case "active":
+		query = query.Where("suspended_at IS NULL")
+	case "suspended":
+		query = query.Where("suspended_at IS NOT NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var users []models.User
+	offset := (request.Page - 1) * request.Size
+	if err := query.Order("id desc").Offset(offset).Limit(request.Size).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	list, err := s.adminUsers(users)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AdminUserListResponse{
+		Users: list,
+		Total: int(total),
+		Page:  request.Page,
+		Size:  request.Size,
+	}, nil
+}
+
+// GetUser 用户详情
+func (s *AdminService) GetUser(adminId int, userId int) (*models.AdminUser, error) {
+	if err := s.checkAdmin(adminId); err != nil {
+		return nil, err
+	}
+	var user models.User
+	if err := s.db.First(&user, userId).Error; err != nil {
+		return nil, err
+	}
+	list, err := s.adminUsers([]models.User{user})
+	if err != nil {
+		return nil, err
+	}
+	return &list[0], nil
+}
+
+// ListUserArticles 用户的全部文章，包括被隐藏和不公开的文章
+func (s *AdminService) ListUserArticles(adminId int, userId int, request *models.AdminPageRequest) (*models.ArticleListResponse, error) {
+	if err := s.checkAdmin(adminId); err != nil {
+		return nil, err
+	}
+	if err := s.db.Select("id").First(&models.User{}, userId).Error; err != nil {
+		return nil, err
+	}
+
+	query := s.db.Model(&models.Article{}).Where("user_id = ?", userId)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var articles []models.Article
+	offset := (request.Page - 1) * request.Size
+	if err := query.Preload("User").Preload("Tags").Order("created_at desc").Offset(offset).Limit(request.Size).Find(&articles).Error; err != nil {
+		return nil, err
+	}
+
+	return &models.ArticleListResponse{
+		Articles: fillArticles(articles, adminId),
+		Total:    int(total),
+		Page:     request.Page,
+		Size:     request.Size,
+	}, nil
+}
+
+// ListUserSessions 用户的登录会话
+func (s *AdminService) ListUserSessions(adminId int, userId int) ([]models.SessionResponse, error) {
+	if err := s.checkAdmin(adminId); err != nil {
+		return nil, err
+	}
+	if err := s.db.Select("id").First(&models.User{}, userId).Error; err != nil {
+		return nil, err
+	}
+	return NewUserService(s.db).ListSessions(userId, 0)
+}
+
+// Suspend 停用账号：不能再登录，已登录的会话全部失效
+func (s *AdminService) Suspend(adminId int, userId int, reason string) (*models.AdminUser, error) {
+	if err := s.checkAdmin(adminId); err != nil {
+		return nil, err
+	}
+	user, err := s.getTarget(adminId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(user).Updates(map[string]interface{}{
+		"suspended_at":   time.Now(),
+		"suspend_reason": strings.TrimSpace(reason),
+		"token_version":  gorm.Expr("token_version + 1"),
+	}).Error; err != nil {
+		return nil, err
+	}
+	if err := revokeSessions(s.db, "user_id = ?", user.Id); err != nil {
+		return nil, err
+	}
+	return s.GetUser(adminId, userId)
+}
+
+// Unsuspend 恢复账号，用户需要重新登录
+func (s *AdminService) Unsuspend(adminId int, userId int) (*models.AdminUser, error) {
+	if err := s.checkAdmin(adminId); err != nil {
+		return nil, err
+	}
+	user, err := s.getTarget(adminId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(user).Updates(map[string]interface{}{
+		"suspended_at":   nil,
+		"suspend_reason": "",
+	}).Error; err != nil {
+		return nil, err
+	}
+	return s.GetUser(adminId, userId)
+}
+
+// UpdateRole 修改角色。已签发的令牌中带有旧角色，因此让用户的会话全部失效
+func (s *AdminService) UpdateRole(adminId int, userId int, role string) (*models.AdminUser, error) {
+	if err := s.checkAdmin(adminId); err != nil {
+		return nil, err
+	}
+	if !rbac.ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	user, err := s.getTarget(adminId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Role != role {
+		if err := s.db.Model(user).Updates(map[string]interface{}{
+			"role":          role,
+			"token_version": gorm.Expr("token_version + 1"),
+		}).Error; err != nil {
+			return nil, err
+		}
+		if err := revokeSessions(s.db, "user_id = ?", user.Id); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetUser(adminId, userId)
+}
+
+// ForcePasswordReset 强制重置密码：会话、个人访问令牌和通行密钥全部失效，重置前不能登录，并向用户发送重置邮件
+func (s *AdminService) ForcePasswordReset(adminId int, userId int) error {
+	if err := s.checkAdmin(adminId); err != nil {
+		return err
+	}
+	user, err := s.getTarget(adminId, userId)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Model(user).Updates(map[string]interface{}{
+		"password_reset_required": true,
+		"token_version":           gorm.Expr("token_version + 1"),
+	}).Error; err != nil {
+		return err
+	}
+	if err := revokeSessions(s.db, "user_id = ?", user.Id); err != nil {
+		return err
+	}
+	// 账号可能已泄露，脚本使用的令牌也一并删除
+	if err := deleteAccessTokens(s.db, user.Id); err != nil {
+		return err
+	}
+	// 攻击者可能已注册自己的通行密钥，全部删除，重置密码后由用户重新注册
+	if err := s.db.Where("user_id = ?", user.Id).Delete(&models.Passkey{}).Error; err != nil {
+		return err
+	}
+	return sendPasswordResetEmail(s.db, user)
+}
+
+// ResetMfa 关闭用户的两步验证，用于用户丢失身份验证器和恢复码的情况
+func (s *AdminService) ResetMfa(adminId int, userId int) error {
+	if err := s.checkAdmin(adminId); err != nil {
+		return err
+	}
+	user, err := s.getTarget(adminId, userId)
+	if err != nil {
+		return err
+	}
+	if user.TotpEnabledAt == nil {
+		return ErrMfaNotEnabled
+	}
+
+	if err := disableMfa(s.db, user); err != nil {
+		return err
+	}
+	log.Printf("mfa of user %d reset by admin %d", user.Id, adminId)
+	return nil
+}
+
+// DeleteUser 删除账号及其文章、会话、webhook 等数据。举报、审核日志和发件记录保留用于审计
+func (s *AdminService) DeleteUser(adminId int, userId int) error {
+	if err := s.checkAdmin(adminId); err != nil {
+		return err
+	}
+	user, err := s.getTarget(adminId, userId)
+	if err != nil {
+		return err
+	}
+
+	var articles []models.Article
+	if err := s.db.Preload("User").Preload("Tags").Where("user_id = ?", user.Id).Find(&articles).Error; err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for i := range articles {
+			if err := deleteArticle(tx, &articles[i]); err != nil {
+				return err
+			}
+		}
+
+		var webhookIds []int
+		if err := tx.Model(&models.Webhook{}).Where("user_id = ?", user.Id).Pluck("id", &webhookIds).Error; err != nil {
+			return err
+		}
+		if len(webhookIds) > 0 {
+			if err := tx.Where("webhook_id IN ?", webhookIds).Delete(&models.WebhookDelivery{}).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, related := range []interface{}{
+			&models.Webhook{}, &models.ArticleCollaborator{}, &models.ArticleAutosave{},
+			&models.UserToken{}, &models.RefreshToken{}, &models.Session{}, &models.UserIdentity{},
+			&models.MfaRecoveryCode{}, &models.MfaChallenge{}, &models.Passkey{}, &models.WebauthnChallenge{},
+			&models.PersonalAccessToken{},
+		} {
+			if err := tx.Where("user_id = ?", user.Id).Delete(related).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(user).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	// 删除的文章同样推送事件，全站 webhook 可以同步删除
+	for i := range articles {
+		if isPublicArticle(&articles[i]) {
+			publishArticleDeleted(&articles[i])
+		}
+		enqueueWebhooks(s.db, models.EventArticleDeleted, 0, articleEventData(&articles[i]))
+	}
+	log.Printf("user %d deleted by admin %d", user.Id, adminId)
+	return nil
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"errors"
+	"server/internal/models"
+	"sort"
+	"time"
+)
+
+var (
+	ErrInvalidTimezone     = errors.New("invalid timezone")
+	ErrInvalidArchiveMonth = errors.New("invalid year or month")
+)
+
+// loadTimezone 解析时区，为空时使用服务器时区
+func loadTimezone(tz string) (*time.Location, error) {
+	if tz == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	return loc, nil
+}
+
+// Archive 按年、月统计查看者可见的文章数。
+// 为保证夏令时等时区规则正确，只查询发布时间并在应用层按指定时区分组
+func (s *ArticleService) Archive(viewerId int, request *models.ArchiveRequest) (*models.ArchiveResponse, error) {
+	loc, err := loadTimezone(request.Tz)
+	if err != nil {
+		return nil, err
+	}
+
+	moderator, err := isModerator(s.db, viewerId)
+	if err != nil {
+		return nil, err
+	}
+
+	var createdAts []time.Time
+	listRequest := &models.ArticleListRequest{UserId: request.UserId}
+	if err := s.filterQuery(viewerId, moderator, listRequest).Pluck("created_at", &createdAts).Error; err != nil {
+		return nil, err
+	}
+
+	years := make(map[int]map[int]int)
+	for _, createdAt := range createdAts {
+		local := createdAt.In(loc)
+		if years[local.Year()] == nil {
+			years[local.Year()] = make(map[int]int)
+		}
+		years[local.Year()][int(local.Month())]++
+	}
+
+	result := &models.ArchiveResponse{
+		Timezone: loc.String(),
+		Total:    len(createdAts),
+		Years:    make([]models.ArchiveYear, 0, len(years)),
+	}
+	for year, months := range years {
+		archiveYear := models.ArchiveYear{Year: year}
+		for month, count := range months {
+			archiveYear.Count += count
+			archiveYear.Months = append(archiveYear.Months, models.ArchiveMonth{Month: month, Count: count})
+		}
+		sort.Slice(archiveYear.Months, func(i, j int) bool {
+			return archiveYear.Months[i].Month > archiveYear.Months[j].Month
+		})
+		result.Years = append(result.Years, archiveYear)
+	}
+	sort.Slice(result.Years, func(i, j int) bool {
+		return result.Years[i].Year > result.Years[j].Year
+	})
+
+	return result, nil
+}
+
+// ArchiveArticles 获取指定时区下某年某月发布的文章
+func (s *ArticleService) ArchiveArticles(viewerId int, year int, month int, request *models.ArchiveArticlesRequest) (*models.ArticleListResponse, error) {
+	if year < 1 || month < 1 || month > 12 {
+		return nil, ErrInvalidArchiveMonth
+	}
+	loc, err := loadTimezone(request.Tz)
+	if err != nil {
+		return nil, err
+	}
+
+	moderator, err := isModerator(s.db, viewerId)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, loc)
+	end := start.AddDate(0, 1, 0)
+	listRequest := &models.ArticleListRequest{UserId: request.UserId}
+	query := s.filterQuery(viewerId, moderator, listRequest).Where("created_at >= ? AND created_at < ?", start, end)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var articles []models.Article
+	offset := (request.Page - 1) * request.Size
+	if err := query.Preload("User").Preload("Tags").Order("created_at desc").Offset(offset).Limit(request.Size).Find(&articles).Error; err != nil {
+		return nil, err
+	}
+
+	return &models.ArticleListResponse{
+		Articles: fillArticles(articles, viewerId),
+		Total:    int(total),
+		Page:     request.Page,
+		Size:     request.Size,
+	}, nil
+}
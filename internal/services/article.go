@@ -1,18 +1,40 @@
 package services
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"server/internal/models"
+	"server/pkg/rbac"
+	"server/pkg/utils"
+	"sort"
+	"strings"
 
 	"gorm.io/gorm"
 )
 
+var (
+	ErrInvalidVisibility        = errors.New("invalid visibility")
+	ErrArticleNotProtected      = errors.New("article is not password protected")
+	ErrIncorrectArticlePassword = errors.New("incorrect article password")
+)
+
+// 受密码保护的帖子未解锁时返回的摘要长度（字数）
+const excerptLength = 100
+
 type ArticleService struct {
-	db *gorm.DB
+	db     *gorm.DB
+	filter *FilterService
+	spam   *SpamService
 }
 
 func NewArticleService(db *gorm.DB) *ArticleService {
-	return &ArticleService{db: db}
+	return &ArticleService{
+		db:     db,
+		filter: NewFilterService(db),
+		spam:   NewSpamService(db),
+	}
 }
 
 // Create 创建帖子
@@ -22,119 +44,433 @@ func (s *ArticleService) Create(userId int, request *models.CreateArticleRequest
 	if err := s.db.First(&user, userId).Error; err != nil {
 		return nil, err
 	}
+	if !rbac.Has(user.Role, rbac.PermArticleCreate) {
+		return nil, ErrPermissionDenied
+	}
+	if requireEmailVerification() && user.EmailVerifiedAt == nil {
+		return nil, ErrEmailNotVerified
+	}
+
+	visibility := request.Visibility
+	if visibility == "" {
+		visibility = models.VisibilityPublic
+	}
+	if !models.ValidVisibility(visibility) {
+		return nil, ErrInvalidVisibility
+	}
+
+	// 内容过滤
+	filtered, err := s.filter.Check(request.Title, request.Content)
+	if err != nil {
+		return nil, err
+	}
+	if filtered.Action == models.FilterActionReject {
+		return nil, ErrContentRejected
+	}
+
+	// 垃圾检测，被隔离的用户发布的文章同样需要审核
+	check, err := s.spam.ScoreArticle(&user, filtered.Title, filtered.Content)
+	if err != nil {
+		return nil, err
+	}
+	quarantined := check.Quarantined || user.Quarantined
+
+	tags, err := findOrCreateTags(s.db, request.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	// 文章访问密码
+	var password string
+	if request.Password != "" {
+		if password, err = utils.HashPassword(request.Password); err != nil {
+			return nil, err
+		}
+	}
 
 	article := models.Article{
-		Title:   request.Title,
-		Content: request.Content,
-		UserId:  userId,
-		User:    user,
-		UserInfo: models.UserInfo{
-			Id:       user.Id,
-			Username: user.Username,
-			Email:    user.Email,
-		},
-	}
-
-	if err := s.db.Create(&article).Error; err != nil {
+		Title:      filtered.Title,
+		Content:    filtered.Content,
+		UserId:     userId,
+		User:       user,
+		Tags:       tags,
+		Hidden:     filtered.Action == models.FilterActionModerate || quarantined,
+		Visibility: visibility,
+		Password:   password,
+		UserInfo:   userInfo(&user),
+	}
+
+	// 文章、垃圾检测记录和审核队列在同一事务中写入，提交后再推送动态和 webhook
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&article).Error; err != nil {
+			return err
+		}
+
+		check.TargetId = article.Id
+		if err := tx.Create(check).Error; err != nil {
+			return err
+		}
+
+		// 命中送审规则或被垃圾检测隔离的文章先隐藏，进入审核队列
+		if filtered.Action == models.FilterActionModerate {
+			detail := "matched filter rules: " + strings.Join(filtered.Matched, ", ")
+			if err := submitForModeration(tx, article.Id, models.ReportReasonFilter, detail); err != nil {
+				return err
+			}
+		}
+		if quarantined {
+			detail := fmt.Sprintf("spam score %.2f", check.Score)
+			if user.Quarantined {
+				detail += ", author is quarantined"
+			}
+			if err := submitForModeration(tx, article.Id, models.ReportReasonSpam, detail); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	// 填充用户信息
-	article.UserInfo = models.UserInfo{
-		Id:       article.User.Id,
-		Username: article.User.Username,
-		Email:    article.User.Email,
+	article.UserInfo = userInfo(&article.User)
+	if isPublicArticle(&article) {
+		publishArticleEvent(models.EventArticleCreated, &article)
 	}
+	enqueueWebhooks(s.db, models.EventArticleCreated, article.UserId, articleEventData(&article))
+	lockIfProtected(&article, userId, "")
 
 	return &article, nil
 }
 
 // Update 更新帖子
 func (s *ArticleService) Update(userId int, articleId int, request *models.UpdateArticleRequest) (*models.Article, error) {
+	article, _, err := s.update(userId, articleId, request, true)
+	return article, err
+}
+
+// update 更新帖子并返回更新前是否公开。notify 为 false 时不推送动态和 webhook，
+// 用于协同编辑的定期保存，由调用方在会话结束时统一推送
+func (s *ArticleService) update(userId int, articleId int, request *models.UpdateArticleRequest, notify bool) (*models.Article, bool, error) {
 	// 查询帖子
 	var article models.Article
-	if err := s.db.Preload("User").First(&article, articleId).Error; err != nil {
-		return nil, err
+	if err := s.db.Preload("User").Preload("Tags").First(&article, articleId).Error; err != nil {
+		return nil, false, err
 	}
 
-	// 检查帖子所有权
-	if article.UserId != userId {
-		return nil, errors.New("unauthorized to update this article")
+	// 检查权限：作者本人，或有权编辑他人文章的编辑/管理员
+	allowed, onBehalf, err := canManageArticle(s.db, userId, &article, rbac.PermArticleEditAny)
+	if err != nil {
+		return nil, false, err
+	}
+	if !allowed {
+		return nil, false, errors.New("unauthorized to update this article")
+	}
+	wasPublic := isPublicArticle(&article)
+
+	if request.Visibility != "" && !models.ValidVisibility(request.Visibility) {
+		return nil, false, ErrInvalidVisibility
+	}
+
+	// 内容过滤
+	filtered, err := s.filter.Check(request.Title, request.Content)
+	if err != nil {
+		return nil, false, err
+	}
+	if filtered.Action == models.FilterActionReject {
+		return nil, false, ErrContentRejected
 	}
 
 	// 更新帖子
-	article.Title = request.Title
-	article.Content = request.Content
+	article.Title = filtered.Title
+	article.Content = filtered.Content
+	if request.Visibility != "" {
+		article.Visibility = request.Visibility
+	}
+	if request.Password != nil {
+		article.Password = ""
+		if *request.Password != "" {
+			if article.Password, err = utils.HashPassword(*request.Password); err != nil {
+				return nil, false, err
+			}
+		}
+	}
+	moderate := filtered.Action == models.FilterActionModerate && !article.Hidden
+	if moderate {
+		article.Hidden = true
+	}
 
-	if err := s.db.Save(&article).Error; err != nil {
-		return nil, err
+	if err := s.db.Omit("Tags").Save(&article).Error; err != nil {
+		return nil, false, err
+	}
+
+	if moderate {
+		detail := "matched filter rules: " + strings.Join(filtered.Matched, ", ")
+		if err := submitForModeration(s.db, article.Id, models.ReportReasonFilter, detail); err != nil {
+			return nil, false, err
+		}
+	}
+	if onBehalf {
+		if err := logModeration(s.db, userId, &article, models.ModerationActionEdit); err != nil {
+			return nil, false, err
+		}
+	}
+
+	// 更新标签
+	if request.Tags != nil {
+		tags, err := findOrCreateTags(s.db, request.Tags)
+		if err != nil {
+			return nil, false, err
+		}
+		if err := s.db.Model(&article).Association("Tags").Replace(tags); err != nil {
+			return nil, false, err
+		}
+		article.Tags = tags
 	}
 
 	// 填充用户信息
-	article.UserInfo = models.UserInfo{
-		Id:       article.User.Id,
-		Username: article.User.Username,
-		Email:    article.User.Email,
+	article.UserInfo = userInfo(&article.User)
+	if notify {
+		if isPublicArticle(&article) {
+			publishArticleEvent(models.EventArticleUpdated, &article)
+		} else if wasPublic {
+			publishArticleDeleted(&article)
+		}
+		enqueueWebhooks(s.db, models.EventArticleUpdated, article.UserId, articleEventData(&article))
 	}
+	lockIfProtected(&article, userId, "")
 
-	return &article, nil
+	return &article, wasPublic, nil
 }
 
 // Delete 删除帖子
 func (s *ArticleService) Delete(userId int, articleId int) error {
 	var article models.Article
-	if err := s.db.First(&article, articleId).Error; err != nil {
+	if err := s.db.Preload("User").Preload("Tags").First(&article, articleId).Error; err != nil {
 		return err
 	}
 
-	// 验证帖子所有者
-	if article.UserId != userId {
+	// 检查权限：作者本人，或有权删除他人文章的编辑/管理员
+	allowed, onBehalf, err := canManageArticle(s.db, userId, &article, rbac.PermArticleDeleteAny)
+	if err != nil {
+		return err
+	}
+	if !allowed {
 		return errors.New("unauthorized to delete this article")
 	}
 
-	return s.db.Delete(&article).Error
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if onBehalf {
+			if err := logModeration(tx, userId, &article, models.ModerationActionDelete); err != nil {
+				return err
+			}
+		}
+		return deleteArticle(tx, &article)
+	})
+	if err != nil {
+		return err
+	}
+
+	if isPublicArticle(&article) {
+		publishArticleDeleted(&article)
+	}
+	enqueueWebhooks(s.db, models.EventArticleDeleted, article.UserId, articleEventData(&article))
+	return nil
 }
 
-// GetById 获取帖子详情
-func (s *ArticleService) GetById(articleId int) (*models.Article, error) {
+// deleteArticle 删除帖子及其置顶、分享链接、自动保存、协作者和标签关联
+func deleteArticle(tx *gorm.DB, article *models.Article) error {
+	for _, related := range []interface{}{&models.ArticlePin{}, &models.ArticleShare{}, &models.ArticleAutosave{}, &models.ArticleCollaborator{}} {
+		if err := tx.Where("article_id = ?", article.Id).Delete(related).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Select("Tags").Delete(article).Error
+}
+
+// GetById 获取帖子详情，被隐藏或不公开的帖子只有作者和审核人员可见，其他人需要通过分享链接访问。
+// 受密码保护的帖子需要携带解锁后得到的访问令牌才返回正文
+func (s *ArticleService) GetById(viewerId int, articleId int, accessToken string) (*models.Article, error) {
 	var article models.Article
-	if err := s.db.Preload("User").First(&article, articleId).Error; err != nil {
+	if err := s.db.Preload("User").Preload("Tags").First(&article, articleId).Error; err != nil {
 		return nil, err
 	}
 
-	// 填充用户信息（不含密码）
-	article.UserInfo = models.UserInfo{
-		Id:       article.User.Id,
-		Username: article.User.Username,
-		Email:    article.User.Email,
+	if (article.Hidden || article.Visibility != models.VisibilityPublic) && article.UserId != viewerId {
+		moderator, err := isModerator(s.db, viewerId)
+		if err != nil {
+			return nil, err
+		}
+		if !moderator {
+			return nil, gorm.ErrRecordNotFound
+		}
 	}
 
+	// 填充用户信息（不含密码）
+	article.UserInfo = userInfo(&article.User)
+	lockIfProtected(&article, viewerId, accessToken)
+
 	return &article, nil
 }
 
-// List 获取帖子列表（支持搜索、排序、过滤）
-func (s *ArticleService) List(request *models.ArticleListRequest) (*models.ArticleListResponse, error) {
-	var total int64
-	var articles []models.Article
+// Unlock 校验文章密码，成功后返回只对该文章有效的短期访问令牌。
+// 只能解锁公开的文章，不公开和私密的文章只能通过分享链接解锁，避免通过文章ID探测私密文章或猜测其密码
+func (s *ArticleService) Unlock(articleId int, password string) (*models.UnlockArticleResponse, error) {
+	var article models.Article
+	if err := s.db.Select("id", "password", "hidden", "visibility").First(&article, articleId).Error; err != nil {
+		return nil, err
+	}
+	if article.Hidden || article.Visibility != models.VisibilityPublic {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return unlockArticle(&article, password)
+}
 
-	offset := (request.Page - 1) * request.Size
+// unlockArticle 校验文章密码并签发访问令牌
+func unlockArticle(article *models.Article, password string) (*models.UnlockArticleResponse, error) {
+	if article.Password == "" {
+		return nil, ErrArticleNotProtected
+	}
+	if !utils.ValidatePassword(password, article.Password) {
+		return nil, ErrIncorrectArticlePassword
+	}
+
+	token, expiresAt, err := utils.GenerateArticleToken(article.Id, passwordFingerprint(article.Password))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return &models.UnlockArticleResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// passwordFingerprint 文章密码哈希的指纹，写入访问令牌，修改密码后旧令牌随之失效
+func passwordFingerprint(hash string) string {
+	sum := sha256.Sum256([]byte(hash))
+	return hex.EncodeToString(sum[:8])
+}
+
+// lockIfProtected 对受密码保护的帖子隐藏正文，只保留标题和摘要；作者本人或持有有效访问令牌时返回全文
+func lockIfProtected(article *models.Article, viewerId int, accessToken string) {
+	if article.Password == "" {
+		return
+	}
+	article.Protected = true
+
+	if viewerId > 0 && article.UserId == viewerId {
+		return
+	}
+	if accessToken != "" {
+		claims, err := utils.ParseArticleToken(accessToken)
+		if err == nil && claims.ArticleId == article.Id && claims.Fingerprint == passwordFingerprint(article.Password) {
+			return
+		}
+	}
+
+	runes := []rune(article.Content)
+	if len(runes) > excerptLength {
+		article.Excerpt = string(runes[:excerptLength]) + "..."
+	} else {
+		article.Excerpt = article.Content
+	}
+	article.Content = ""
+	article.Locked = true
+}
+
+// visibleTo 限制查询范围为查看者可见的帖子：审核人员可见全部，其他人只能看到未隐藏的帖子和自己的帖子
+func visibleTo(viewerId int, moderator bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if moderator {
+			return db
+		}
+		if viewerId > 0 {
+			return db.Where("hidden = ? OR user_id = ?", false, viewerId)
+		}
+		return db.Where("hidden = ?", false)
+	}
+}
 
-	// 构建查询条件
-	query := s.db.Model(&models.Article{})
-	countQuery := s.db.Model(&models.Article{})
+// filterQuery 根据列表请求构建带过滤条件的查询
+func (s *ArticleService) filterQuery(viewerId int, moderator bool, request *models.ArticleListRequest) *gorm.DB {
+	query := s.db.Model(&models.Article{}).Scopes(visibleTo(viewerId, moderator))
 
-	// 搜索功能：按标题或内容搜索
+	// 列表只展示公开帖子，作者查看自己的帖子列表时除外
+	if request.UserId <= 0 || request.UserId != viewerId {
+		query = query.Where("visibility = ?", models.VisibilityPublic)
+	}
+
+	// 搜索功能：按标题或内容搜索。受密码保护的帖子只有作者本人可以搜索正文，避免通过搜索结果推断正文内容
 	if request.Search != "" {
-		searchCondition := "title LIKE ? OR content LIKE ?"
 		searchValue := "%" + request.Search + "%"
-		query = query.Where(searchCondition, searchValue, searchValue)
-		countQuery = countQuery.Where(searchCondition, searchValue, searchValue)
+		query = query.Where("title LIKE ? OR (content LIKE ? AND (COALESCE(password, '') = '' OR user_id = ?))", searchValue, searchValue, viewerId)
 	}
 
 	// 按用户ID过滤
 	if request.UserId > 0 {
 		query = query.Where("user_id = ?", request.UserId)
-		countQuery = countQuery.Where("user_id = ?", request.UserId)
+	}
+
+	// 按标签过滤
+	if request.Tag != "" {
+		tagQuery := s.db.Table("article_tags").
+			Select("article_tags.article_id").
+			Joins("JOIN tags ON tags.id = article_tags.tag_id").
+			Where("tags.name = ?", request.Tag)
+		query = query.Where("id IN (?)", tagQuery)
+	}
+
+	return query
+}
+
+// List 获取帖子列表（支持搜索、排序、过滤）
+// 当前范围（全站或标签）内有效的置顶文章只在第一页最前面返回，并从常规分页中排除
+func (s *ArticleService) List(viewerId int, request *models.ArticleListRequest) (*models.ArticleListResponse, error) {
+	var total int64
+	var articles []models.Article
+
+	offset := (request.Page - 1) * request.Size
+
+	moderator, err := isModerator(s.db, viewerId)
+	if err != nil {
+		return nil, err
+	}
+
+	// 查询当前范围内的置顶文章
+	pins, err := activePins(s.db, request.Tag)
+	if err != nil {
+		return nil, err
+	}
+	var pinned []models.Article
+	if len(pins) > 0 {
+		pinnedIds := make([]int, len(pins))
+		positions := make(map[int]int, len(pins))
+		for i, pin := range pins {
+			pinnedIds[i] = pin.ArticleId
+			positions[pin.ArticleId] = i
+		}
+		if err := s.filterQuery(viewerId, moderator, request).Preload("User").Preload("Tags").Where("id IN ?", pinnedIds).Find(&pinned).Error; err != nil {
+			return nil, err
+		}
+		sort.Slice(pinned, func(i, j int) bool {
+			return positions[pinned[i].Id] < positions[pinned[j].Id]
+		})
+		for i := range pinned {
+			pinned[i].Pinned = true
+		}
+	}
+
+	query := s.filterQuery(viewerId, moderator, request)
+	countQuery := s.filterQuery(viewerId, moderator, request)
+	if len(pinned) > 0 {
+		pinnedIds := make([]int, len(pinned))
+		for i, article := range pinned {
+			pinnedIds[i] = article.Id
+		}
+		query = query.Where("id NOT IN ?", pinnedIds)
+		countQuery = countQuery.Where("id NOT IN ?", pinnedIds)
 	}
 
 	// 获取总数
@@ -156,29 +492,34 @@ func (s *ArticleService) List(request *models.ArticleListRequest) (*models.Artic
 	}
 
 	// 获取分页数据
-	if err := query.Preload("User").Order(orderBy).Offset(offset).Limit(request.Size).Find(&articles).Error; err != nil {
+	if err := query.Preload("User").Preload("Tags").Order(orderBy).Offset(offset).Limit(request.Size).Find(&articles).Error; err != nil {
 		return nil, err
 	}
 
-	// 填充用户信息（不含密码）
-	articleResponses := make([]models.Article, len(articles))
-	for i, article := range articles {
-		articleResponses[i] = article
-		articleResponses[i].UserInfo = models.UserInfo{
-			Id:       article.User.Id,
-			Username: article.User.Username,
-			Email:    article.User.Email,
-		}
+	// 第一页在最前面插入置顶文章
+	if request.Page == 1 {
+		articles = append(pinned, articles...)
 	}
 
 	return &models.ArticleListResponse{
-		Articles: articleResponses,
-		Total:    int(total),
+		Articles: fillArticles(articles, viewerId),
+		Total:    int(total) + len(pinned),
 		Page:     request.Page,
 		Size:     request.Size,
 	}, nil
 }
 
+// fillArticles 填充列表中帖子的用户信息（不含密码），并对受密码保护的帖子隐藏正文
+func fillArticles(articles []models.Article, viewerId int) []models.Article {
+	articleResponses := make([]models.Article, len(articles))
+	for i, article := range articles {
+		articleResponses[i] = article
+		articleResponses[i].UserInfo = userInfo(&article.User)
+		lockIfProtected(&articleResponses[i], viewerId, "")
+	}
+	return articleResponses
+}
+
 // GetStats 获取文章统计信息
 func (s *ArticleService) GetStats() (*models.ArticleStatsResponse, error) {
 	var totalArticles int64
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"server/internal/models"
+	"server/pkg/utils"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// createProtectedArticle 创建密码为 article-password 的文章
+func createProtectedArticle(t *testing.T, service *ArticleService, visibility string) *models.Article {
+	t.Helper()
+	password, err := utils.HashPassword("article-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	article := &models.Article{UserId: 1, Title: "内部资料", Content: "正文", Visibility: visibility, Password: password}
+	if err := service.db.Create(article).Error; err != nil {
+		t.Fatal(err)
+	}
+	return article
+}
+
+func TestUnlockArticle(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	service := NewArticleService(newTestDB(t, appTables...))
+
+	public := createProtectedArticle(t, service, models.VisibilityPublic)
+	if _, err := service.Unlock(public.Id, "wrong-password"); err != ErrIncorrectArticlePassword {
+		t.Errorf("wrong password: %v, want ErrIncorrectArticlePassword", err)
+	}
+	response, err := service.Unlock(public.Id, "article-password")
+	if err != nil || response.Token == "" {
+		t.Fatalf("Unlock: %+v, %v", response, err)
+	}
+
+	// 不公开和私密的文章不能通过文章ID解锁，密码正确与否都返回不存在
+	for _, visibility := range []string{models.VisibilityUnlisted, models.VisibilityPrivate} {
+		article := createProtectedArticle(t, service, visibility)
+		for _, password := range []string{"wrong-password", "article-password"} {
+			if _, err := service.Unlock(article.Id, password); err != gorm.ErrRecordNotFound {
+				t.Errorf("%s article with password %q: %v, want not found", visibility, password, err)
+			}
+		}
+	}
+}
+
+func TestUnlockSharedArticle(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	db := newTestDB(t, appTables...)
+	articles := NewArticleService(db)
+	shares := NewShareService(db)
+
+	article := createProtectedArticle(t, articles, models.VisibilityPrivate)
+	expired := time.Now().Add(-time.Minute)
+	now := time.Now()
+	if err := db.Create(&[]models.ArticleShare{
+		{ArticleId: article.Id, Token: "valid-share", CreatedBy: 1},
+		{ArticleId: article.Id, Token: "expired-share", ExpiresAt: &expired, CreatedBy: 1},
+		{ArticleId: article.Id, Token: "revoked-share", RevokedAt: &now, CreatedBy: 1},
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := shares.Unlock("valid-share", "wrong-password"); err != ErrIncorrectArticlePassword {
+		t.Errorf("wrong password: %v, want ErrIncorrectArticlePassword", err)
+	}
+	response, err := shares.Unlock("valid-share", "article-password")
+	if err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+
+	// 解锁得到的访问令牌可以通过分享链接读取正文
+	shared, err := shares.GetByToken("valid-share", response.Token)
+	if err != nil || shared.Content != "正文" {
+		t.Fatalf("GetByToken with access token: %+v, %v", shared, err)
+	}
+
+	for _, token := range []string{"expired-share", "revoked-share", "unknown-share"} {
+		if _, err := shares.Unlock(token, "article-password"); err != gorm.ErrRecordNotFound {
+			t.Errorf("Unlock via %s: %v, want not found", token, err)
+		}
+	}
+}
+
+func TestCreateArticleModerationAtomic(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	db := newTestDB(t, appTables...)
+	now := time.Now()
+	user := &models.User{Username: "alice", Email: "alice@example.com", Role: models.RoleAuthor, EmailVerifiedAt: &now}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.FilterRule{Type: models.FilterTypeWord, Pattern: "广告", Action: models.FilterActionModerate}).Error; err != nil {
+		t.Fatal(err)
+	}
+	invalidateFilter()
+	t.Cleanup(invalidateFilter)
+	service := NewArticleService(db)
+
+	article, err := service.Create(user.Id, &models.CreateArticleRequest{Title: "标题", Content: "广告"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	var reports int64
+	db.Model(&models.Report{}).Where("article_id = ? AND reason = ?", article.Id, models.ReportReasonFilter).Count(&reports)
+	if !article.Hidden || reports != 1 {
+		t.Errorf("hidden = %v, %d reports", article.Hidden, reports)
+	}
+
+	// 送审失败时文章和垃圾检测记录一起回滚
+	if err := db.Migrator().DropTable(&models.Report{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Create(user.Id, &models.CreateArticleRequest{Title: "另一篇", Content: "广告"}); err == nil {
+		t.Fatal("Create succeeded without the report table")
+	}
+	var articles, checks int64
+	db.Model(&models.Article{}).Count(&articles)
+	db.Model(&models.SpamCheck{}).Count(&checks)
+	if articles != 1 || checks != 1 {
+		t.Errorf("%d articles and %d spam checks after failed create, want 1 and 1", articles, checks)
+	}
+}
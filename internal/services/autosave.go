@@ -0,0 +1,164 @@
+package services
+
+import (
+	"os"
+	"server/internal/models"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAutosaveCoalesce = 30 * time.Second
+	defaultAutosaveMax      = 20
+)
+
+type AutosaveService struct {
+	db             *gorm.DB
+	articleService *ArticleService
+}
+
+func NewAutosaveService(db *gorm.DB) *AutosaveService {
+	return &AutosaveService{
+		db:             db,
+		articleService: NewArticleService(db),
+	}
+}
+
+// autosaveCoalesce 合并窗口：距上次自动保存不超过该时长时覆盖上一条记录，可通过 AUTOSAVE_COALESCE 配置
+func autosaveCoalesce() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv("AUTOSAVE_COALESCE")); err == nil && value >= 0 {
+		return value
+	}
+	return defaultAutosaveCoalesce
+}
+
+// autosaveMax 每篇文章每个作者最多保留的自动保存记录数，可通过 AUTOSAVE_MAX 配置
+func autosaveMax() int {
+	if value, err := strconv.Atoi(os.Getenv("AUTOSAVE_MAX")); err == nil && value > 0 {
+		return value
+	}
+	return defaultAutosaveMax
+}
+
+// checkAuthor 检查文章是否存在且属于该用户
+func (s *AutosaveService) checkAuthor(userId int, articleId int) error {
+	var article models.Article
+	if err := s.db.Select("id", "user_id").First(&article, articleId).Error; err != nil {
+		return err
+	}
+	if article.UserId != userId {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// Save 自动保存工作副本：合并窗口内的多次保存会覆盖同一条记录，超出数量上限时删除最旧的记录
+func (s *AutosaveService) Save(userId int, articleId int, request *models.AutosaveRequest) (*models.ArticleAutosave, error) {
+	if err := s.checkAuthor(userId, articleId); err != nil {
+		return nil, err
+	}
+
+	var autosave models.ArticleAutosave
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("article_id = ? AND user_id = ?", articleId, userId).Order("id desc").First(&autosave).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+
+		if err == gorm.ErrRecordNotFound || time.Since(autosave.UpdatedAt) > autosaveCoalesce() {
+			autosave = models.ArticleAutosave{ArticleId: articleId, UserId: userId}
+		}
+		autosave.Title = request.Title
+		autosave.Content = request.Content
+		autosave.Saves++
+		if err := tx.Save(&autosave).Error; err != nil {
+			return err
+		}
+
+		// 只保留最近的若干条记录
+		var staleIds []int
+		if err := tx.Model(&models.ArticleAutosave{}).
+			Where("article_id = ? AND user_id = ?", articleId, userId).
+			Order("id desc").Offset(autosaveMax()).
+			Pluck("id", &staleIds).Error; err != nil {
+			return err
+		}
+		if len(staleIds) > 0 {
+			return tx.Delete(&models.ArticleAutosave{}, staleIds).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &autosave, nil
+}
+
+// Get 获取最新的工作副本和自动保存历史
+func (s *AutosaveService) Get(userId int, articleId int) (*models.AutosaveResponse, error) {
+	if err := s.checkAuthor(userId, articleId); err != nil {
+		return nil, err
+	}
+
+	var history []models.ArticleAutosave
+	if err := s.db.Where("article_id = ? AND user_id = ?", articleId, userId).Order("id desc").Find(&history).Error; err != nil {
+		return nil, err
+	}
+	if len(history) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	current := history[0]
+	for i := range history {
+		history[i].Content = ""
+	}
+
+	return &models.AutosaveResponse{
+		Current: &current,
+		History: history,
+	}, nil
+}
+
+// Discard 丢弃工作副本
+func (s *AutosaveService) Discard(userId int, articleId int) error {
+	if err := s.checkAuthor(userId, articleId); err != nil {
+		return err
+	}
+
+	return s.db.Where("article_id = ? AND user_id = ?", articleId, userId).Delete(&models.ArticleAutosave{}).Error
+}
+
+// Publish 将最新的工作副本发布为文章的正式内容（经过与普通更新相同的校验和过滤），发布后清空工作副本。
+// autosaveId 不为 0 时发布指定的历史记录
+func (s *AutosaveService) Publish(userId int, articleId int, autosaveId int) (*models.Article, error) {
+	if err := s.checkAuthor(userId, articleId); err != nil {
+		return nil, err
+	}
+
+	var autosave models.ArticleAutosave
+	query := s.db.Where("article_id = ? AND user_id = ?", articleId, userId)
+	if autosaveId > 0 {
+		query = query.Where("id = ?", autosaveId)
+	}
+	if err := query.Order("id desc").First(&autosave).Error; err != nil {
+		return nil, err
+	}
+
+	article, err := s.articleService.Update(userId, articleId, &models.UpdateArticleRequest{
+		Id:      articleId,
+		Title:   autosave.Title,
+		Content: autosave.Content,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Where("article_id = ? AND user_id = ?", articleId, userId).Delete(&models.ArticleAutosave{}).Error; err != nil {
+		return nil, err
+	}
+
+	return article, nil
+}
@@ -0,0 +1,593 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"server/internal/models"
+	"server/pkg/ot"
+	"server/pkg/utils"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRevisionTooOld         = errors.New("revision is no longer in history, please reload the document")
+	ErrInvalidRevision        = errors.New("invalid revision")
+	ErrInvalidCollaborator    = errors.New("invalid collaborator")
+	ErrUnknownCollabMessage   = errors.New("unknown message type")
+	ErrCollabOperationMissing = errors.New("operation is required")
+	ErrCollabDocumentTooLarge = errors.New("document is too large")
+)
+
+const (
+	// 每个会话保留的最近操作数，落后更多版本的客户端需要重新加载
+	collabHistoryLimit = 1000
+	// 客户端发送队列长度，队列满说明客户端过慢，会被断开
+	collabSendBuffer           = 256
+	defaultCollabPersistPeriod = 10 * time.Second
+	// 协同文档的默认最大长度（UTF-16 码元）
+	defaultCollabMaxDocumentLength = 1 << 20
+)
+
+// collabPersistPeriod 协同文档定期保存的间隔，可通过 COLLAB_PERSIST_INTERVAL 配置
+func collabPersistPeriod() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv("COLLAB_PERSIST_INTERVAL")); err == nil && value > 0 {
+		return value
+	}
+	return defaultCollabPersistPeriod
+}
+
+// collabMaxDocumentLength 协同文档的最大长度（UTF-16 码元），可通过 COLLAB_MAX_DOCUMENT_LENGTH 配置
+func collabMaxDocumentLength() int {
+	if value, err := strconv.Atoi(os.Getenv("COLLAB_MAX_DOCUMENT_LENGTH")); err == nil && value > 0 {
+		return value
+	}
+	return defaultCollabMaxDocumentLength
+}
+
+// 所有进行中的协同编辑会话，按文章ID索引
+var collabRooms = struct {
+	sync.Mutex
+	rooms map[int]*collabRoom
+}{rooms: make(map[int]*collabRoom)}
+
+// collabRoom 单篇文章的协同编辑会话，使用中心化的操作转换：
+// 服务端维护权威文档和操作历史，客户端提交基于某个版本的操作，服务端将其与之后的并发操作转换后再应用
+type collabRoom struct {
+	mu           sync.Mutex
+	db           *gorm.DB
+	articleId    int
+	document     string
+	revision     int             // 当前版本号，即已应用的操作总数
+	history      []*ot.Operation // 最近的操作
+	historyStart int             // history[0] 对应的版本号
+	clients      map[string]*CollabClient
+	dirty        bool
+	stop         chan struct{}
+	persistMu    sync.Mutex // 保证同一时间只有一次保存，避免失败的保存与关闭会话交错
+	unpublished  bool       // 已保存但尚未推送文章更新事件，会话结束时统一推送
+	wasPublic    bool       // 第一次未推送的保存之前文章是否公开
+}
+
+// CollabClient 一个协同编辑连接
+type CollabClient struct {
+	Id       string
+	UserId   int
+	Username string
+	Send     chan []byte // 待发送给客户端的消息，会话结束或客户端过慢时关闭
+
+	room   *collabRoom
+	cursor *models.CollabCursor
+	closed bool // 发送队列已关闭，由会话锁保护
+}
+
+type CollabService struct {
+	db *gorm.DB
+}
+
+func NewCollabService(db *gorm.DB) *CollabService {
+	return &CollabService{db: db}
+}
+
+// CheckAccess 检查用户是否可以协同编辑文章（作者或协作者），返回用户信息
+func (s *CollabService) CheckAccess(userId int, articleId int) (*models.User, error) {
+	var article models.Article
+	if err := s.db.Select("id", "user_id").First(&article, articleId).Error; err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := s.db.First(&user, userId).Error; err != nil {
+		return nil, err
+	}
+	if article.UserId == userId {
+		return &user, nil
+	}
+
+	var count int64
+	if err := s.db.Model(&models.ArticleCollaborator{}).
+		Where("article_id = ? AND user_id = ?", articleId, userId).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrPermissionDenied
+	}
+	return &user, nil
+}
+
+// checkAuthor 检查文章是否存在且属于该用户
+func (s *CollabService) checkAuthor(userId int, articleId int) error {
+	var article models.Article
+	if err := s.db.Select("id", "user_id").First(&article, articleId).Error; err != nil {
+		return err
+	}
+	if article.UserId != userId {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// AddCollaborator 作者添加协作者
+func (s *CollabService) AddCollaborator(ownerId int, articleId int, userId int) (*models.ArticleCollaborator, error) {
+	if err := s.checkAuthor(ownerId, articleId); err != nil {
+		return nil, err
+	}
+	if userId == ownerId {
+		return nil, ErrInvalidCollaborator
+	}
+
+	var user models.User
+	if err := s.db.First(&user, userId).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrInvalidCollaborator
+		}
+		return nil, err
+	}
+
+	collaborator := models.ArticleCollaborator{ArticleId: articleId, UserId: userId}
+	if err := s.db.Where(&collaborator).FirstOrCreate(&collaborator, models.ArticleCollaborator{AddedBy: ownerId}).Error; err != nil {
+		return nil, err
+	}
+	collaborator.UserInfo = userInfo(&user)
+	return &collaborator, nil
+}
+
+// RemoveCollaborator 作者移除协作者，已连接的会话会被断开
+func (s *CollabService) RemoveCollaborator(ownerId int, articleId int, userId int) error {
+	if err := s.checkAuthor(ownerId, articleId); err != nil {
+		return err
+	}
+
+	result := s.db.Where("article_id = ? AND user_id = ?", articleId, userId).Delete(&models.ArticleCollaborator{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	disconnectCollaborator(articleId, userId)
+	return nil
+}
+
+// ListCollaborators 获取文章协作者
+func (s *CollabService) ListCollaborators(ownerId int, articleId int) ([]models.ArticleCollaborator, error) {
+	if err := s.checkAuthor(ownerId, articleId); err != nil {
+		return nil, err
+	}
+
+	var collaborators []models.ArticleCollaborator
+	if err := s.db.Preload("User").Where("article_id = ?", articleId).Order("id asc").Find(&collaborators).Error; err != nil {
+		return nil, err
+	}
+	for i, collaborator := range collaborators {
+		collaborators[i].UserInfo = userInfo(&collaborator.User)
+	}
+	return collaborators, nil
+}
+
+// Join 加入文章的协同编辑会话，会话不存在时从数据库加载文章内容创建
+func (s *CollabService) Join(articleId int, user *models.User) (*CollabClient, error) {
+	clientId, err := utils.GenerateRandomToken(9)
+	if err != nil {
+		return nil, err
+	}
+	client := &CollabClient{
+		Id:       clientId,
+		UserId:   user.Id,
+		Username: user.Username,
+		Send:     make(chan []byte, collabSendBuffer),
+	}
+
+	collabRooms.Lock()
+	defer collabRooms.Unlock()
+
+	room := collabRooms.rooms[articleId]
+	if room == nil {
+		var article models.Article
+		if err := s.db.Select("id", "content").First(&article, articleId).Error; err != nil {
+			return nil, err
+		}
+		room = &collabRoom{
+			db:        s.db,
+			articleId: articleId,
+			document:  article.Content,
+			clients:   make(map[string]*CollabClient),
+			stop:      make(chan struct{}),
+		}
+		collabRooms.rooms[articleId] = room
+		go room.persistLoop()
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	client.room = room
+	clients := make([]models.CollabClientInfo, 0, len(room.clients))
+	for _, other := range room.clients {
+		clients = append(clients, other.info())
+		other.send(&models.CollabMessage{
+			Type:     models.CollabMessageJoin,
+			Revision: room.revision,
+			ClientId: client.Id,
+			UserId:   client.UserId,
+			Username: client.Username,
+		})
+	}
+	room.clients[client.Id] = client
+
+	document := room.document
+	client.send(&models.CollabMessage{
+		Type:     models.CollabMessageInit,
+		Revision: room.revision,
+		ClientId: client.Id,
+		Document: &document,
+		Clients:  clients,
+	})
+
+	return client, nil
+}
+
+// disconnectCollaborator 断开某个用户在文章会话中的所有连接
+func disconnectCollaborator(articleId int, userId int) {
+	collabRooms.Lock()
+	room := collabRooms.rooms[articleId]
+	collabRooms.Unlock()
+	if room == nil {
+		return
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	for _, client := range room.clients {
+		if client.UserId == userId {
+			client.close()
+		}
+	}
+}
+
+// info 在线协作者信息
+func (c *CollabClient) info() models.CollabClientInfo {
+	return models.CollabClientInfo{
+		ClientId: c.Id,
+		UserId:   c.UserId,
+		Username: c.Username,
+		Cursor:   c.cursor,
+	}
+}
+
+// send 向客户端发送消息，调用方需持有会话锁。发送队列已满时断开该客户端
+func (c *CollabClient) send(message *models.CollabMessage) {
+	if c.closed {
+		return
+	}
+	data, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+	select {
+	case c.Send <- data:
+	default:
+		c.close()
+	}
+}
+
+// close 关闭发送队列，连接的写协程会随之退出并断开连接，调用方需持有会话锁
+func (c *CollabClient) close() {
+	if !c.closed {
+		c.closed = true
+		close(c.Send)
+	}
+}
+
+// Handle 处理客户端消息，返回的错误会以 error 消息发送给该客户端
+func (c *CollabClient) Handle(message *models.CollabMessage) error {
+	switch message.Type {
+	case models.CollabMessageOperation:
+		if message.Operation == nil {
+			return ErrCollabOperationMissing
+		}
+		return c.room.receiveOperation(c, message.Revision, message.Operation, message.Cursor)
+	case models.CollabMessageCursor:
+		c.room.updateCursor(c, message.Cursor)
+		return nil
+	default:
+		return ErrUnknownCollabMessage
+	}
+}
+
+// SendError 向客户端发送错误消息
+func (c *CollabClient) SendError(err error) {
+	c.room.mu.Lock()
+	defer c.room.mu.Unlock()
+	if _, ok := c.room.clients[c.Id]; ok {
+		c.send(&models.CollabMessage{Type: models.CollabMessageError, Message: err.Error()})
+	}
+}
+
+// Disconnect 向客户端发送错误消息后断开连接，用于登录令牌失效等情况
+func (c *CollabClient) Disconnect(err error) {
+	c.room.mu.Lock()
+	defer c.room.mu.Unlock()
+	if _, ok := c.room.clients[c.Id]; ok {
+		c.send(&models.CollabMessage{Type: models.CollabMessageError, Message: err.Error()})
+		c.close()
+	}
+}
+
+// Leave 离开会话，最后一个协作者离开时保存文档并关闭会话；保存失败时保留会话，由定期保存继续重试
+func (c *CollabClient) Leave() {
+	room := c.room
+
+	room.mu.Lock()
+	if _, ok := room.clients[c.Id]; !ok {
+		room.mu.Unlock()
+		return
+	}
+	delete(room.clients, c.Id)
+	c.close()
+	for _, other := range room.clients {
+		other.send(&models.CollabMessage{
+			Type:     models.CollabMessageLeave,
+			Revision: room.revision,
+			ClientId: c.Id,
+			UserId:   c.UserId,
+			Username: c.Username,
+		})
+	}
+	empty := len(room.clients) == 0
+	room.mu.Unlock()
+
+	if !empty {
+		return
+	}
+
+	// 先保存再关闭会话；保存期间有人加入时会话继续使用内存中的文档
+	if err := room.persist(); err != nil {
+		return
+	}
+	room.closeIfIdle()
+}
+
+// closeIfIdle 没有协作者且文档已保存时关闭会话，并推送一次文章更新事件
+func (r *collabRoom) closeIfIdle() {
+	collabRooms.Lock()
+	r.mu.Lock()
+	closed := len(r.clients) == 0 && !r.dirty && collabRooms.rooms[r.articleId] == r
+	if closed {
+		delete(collabRooms.rooms, r.articleId)
+		close(r.stop)
+	}
+	unpublished, wasPublic := closed && r.unpublished, r.wasPublic
+	if unpublished {
+		r.unpublished = false
+	}
+	r.mu.Unlock()
+	collabRooms.Unlock()
+
+	if unpublished {
+		publishArticleChange(r.db, r.articleId, wasPublic)
+		var article models.Article
+		if err := r.db.Preload("User").Preload("Tags").First(&article, r.articleId).Error; err == nil {
+			enqueueWebhooks(r.db, models.EventArticleUpdated, article.UserId, articleEventData(&article))
+		}
+	}
+}
+
+// receiveOperation 接收客户端基于 revision 版本的操作，转换后应用并广播
+func (r *collabRoom) receiveOperation(sender *CollabClient, revision int, operation *ot.Operation, cursor *models.CollabCursor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if revision < 0 || revision > r.revision {
+		return ErrInvalidRevision
+	}
+	if revision < r.historyStart {
+		return ErrRevisionTooOld
+	}
+	operation, err := r.apply(revision, operation)
+	if err != nil {
+		return err
+	}
+	r.dirty = true
+
+	// 发送者携带了新光标时以其为准
+	if cursor != nil {
+		sender.cursor = cursor
+	}
+
+	for _, client := range r.clients {
+		if client == sender {
+			client.send(&models.CollabMessage{Type: models.CollabMessageAck, Revision: r.revision})
+			continue
+		}
+		client.send(&models.CollabMessage{
+			Type:      models.CollabMessageOperation,
+			Revision:  r.revision,
+			Operation: operation,
+			Cursor:    sender.cursor,
+			ClientId:  sender.Id,
+			UserId:    sender.UserId,
+			Username:  sender.Username,
+		})
+	}
+	return nil
+}
+
+// apply 将基于 revision 版本的操作与之后的并发操作逐一转换后应用到文档，并随之移动所有光标，返回转换后的操作。
+// 调用方需持有会话锁并已校验 revision
+func (r *collabRoom) apply(revision int, operation *ot.Operation) (*ot.Operation, error) {
+	for _, concurrent := range r.history[revision-r.historyStart:] {
+		transformed, _, err := ot.Transform(operation, concurrent)
+		if err != nil {
+			return nil, err
+		}
+		operation = transformed
+	}
+	// 超过长度上限时只允许不增加长度的编辑
+	if operation.TargetLength > collabMaxDocumentLength() && operation.TargetLength > operation.BaseLength {
+		return nil, ErrCollabDocumentTooLarge
+	}
+
+	document, err := operation.Apply(r.document)
+	if err != nil {
+		return nil, err
+	}
+	r.document = document
+	r.revision++
+	r.history = append(r.history, operation)
+	if len(r.history) > collabHistoryLimit {
+		trim := len(r.history) - collabHistoryLimit
+		r.history = append([]*ot.Operation(nil), r.history[trim:]...)
+		r.historyStart += trim
+	}
+
+	for _, client := range r.clients {
+		if client.cursor != nil {
+			client.cursor = &models.CollabCursor{
+				Position:     ot.TransformIndex(operation, client.cursor.Position),
+				SelectionEnd: ot.TransformIndex(operation, client.cursor.SelectionEnd),
+			}
+		}
+	}
+	return operation, nil
+}
+
+// updateCursor 更新光标并广播给其他客户端，cursor 为 nil 表示失去焦点
+func (r *collabRoom) updateCursor(sender *CollabClient, cursor *models.CollabCursor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sender.cursor = cursor
+	for _, client := range r.clients {
+		if client == sender {
+			continue
+		}
+		client.send(&models.CollabMessage{
+			Type:     models.CollabMessageCursor,
+			Revision: r.revision,
+			Cursor:   cursor,
+			ClientId: sender.Id,
+			UserId:   sender.UserId,
+			Username: sender.Username,
+		})
+	}
+}
+
+// persistLoop 定期保存有改动的文档，会话关闭时退出
+func (r *collabRoom) persistLoop() {
+	ticker := time.NewTicker(collabPersistPeriod())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// 最后一个协作者离开时保存失败的会话在这里重试，保存成功后关闭
+			if err := r.persist(); err == nil {
+				r.closeIfIdle()
+			}
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// persist 通过 ArticleService 的更新流程保存当前文档（经过与普通更新相同的内容过滤），以作者身份更新正文、保留标题。
+// 定期保存不推送动态和 webhook，避免编辑期间频繁通知订阅方。文章已被删除时丢弃文档。
+// 文档被内容过滤拒绝时不再重试，直到协作者继续编辑；没有协作者时会话随之关闭，未保存的文档被丢弃。
+// 敏感词被替换后，替换作为一次服务端操作应用到内存中的文档并广播，保证协作者看到的与保存的一致
+func (r *collabRoom) persist() error {
+	r.persistMu.Lock()
+	defer r.persistMu.Unlock()
+
+	r.mu.Lock()
+	if !r.dirty {
+		r.mu.Unlock()
+		return nil
+	}
+	document := r.document
+	revision := r.revision
+	r.dirty = false
+	r.mu.Unlock()
+
+	var saved *models.Article
+	var wasPublic bool
+	err := func() error {
+		var article models.Article
+		if err := r.db.Select("id", "user_id", "title").First(&article, r.articleId).Error; err != nil {
+			return err
+		}
+		var err error
+		saved, wasPublic, err = NewArticleService(r.db).update(article.UserId, r.articleId, &models.UpdateArticleRequest{
+			Id:      r.articleId,
+			Title:   article.Title,
+			Content: document,
+		}, false)
+		return err
+	}()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == gorm.ErrRecordNotFound {
+		r.unpublished = false
+		return nil
+	}
+	if err == ErrContentRejected {
+		for _, client := range r.clients {
+			client.send(&models.CollabMessage{Type: models.CollabMessageError, Revision: revision, Message: "document was not saved: " + err.Error()})
+		}
+		return nil
+	}
+	if err != nil {
+		log.Printf("collab: failed to persist article %d: %v", r.articleId, err)
+		r.dirty = true
+		for _, client := range r.clients {
+			client.send(&models.CollabMessage{Type: models.CollabMessageError, Revision: revision, Message: "failed to save document: " + err.Error()})
+		}
+		return err
+	}
+	if !r.unpublished {
+		r.unpublished = true
+		r.wasPublic = wasPublic
+	}
+	if saved.Content != document && revision >= r.historyStart {
+		operation, err := r.apply(revision, ot.Diff(document, saved.Content))
+		if err != nil {
+			log.Printf("collab: failed to apply filtered content of article %d: %v", r.articleId, err)
+		} else {
+			for _, client := range r.clients {
+				client.send(&models.CollabMessage{Type: models.CollabMessageOperation, Revision: r.revision, Operation: operation})
+			}
+		}
+	}
+	for _, client := range r.clients {
+		client.send(&models.CollabMessage{Type: models.CollabMessageSaved, Revision: revision})
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"encoding/json"
+	"server/internal/models"
+	"server/pkg/ot"
+	"testing"
+)
+
+// newCollabTest 创建作者 alice 的文章，并让 alice 和协作者 bob 加入协同编辑会话
+func newCollabTest(t *testing.T, rules ...models.FilterRule) (*CollabService, *models.Article, *CollabClient, *CollabClient) {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("COLLAB_PERSIST_INTERVAL", "1h")
+	db := newTestDB(t, appTables...)
+
+	alice := &models.User{Username: "alice", Email: "alice@example.com", Role: models.RoleAuthor}
+	bob := &models.User{Username: "bob", Email: "bob@example.com", Role: models.RoleAuthor}
+	if err := db.Create([]*models.User{alice, bob}).Error; err != nil {
+		t.Fatal(err)
+	}
+	article := &models.Article{UserId: alice.Id, Title: "标题", Content: "正文", Visibility: models.VisibilityPublic}
+	if err := db.Create(article).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) > 0 {
+		if err := db.Create(&rules).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	invalidateFilter()
+	t.Cleanup(invalidateFilter)
+
+	service := NewCollabService(db)
+	first, err := service.Join(article.Id, alice)
+	if err != nil {
+		t.Fatalf("Join: %v", err)
+	}
+	second, err := service.Join(article.Id, bob)
+	if err != nil {
+		t.Fatalf("Join: %v", err)
+	}
+	t.Cleanup(func() {
+		first.Leave()
+		second.Leave()
+	})
+	receive(t, first)
+	receive(t, second)
+	return service, article, first, second
+}
+
+// receive 取出客户端发送队列中的全部消息
+func receive(t *testing.T, client *CollabClient) []models.CollabMessage {
+	t.Helper()
+	var messages []models.CollabMessage
+	for {
+		select {
+		case data, ok := <-client.Send:
+			if !ok {
+				return messages
+			}
+			var message models.CollabMessage
+			if err := json.Unmarshal(data, &message); err != nil {
+				t.Fatal(err)
+			}
+			messages = append(messages, message)
+		default:
+			return messages
+		}
+	}
+}
+
+// insertAt 构造在文档 position 处插入文本的操作
+func insertAt(length int, position int, text string) *ot.Operation {
+	return (&ot.Operation{}).Retain(position).Insert(text).Retain(length - position)
+}
+
+func roomOf(articleId int) *collabRoom {
+	collabRooms.Lock()
+	defer collabRooms.Unlock()
+	return collabRooms.rooms[articleId]
+}
+
+func TestCollabPersistMasked(t *testing.T) {
+	service, article, alice, bob := newCollabTest(t, models.FilterRule{Type: models.FilterTypeWord, Pattern: "敏感", Action: models.FilterActionMask})
+
+	if err := alice.Handle(&models.CollabMessage{Type: models.CollabMessageOperation, Revision: 0, Operation: insertAt(2, 2, "含敏感词")}); err != nil {
+		t.Fatalf("operation: %v", err)
+	}
+	receive(t, bob)
+
+	room := roomOf(article.Id)
+	if err := room.persist(); err != nil {
+		t.Fatalf("persist: %v", err)
+	}
+
+	// 打码作为一次服务端操作应用到内存中的文档并广播，与保存的正文一致
+	var saved models.Article
+	if err := service.db.First(&saved, article.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if saved.Content != "正文含**词" || room.document != saved.Content || room.revision != 2 || room.dirty {
+		t.Fatalf("saved %q, document %q at revision %d (dirty %v)", saved.Content, room.document, room.revision, room.dirty)
+	}
+	var masked *ot.Operation
+	for _, message := range receive(t, bob) {
+		if message.Type == models.CollabMessageOperation {
+			masked = message.Operation
+		}
+	}
+	if masked == nil {
+		t.Fatal("mask operation was not broadcast")
+	}
+	if document, err := masked.Apply("正文含敏感词"); err != nil || document != saved.Content {
+		t.Errorf("applying broadcast operation: %q, %v", document, err)
+	}
+
+	// 基于打码前版本的操作经过转换后仍然正确应用
+	if err := bob.Handle(&models.CollabMessage{Type: models.CollabMessageOperation, Revision: 1, Operation: insertAt(6, 6, "。")}); err != nil {
+		t.Fatalf("stale operation: %v", err)
+	}
+	if room.document != "正文含**词。" {
+		t.Errorf("document = %q", room.document)
+	}
+}
+
+func TestCollabPersistRejected(t *testing.T) {
+	service, article, alice, bob := newCollabTest(t, models.FilterRule{Type: models.FilterTypeWord, Pattern: "违禁", Action: models.FilterActionReject})
+
+	if err := alice.Handle(&models.CollabMessage{Type: models.CollabMessageOperation, Revision: 0, Operation: insertAt(2, 2, "违禁词")}); err != nil {
+		t.Fatalf("operation: %v", err)
+	}
+	receive(t, bob)
+
+	// 被拒绝的文档不会反复重试保存，协作者收到错误
+	room := roomOf(article.Id)
+	if err := room.persist(); err != nil {
+		t.Fatalf("persist: %v", err)
+	}
+	if room.dirty {
+		t.Error("rejected document is still marked dirty")
+	}
+	messages := receive(t, bob)
+	if len(messages) != 1 || messages[0].Type != models.CollabMessageError {
+		t.Errorf("messages = %+v, want one error", messages)
+	}
+
+	// 继续编辑后重新尝试保存
+	if err := alice.Handle(&models.CollabMessage{Type: models.CollabMessageOperation, Revision: 1, Operation: insertAt(5, 2, "无")}); err != nil {
+		t.Fatalf("operation: %v", err)
+	}
+	if !room.dirty {
+		t.Error("edited document is not marked dirty")
+	}
+
+	// 所有协作者离开后会话关闭，数据库中的正文不变
+	alice.Leave()
+	bob.Leave()
+	if roomOf(article.Id) != nil {
+		t.Error("room with rejected document is still open")
+	}
+	var saved models.Article
+	if err := service.db.First(&saved, article.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if saved.Content != "正文" {
+		t.Errorf("saved content = %q", saved.Content)
+	}
+}
+
+func TestCollabDocumentLengthLimit(t *testing.T) {
+	_, article, alice, _ := newCollabTest(t)
+	t.Setenv("COLLAB_MAX_DOCUMENT_LENGTH", "6")
+
+	if err := alice.Handle(&models.CollabMessage{Type: models.CollabMessageOperation, Revision: 0, Operation: insertAt(2, 2, "1234")}); err != nil {
+		t.Fatalf("operation within limit: %v", err)
+	}
+	if err := alice.Handle(&models.CollabMessage{Type: models.CollabMessageOperation, Revision: 1, Operation: insertAt(6, 6, "5")}); err != ErrCollabDocumentTooLarge {
+		t.Fatalf("operation over limit: %v, want ErrCollabDocumentTooLarge", err)
+	}
+
+	// 基于旧版本的插入按转换后的长度检查
+	if err := alice.Handle(&models.CollabMessage{Type: models.CollabMessageOperation, Revision: 0, Operation: insertAt(2, 0, "0")}); err != ErrCollabDocumentTooLarge {
+		t.Fatalf("stale operation over limit: %v, want ErrCollabDocumentTooLarge", err)
+	}
+
+	// 已超过上限的文档仍然可以删减
+	t.Setenv("COLLAB_MAX_DOCUMENT_LENGTH", "2")
+	if err := alice.Handle(&models.CollabMessage{Type: models.CollabMessageOperation, Revision: 1, Operation: (&ot.Operation{}).Retain(5).Delete(1)}); err != nil {
+		t.Fatalf("delete over limit: %v", err)
+	}
+	if room := roomOf(article.Id); room.document != "正文123" || room.revision != 2 {
+		t.Errorf("document %q at revision %d", room.document, room.revision)
+	}
+}
+
+func TestCollabReceiveOperation(t *testing.T) {
+	_, article, alice, bob := newCollabTest(t)
+	room := roomOf(article.Id)
+
+	// 两人基于同一版本同时编辑，后到的操作与先到的转换后应用
+	if err := alice.Handle(&models.CollabMessage{Type: models.CollabMessageOperation, Revision: 0, Operation: insertAt(2, 0, "《")}); err != nil {
+		t.Fatalf("alice: %v", err)
+	}
+	if err := bob.Handle(&models.CollabMessage{Type: models.CollabMessageOperation, Revision: 0, Operation: insertAt(2, 2, "》")}); err != nil {
+		t.Fatalf("bob: %v", err)
+	}
+	if room.document != "《正文》" || room.revision != 2 {
+		t.Fatalf("document %q at revision %d", room.document, room.revision)
+	}
+
+	// 发送者收到确认，其他人收到转换后的操作
+	messages := receive(t, bob)
+	if len(messages) != 2 || messages[0].Type != models.CollabMessageOperation || messages[1].Type != models.CollabMessageAck || messages[1].Revision != 2 {
+		t.Fatalf("bob messages = %+v", messages)
+	}
+	messages = receive(t, alice)
+	if len(messages) != 2 || messages[1].Type != models.CollabMessageOperation {
+		t.Fatalf("alice messages = %+v", messages)
+	}
+	if document, err := messages[1].Operation.Apply("《正文"); err != nil || document != "《正文》" {
+		t.Errorf("applying transformed operation: %q, %v", document, err)
+	}
+
+	for _, revision := range []int{-1, 3} {
+		if err := alice.Handle(&models.CollabMessage{Type: models.CollabMessageOperation, Revision: revision, Operation: insertAt(4, 0, "x")}); err != ErrInvalidRevision {
+			t.Errorf("revision %d: %v, want ErrInvalidRevision", revision, err)
+		}
+	}
+	// 基于版本的长度不匹配的操作被拒绝，文档不变
+	if err := alice.Handle(&models.CollabMessage{Type: models.CollabMessageOperation, Revision: 2, Operation: insertAt(2, 0, "x")}); err == nil {
+		t.Error("operation with wrong base length was applied")
+	}
+
+	// 早于保留历史的版本需要重新加载
+	room.history = room.history[1:]
+	room.historyStart = 1
+	if err := alice.Handle(&models.CollabMessage{Type: models.CollabMessageOperation, Revision: 0, Operation: insertAt(2, 0, "x")}); err != ErrRevisionTooOld {
+		t.Errorf("revision before history: %v, want ErrRevisionTooOld", err)
+	}
+	if room.document != "《正文》" || room.revision != 2 {
+		t.Errorf("document %q at revision %d after rejected operations", room.document, room.revision)
+	}
+}
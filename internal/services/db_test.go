@@ -0,0 +1,61 @@
+package services
+
+import (
+	"path/filepath"
+	"server/internal/models"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// appTables 与 main 中自动迁移的表相同，用于需要完整业务流程的测试
+var appTables = []interface{}{
+	&models.User{},
+	&models.Article{},
+	&models.Tag{},
+	&models.ArticlePin{},
+	&models.Report{},
+	&models.ModerationLog{},
+	&models.FilterRule{},
+	&models.SpamCheck{},
+	&models.SpamSample{},
+	&models.ArticleShare{},
+	&models.ArticleAutosave{},
+	&models.ArticleCollaborator{},
+	&models.Webhook{},
+	&models.WebhookDelivery{},
+	&models.EmailOutbox{},
+	&models.UserToken{},
+	&models.Session{},
+	&models.RefreshToken{},
+	&models.RevokedToken{},
+	&models.UserIdentity{},
+	&models.OidcLoginState{},
+	&models.MfaRecoveryCode{},
+	&models.MfaChallenge{},
+	&models.Passkey{},
+	&models.WebauthnChallenge{},
+	&models.PersonalAccessToken{},
+}
+
+// newTestDB 创建只在当前测试中使用的 SQLite 数据库并迁移指定的表
+func newTestDB(t *testing.T, tables ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")+"?_busy_timeout=5000"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("database handle: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
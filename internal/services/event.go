@@ -0,0 +1,186 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"server/internal/models"
+	"server/pkg/events"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidEventFilter = errors.New("invalid event filter")
+
+const (
+	defaultEventHistory = 1000
+	// 每个订阅者最多积压的事件数，超出后连接被断开，客户端会带着 Last-Event-ID 重连
+	eventSubscriberBuffer = 64
+)
+
+// 站点动态事件总线，文章和用户服务在数据变更后发布事件
+var activityEvents = events.NewBus(eventHistory(), eventSubscriberBuffer)
+
+// eventHistory 保留用于断线续传的最近事件数，可通过 EVENTS_HISTORY 配置
+func eventHistory() int {
+	if value, err := strconv.Atoi(os.Getenv("EVENTS_HISTORY")); err == nil && value > 0 {
+		return value
+	}
+	return defaultEventHistory
+}
+
+// isPublicArticle 文章是否对所有人可见，只有公开文章的变更会发布到事件流
+func isPublicArticle(article *models.Article) bool {
+	return !article.Hidden && article.Visibility == models.VisibilityPublic
+}
+
+// articleEventData 文章事件内容，需要预加载 User 和 Tags
+func articleEventData(article *models.Article) models.ArticleEvent {
+	tags := make([]string, 0, len(article.Tags))
+	for _, tag := range article.Tags {
+		tags = append(tags, tag.Name)
+	}
+	return models.ArticleEvent{
+		Id:         article.Id,
+		Title:      article.Title,
+		UserId:     article.UserId,
+		Username:   article.User.Username,
+		Tags:       tags,
+		Visibility: article.Visibility,
+		Protected:  article.Password != "",
+		CreatedAt:  article.CreatedAt,
+		UpdatedAt:  article.UpdatedAt,
+	}
+}
+
+// publishArticleEvent 发布文章事件
+func publishArticleEvent(eventType string, article *models.Article) {
+	activityEvents.Publish(events.Event{
+		Type:      eventType,
+		ArticleId: article.Id,
+		UserId:    article.UserId,
+		Data:      articleEventData(article),
+	})
+}
+
+// publishArticleDeleted 发布文章删除事件
+func publishArticleDeleted(article *models.Article) {
+	activityEvents.Publish(events.Event{
+		Type:      models.EventArticleDeleted,
+		ArticleId: article.Id,
+		UserId:    article.UserId,
+		Data:      models.ArticleEvent{Id: article.Id, UserId: article.UserId, Tags: []string{}},
+	})
+}
+
+// publishArticleChange 文章可能改变了公开状态（审核、垃圾标注等）后发布事件：
+// 变更后公开时为更新事件，原本公开而现在不公开或已删除时为删除事件
+func publishArticleChange(db *gorm.DB, articleId int, wasPublic bool) {
+	var article models.Article
+	if err := db.Preload("User").Preload("Tags").First(&article, articleId).Error; err != nil {
+		if err == gorm.ErrRecordNotFound && wasPublic {
+			publishArticleDeleted(&models.Article{Id: articleId})
+		}
+		return
+	}
+
+	if isPublicArticle(&article) {
+		publishArticleEvent(models.EventArticleUpdated, &article)
+	} else if wasPublic {
+		publishArticleDeleted(&article)
+	}
+}
+
+// publishUserRegistered 发布用户注册事件，被隔离的用户不会发布
+func publishUserRegistered(user *models.User) {
+	if user.Quarantined {
+		return
+	}
+	activityEvents.Publish(events.Event{
+		Type:   models.EventUserRegistered,
+		UserId: user.Id,
+		Data: models.UserEvent{
+			Id:        user.Id,
+			Username:  user.Username,
+			CreatedAt: user.CreatedAt,
+		},
+	})
+}
+
+type EventService struct{}
+
+func NewEventService() *EventService {
+	return &EventService{}
+}
+
+// parseIds 解析逗号分隔的ID列表
+func parseIds(value string) (map[int]bool, error) {
+	if value == "" {
+		return nil, nil
+	}
+	ids := make(map[int]bool)
+	for _, part := range strings.Split(value, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || id <= 0 {
+			return nil, ErrInvalidEventFilter
+		}
+		ids[id] = true
+	}
+	return ids, nil
+}
+
+// eventFilter 根据请求构造事件过滤条件。按作者或文章过滤时不接收用户注册事件
+func eventFilter(request *models.EventStreamRequest) (events.Filter, error) {
+	var types map[string]bool
+	if request.Types != "" {
+		types = make(map[string]bool)
+		for _, eventType := range strings.Split(request.Types, ",") {
+			eventType = strings.TrimSpace(eventType)
+			switch eventType {
+			case models.EventArticleCreated, models.EventArticleUpdated, models.EventArticleDeleted, models.EventUserRegistered:
+				types[eventType] = true
+			default:
+				return nil, ErrInvalidEventFilter
+			}
+		}
+	}
+	authorIds, err := parseIds(request.AuthorIds)
+	if err != nil {
+		return nil, err
+	}
+	articleIds, err := parseIds(request.ArticleIds)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(event *events.Event) bool {
+		if types != nil && !types[event.Type] {
+			return false
+		}
+		if authorIds != nil && (event.ArticleId == 0 || !authorIds[event.UserId]) {
+			return false
+		}
+		if articleIds != nil && !articleIds[event.ArticleId] {
+			return false
+		}
+		return true
+	}, nil
+}
+
+// Subscribe 订阅站点动态。lastEventId 为客户端最后收到的事件ID（Last-Event-ID），
+// 返回缓冲区中之后的事件；complete 为 false 表示部分事件已经丢失，客户端应重新加载数据
+func (s *EventService) Subscribe(request *models.EventStreamRequest, lastEventId string) (sub *events.Subscription, missed []events.Event, complete bool, err error) {
+	filter, err := eventFilter(request)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	// 无法识别的 Last-Event-ID 视为事件已丢失
+	lastId, parseErr := strconv.ParseUint(lastEventId, 10, 64)
+	sub, missed, complete = activityEvents.Subscribe(lastId, filter)
+	if lastEventId != "" && parseErr != nil {
+		complete = false
+	}
+	return sub, missed, complete, nil
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"errors"
+	"server/internal/models"
+	"server/pkg/filter"
+	"server/pkg/rbac"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrContentRejected   = errors.New("content contains prohibited words or links")
+	ErrInvalidFilterRule = errors.New("invalid filter rule")
+)
+
+// compiledFilter 编译后的过滤规则
+type compiledFilter struct {
+	words       *filter.Matcher
+	wordActions []string          // 与 words 中的模式下标一一对应
+	domains     map[string]bool   // 域名黑名单
+	domainRules map[string]string // 域名 -> 动作
+}
+
+// 编译结果在所有 FilterService 实例间共享，规则变更后置空，下次检查时重新加载
+var (
+	filterMu       sync.RWMutex
+	filterCompiled *compiledFilter
+)
+
+// FilterResult 内容过滤结果
+type FilterResult struct {
+	Title   string   // 处理（打码）后的标题
+	Content string   // 处理（打码）后的内容
+	Action  string   // 最终动作，为空表示直接通过
+	Matched []string // 命中的违禁词或域名
+}
+
+type FilterService struct {
+	db *gorm.DB
+}
+
+func NewFilterService(db *gorm.DB) *FilterService {
+	return &FilterService{db: db}
+}
+
+// compiled 获取编译后的过滤规则，必要时从数据库加载
+func (s *FilterService) compiled() (*compiledFilter, error) {
+	filterMu.RLock()
+	compiled := filterCompiled
+	filterMu.RUnlock()
+	if compiled != nil {
+		return compiled, nil
+	}
+
+	filterMu.Lock()
+	defer filterMu.Unlock()
+	if filterCompiled != nil {
+		return filterCompiled, nil
+	}
+
+	var rules []models.FilterRule
+	if err := s.db.Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	var patterns []string
+	compiled = &compiledFilter{
+		domains:     make(map[string]bool),
+		domainRules: make(map[string]string),
+	}
+	for _, rule := range rules {
+		switch rule.Type {
+		case models.FilterTypeWord:
+			patterns = append(patterns, rule.Pattern)
+			compiled.wordActions = append(compiled.wordActions, rule.Action)
+		case models.FilterTypeDomain:
+			compiled.domains[rule.Pattern] = true
+			compiled.domainRules[rule.Pattern] = rule.Action
+		}
+	}
+	compiled.words = filter.NewMatcher(patterns)
+
+	filterCompiled = compiled
+	return compiled, nil
+}
+
+// invalidateFilter 规则变更后清除编译缓存
+func invalidateFilter() {
+	filterMu.Lock()
+	filterCompiled = nil
+	filterMu.Unlock()
+}
+
+// Check 对标题和内容执行过滤
+func (s *FilterService) Check(title string, content string) (*FilterResult, error) {
+	compiled, err := s.compiled()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FilterResult{}
+	actions := make(map[string]bool)
+	seen := make(map[string]bool)
+	check := func(text string) string {
+		var masks []filter.Match
+
+		for _, match := range compiled.words.FindAll(text) {
+			action := compiled.wordActions[match.Pattern]
+			actions[action] = true
+			if action == models.FilterActionMask {
+				masks = append(masks, match)
+			}
+			if pattern := compiled.words.Pattern(match.Pattern); !seen[pattern] {
+				seen[pattern] = true
+				result.Matched = append(result.Matched, pattern)
+			}
+		}
+
+		for _, link := range filter.ExtractLinks(text) {
+			domain, ok := filter.MatchDomain(link.Host, compiled.domains)
+			if !ok {
+				continue
+			}
+			action := compiled.domainRules[domain]
+			actions[action] = true
+			if action == models.FilterActionMask {
+				masks = append(masks, filter.Match{Start: link.Start, End: link.End})
+			}
+			if !seen[domain] {
+				seen[domain] = true
+				result.Matched = append(result.Matched, domain)
+			}
+		}
+
+		return filter.Mask(text, masks)
+	}
+
+	result.Title = check(title)
+	result.Content = check(content)
+
+	switch {
+	case actions[models.FilterActionReject]:
+		result.Action = models.FilterActionReject
+	case actions[models.FilterActionModerate]:
+		result.Action = models.FilterActionModerate
+	case actions[models.FilterActionMask]:
+		result.Action = models.FilterActionMask
+	}
+
+	return result, nil
+}
+
+// checkAdmin 检查用户是否有站点管理权限（管理员）
+func (s *FilterService) checkAdmin(userId int) error {
+	return requirePermission(s.db, userId, rbac.PermSiteManage)
+}
+
+// ListRules 获取过滤规则列表
+func (s *FilterService) ListRules(userId int, request *models.FilterRuleListRequest) (*models.FilterRuleListResponse, error) {
+	if err := s.checkAdmin(userId); err != nil {
+		return nil, err
+	}
+
+	query := s.db.Model(&models.FilterRule{})
+	if request.Type != "" {
+		query = query.Where("type = ?", request.Type)
+	}
+	if request.Search != "" {
+		query = query.Where("pattern LIKE ?", "%"+request.Search+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var rules []models.FilterRule
+	offset := (request.Page - 1) * request.Size
+	if err := query.Order("id desc").Offset(offset).Limit(request.Size).Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	return &models.FilterRuleListResponse{
+		Rules: rules,
+		Total: int(total),
+		Page:  request.Page,
+		Size:  request.Size,
+	}, nil
+}
+
+// CreateRules 批量添加过滤规则，已存在的规则会更新动作
+func (s *FilterService) CreateRules(userId int, request *models.CreateFilterRulesRequest) ([]models.FilterRule, error) {
+	if err := s.checkAdmin(userId); err != nil {
+		return nil, err
+	}
+
+	switch request.Type {
+	case models.FilterTypeWord, models.FilterTypeDomain:
+	default:
+		return nil, ErrInvalidFilterRule
+	}
+	switch request.Action {
+	case models.FilterActionReject, models.FilterActionMask, models.FilterActionModerate:
+	default:
+		return nil, ErrInvalidFilterRule
+	}
+
+	var rules []models.FilterRule
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, pattern := range request.Patterns {
+			pattern = strings.TrimSpace(pattern)
+			if request.Type == models.FilterTypeDomain {
+				pattern = strings.TrimPrefix(strings.ToLower(pattern), "*.")
+			}
+			if pattern == "" {
+				continue
+			}
+
+			rule := models.FilterRule{Type: request.Type, Pattern: pattern}
+			if err := tx.Where(&rule).FirstOrInit(&rule).Error; err != nil {
+				return err
+			}
+			rule.Action = request.Action
+			if rule.Id == 0 {
+				rule.CreatedBy = userId
+			}
+			if err := tx.Save(&rule).Error; err != nil {
+				return err
+			}
+			rules = append(rules, rule)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, ErrInvalidFilterRule
+	}
+
+	invalidateFilter()
+	return rules, nil
+}
+
+// DeleteRule 删除过滤规则
+func (s *FilterService) DeleteRule(userId int, ruleId int) error {
+	if err := s.checkAdmin(userId); err != nil {
+		return err
+	}
+
+	result := s.db.Delete(&models.FilterRule{}, ruleId)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	invalidateFilter()
+	return nil
+}
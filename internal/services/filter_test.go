@@ -0,0 +1,49 @@
+package services
+
+import (
+	"reflect"
+	"server/internal/models"
+	"testing"
+)
+
+func TestFilterCheck(t *testing.T) {
+	db := newTestDB(t, appTables...)
+	if err := db.Create(&[]models.FilterRule{
+		{Type: models.FilterTypeWord, Pattern: "违禁", Action: models.FilterActionReject},
+		{Type: models.FilterTypeWord, Pattern: "敏感", Action: models.FilterActionMask},
+		{Type: models.FilterTypeWord, Pattern: "广告", Action: models.FilterActionModerate},
+		{Type: models.FilterTypeDomain, Pattern: "spam.com", Action: models.FilterActionMask},
+		{Type: models.FilterTypeDomain, Pattern: "evil.com", Action: models.FilterActionReject},
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+	invalidateFilter()
+	t.Cleanup(invalidateFilter)
+
+	tests := []struct {
+		name    string
+		title   string
+		content string
+		want    FilterResult
+	}{
+		{name: "clean", title: "标题", content: "正文", want: FilterResult{Title: "标题", Content: "正文"}},
+		{name: "mask", title: "敏感标题", content: "正文敏感", want: FilterResult{Title: "**标题", Content: "正文**", Action: models.FilterActionMask, Matched: []string{"敏感"}}},
+		{name: "mask subdomain link", title: "标题", content: "见 https://a.spam.com/x 。", want: FilterResult{Title: "标题", Content: "见 ******************** 。", Action: models.FilterActionMask, Matched: []string{"spam.com"}}},
+		{name: "moderate", title: "标题", content: "广告敏感", want: FilterResult{Title: "标题", Content: "广告**", Action: models.FilterActionModerate, Matched: []string{"广告", "敏感"}}},
+		{name: "reject word", title: "违禁", content: "广告", want: FilterResult{Title: "违禁", Content: "广告", Action: models.FilterActionReject, Matched: []string{"违禁", "广告"}}},
+		{name: "reject domain", title: "标题", content: "www.evil.com", want: FilterResult{Title: "标题", Content: "www.evil.com", Action: models.FilterActionReject, Matched: []string{"evil.com"}}},
+	}
+
+	service := NewFilterService(db)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := service.Check(test.title, test.content)
+			if err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+			if !reflect.DeepEqual(*result, test.want) {
+				t.Errorf("Check = %+v, want %+v", *result, test.want)
+			}
+		})
+	}
+}
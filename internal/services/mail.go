@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/mail"
+	"os"
+	"server/internal/models"
+	"server/internal/templates"
+	"server/pkg/mailer"
+	"server/pkg/rbac"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidEmail   = errors.New("invalid email address")
+	ErrEmailNotFailed = errors.New("only failed emails can be retried")
+)
+
+const (
+	mailSendTimeout  = 30 * time.Second
+	mailBatchSize    = 20
+	mailPollInterval = 5 * time.Second
+	// 发送进行中时占用该记录的时长，避免被再次取出
+	mailLease              = 2 * mailSendTimeout
+	mailRetryBase          = time.Minute
+	mailRetryMax           = 6 * time.Hour
+	defaultMailMaxAttempts = 6
+)
+
+var (
+	mailSenderOnce sync.Once
+	mailSender     mailer.Mailer
+)
+
+// getMailer 按环境变量创建邮件发送器。环境变量在 main 中加载，因此需要延迟创建
+func getMailer() mailer.Mailer {
+	mailSenderOnce.Do(func() {
+		mailSender = mailer.NewFromEnv()
+	})
+	return mailSender
+}
+
+// mailMaxAttempts 最大发送次数，可通过 MAIL_MAX_ATTEMPTS 配置
+func mailMaxAttempts() int {
+	if value, err := strconv.Atoi(os.Getenv("MAIL_MAX_ATTEMPTS")); err == nil && value > 0 {
+		return value
+	}
+	return defaultMailMaxAttempts
+}
+
+// 发件箱发送协程
+var mailWorker = newBackgroundWorker()
+
+// StartMailWorker 启动后台发送协程，定期发送发件箱中到期的邮件
+func StartMailWorker(db *gorm.DB) {
+	mailWorker.Start(mailPollInterval, func() {
+		processOutbox(db)
+	})
+}
+
+// queueEmail 渲染模板并写入发件箱，由后台协程异步发送。db 可以是事务，事务提交后邮件才会被发送
+func queueEmail(db *gorm.DB, userId int, to string, locale string, template string, data map[string]interface{}) (*models.EmailOutbox, error) {
+	if _, err := mail.ParseAddress(to); err != nil {
+		return nil, ErrInvalidEmail
+	}
+
+	locale = templates.ResolveLocale(locale)
+	subject, text, html, err := templates.RenderEmail(locale, template, data)
+	if err != nil {
+		return nil, err
+	}
+
+	email := models.EmailOutbox{
+		UserId:        userId,
+		To:            to,
+		Template:      template,
+		Locale:        locale,
+		Subject:       subject,
+		TextBody:      text,
+		HtmlBody:      html,
+		Status:        models.EmailStatusPending,
+		NextAttemptAt: time.Now(),
+	}
+	if err := db.Create(&email).Error; err != nil {
+		return nil, err
+	}
+
+	mailWorker.Wake()
+	return &email, nil
+}
+
+// processOutbox 发送所有到期的邮件
+func processOutbox(db *gorm.DB) {
+	for {
+		var emails []models.EmailOutbox
+		if err := db.Where("status = ? AND next_attempt_at <= ?", models.EmailStatusPending, time.Now()).
+			Order("next_attempt_at asc").Limit(mailBatchSize).Find(&emails).Error; err != nil {
+			log.Printf("mail: failed to load outbox: %v", err)
+			return
+		}
+		if len(emails) == 0 {
+			return
+		}
+
+		for i := range emails {
+			// 先占用记录，多个实例同时运行时只有一个会占用成功
+			email := &emails[i]
+			result := db.Model(&models.EmailOutbox{}).
+				Where("id = ? AND status = ? AND next_attempt_at = ?", email.Id, models.EmailStatusPending, email.NextAttemptAt).
+				Update("next_attempt_at", time.Now().Add(mailLease))
+			if result.Error != nil || result.RowsAffected == 0 {
+				continue
+			}
+			sendOutboxEmail(db, email)
+		}
+
+		if len(emails) < mailBatchSize {
+			return
+		}
+	}
+}
+
+// sendOutboxEmail 发送一封邮件并记录结果，失败时按指数退避安排重试
+func sendOutboxEmail(db *gorm.DB, email *models.EmailOutbox) {
+	ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+	defer cancel()
+
+	now := time.Now()
+	email.Attempts++
+	err := getMailer().Send(ctx, &mailer.Message{
+		From:    mailer.DefaultFrom(),
+		To:      []string{email.To},
+		Subject: email.Subject,
+		Text:    email.TextBody,
+		HTML:    email.HtmlBody,
+	})
+
+	switch {
+	case err == nil:
+		email.Status = models.EmailStatusSent
+		email.SentAt = &now
+		email.LastError = ""
+	case email.Attempts >= mailMaxAttempts():
+		email.Status = models.EmailStatusFailed
+		email.LastError = err.Error()
+	default:
+		email.Status = models.EmailStatusPending
+		email.NextAttemptAt = now.Add(exponentialBackoff(mailRetryBase, mailRetryMax, email.Attempts))
+		email.LastError = err.Error()
+	}
+	if len(email.LastError) > 1024 {
+		email.LastError = email.LastError[:1024]
+	}
+
+	if err := db.Model(email).
+		Select("status", "attempts", "next_attempt_at", "last_error", "sent_at").
+		Updates(email).Error; err != nil {
+		log.Printf("mail: failed to save outbox email %d: %v", email.Id, err)
+	}
+}
+
+type MailService struct {
+	db *gorm.DB
+}
+
+func NewMailService(db *gorm.DB) *MailService {
+	return &MailService{db: db}
+}
+
+// checkAdmin 检查用户是否有站点管理权限（管理员）
+func (s *MailService) checkAdmin(userId int) error {
+	return requirePermission(s.db, userId, rbac.PermSiteManage)
+}
+
+// SendTest 管理员发送测试邮件，用于检查邮件配置
+func (s *MailService) SendTest(userId int, request *models.SendTestEmailRequest) (*models.EmailOutbox, error) {
+	if err := s.checkAdmin(userId); err != nil {
+		return nil, err
+	}
+	return queueEmail(s.db, 0, request.Email, request.Locale, "test", nil)
+}
+
+// ListOutbox 管理员查看发件箱
+func (s *MailService) ListOutbox(userId int, request *models.EmailOutboxListRequest) (*models.EmailOutboxListResponse, error) {
+	if err := s.checkAdmin(userId); err != nil {
+		return nil, err
+	}
+
+	query := s.db.Model(&models.EmailOutbox{})
+	if request.Status != "" {
+		query = query.Where("status = ?", request.Status)
+	}
+	if request.Template != "" {
+		query = query.Where("template = ?", request.Template)
+	}
+	if request.UserId > 0 {
+		query = query.Where("user_id = ?", request.UserId)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var emails []models.EmailOutbox
+	offset := (request.Page - 1) * request.Size
+	if err := query.Order("id desc").Offset(offset).Limit(request.Size).Find(&emails).Error; err != nil {
+		return nil, err
+	}
+
+	return &models.EmailOutboxListResponse{
+		Emails: emails,
+		Total:  int(total),
+		Page:   request.Page,
+		Size:   request.Size,
+	}, nil
+}
+
+// Retry 管理员将发送失败的邮件重新放回发送队列
+func (s *MailService) Retry(userId int, emailId int) (*models.EmailOutbox, error) {
+	if err := s.checkAdmin(userId); err != nil {
+		return nil, err
+	}
+
+	var email models.EmailOutbox
+	if err := s.db.First(&email, emailId).Error; err != nil {
+		return nil, err
+	}
+	if email.Status != models.EmailStatusFailed {
+		return nil, ErrEmailNotFailed
+	}
+
+	email.Status = models.EmailStatusPending
+	email.Attempts = 0
+	email.NextAttemptAt = time.Now()
+	if err := s.db.Model(&email).Select("status", "attempts", "next_attempt_at").Updates(&email).Error; err != nil {
+		return nil, err
+	}
+
+	mailWorker.Wake()
+	return &email, nil
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"server/internal/models"
+	"server/pkg/mailer"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fakeMailer 记录发送的邮件，前 failures 次发送返回错误
+type fakeMailer struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+	sent     []*mailer.Message
+}
+
+func (m *fakeMailer) Send(_ context.Context, message *mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts++
+	if m.failures > 0 {
+		m.failures--
+		return errors.New("451 temporary failure")
+	}
+	m.sent = append(m.sent, message)
+	return nil
+}
+
+// useMailer 在测试期间替换邮件发送器
+func useMailer(t *testing.T, m mailer.Mailer) {
+	t.Helper()
+	mailSenderOnce.Do(func() {})
+	previous := mailSender
+	mailSender = m
+	t.Cleanup(func() { mailSender = previous })
+}
+
+func loadEmail(t *testing.T, db *gorm.DB, id int) models.EmailOutbox {
+	t.Helper()
+	var email models.EmailOutbox
+	if err := db.First(&email, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return email
+}
+
+// makeEmailDue 将邮件的下次发送时间提前到现在
+func makeEmailDue(t *testing.T, db *gorm.DB, id int) {
+	t.Helper()
+	if err := db.Model(&models.EmailOutbox{}).Where("id = ?", id).Update("next_attempt_at", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOutboxSend(t *testing.T) {
+	db := newTestDB(t, &models.EmailOutbox{})
+	sender := &fakeMailer{}
+	useMailer(t, sender)
+	t.Setenv("MAIL_FROM", "Blog <no-reply@example.com>")
+
+	queued, err := queueEmail(db, 7, "alice@example.com", "en", "test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	processOutbox(db)
+
+	email := loadEmail(t, db, queued.Id)
+	if email.Status != models.EmailStatusSent || email.Attempts != 1 || email.SentAt == nil || email.LastError != "" {
+		t.Fatalf("email = %+v", email)
+	}
+	if len(sender.sent) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(sender.sent))
+	}
+	message := sender.sent[0]
+	if message.From != "Blog <no-reply@example.com>" || len(message.To) != 1 || message.To[0] != "alice@example.com" {
+		t.Errorf("message from %q to %v", message.From, message.To)
+	}
+	if message.Subject != queued.Subject || message.Text == "" || message.HTML == "" {
+		t.Errorf("message = %+v", message)
+	}
+}
+
+func TestOutboxRetry(t *testing.T) {
+	t.Setenv("MAIL_MAX_ATTEMPTS", "3")
+	db := newTestDB(t, &models.EmailOutbox{}, &models.User{})
+	sender := &fakeMailer{failures: 3}
+	useMailer(t, sender)
+
+	queued, err := queueEmail(db, 0, "bob@example.com", "zh-CN", "test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for attempt, backoff := range []time.Duration{mailRetryBase, 2 * mailRetryBase} {
+		before := time.Now()
+		processOutbox(db)
+
+		email := loadEmail(t, db, queued.Id)
+		if email.Status != models.EmailStatusPending || email.Attempts != attempt+1 || email.LastError == "" {
+			t.Fatalf("attempt %d: email = %+v", attempt+1, email)
+		}
+		wait := email.NextAttemptAt.Sub(before)
+		if wait < backoff || wait > backoff+time.Minute {
+			t.Errorf("attempt %d: next attempt in %v, want about %v", attempt+1, wait, backoff)
+		}
+
+		// 未到重试时间时不会发送
+		processOutbox(db)
+		if sender.attempts != attempt+1 {
+			t.Fatalf("sent before next_attempt_at: %d attempts", sender.attempts)
+		}
+		makeEmailDue(t, db, queued.Id)
+	}
+
+	// 达到最大次数后标记为失败，不再自动重试
+	processOutbox(db)
+	email := loadEmail(t, db, queued.Id)
+	if email.Status != models.EmailStatusFailed || email.Attempts != 3 {
+		t.Fatalf("email = %+v", email)
+	}
+	makeEmailDue(t, db, queued.Id)
+	processOutbox(db)
+	if sender.attempts != 3 {
+		t.Fatalf("failed email was retried automatically: %d attempts", sender.attempts)
+	}
+
+	// 管理员手动重试后重新进入队列并发送成功
+	admin := models.User{Username: "admin", Email: "admin@example.com", Role: models.RoleAdmin}
+	author := models.User{Username: "author", Email: "author@example.com", Role: models.RoleAuthor}
+	if err := db.Create(&[]*models.User{&admin, &author}).Error; err != nil {
+		t.Fatal(err)
+	}
+	service := NewMailService(db)
+	if _, err := service.Retry(author.Id, queued.Id); err != ErrPermissionDenied {
+		t.Fatalf("Retry by author: %v, want ErrPermissionDenied", err)
+	}
+	if _, err := service.Retry(admin.Id, queued.Id); err != nil {
+		t.Fatalf("Retry: %v", err)
+	}
+	processOutbox(db)
+	email = loadEmail(t, db, queued.Id)
+	if email.Status != models.EmailStatusSent || email.Attempts != 1 || len(sender.sent) != 1 {
+		t.Fatalf("email after retry = %+v", email)
+	}
+	if _, err := service.Retry(admin.Id, queued.Id); err != ErrEmailNotFailed {
+		t.Errorf("Retry sent email: %v, want ErrEmailNotFailed", err)
+	}
+}
@@ -0,0 +1,412 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"log"
+	"server/internal/models"
+	"server/internal/templates"
+	"server/pkg/totp"
+	"server/pkg/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMfaAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMfaNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrMfaSetupRequired  = errors.New("two-factor setup has not been started")
+	ErrInvalidMfaCode    = errors.New("invalid verification code")
+	ErrInvalidMfaToken   = errors.New("invalid or expired mfa token, please log in again")
+	ErrMfaLocked         = errors.New("too many failed verification attempts, please try again later")
+)
+
+const (
+	mfaChallengeExpires = 5 * time.Minute
+	mfaMaxAttempts      = 5  // 每个 mfa_token 最多尝试的次数
+	mfaMaxChallenges    = 5  // 每个用户在 mfaChallengeExpires 内最多获取的 mfa_token 数量
+	mfaMaxFailures      = 10 // 每个用户连续验证失败的次数上限，达到后锁定 mfaLockout
+	mfaLockout          = 15 * time.Minute
+	totpSkew            = 1  // 允许前后各一个时间步（30 秒）的时钟偏差
+	recoveryCodeCount   = 10 // 每次生成的恢复码数量
+)
+
+// mfaLocked 用户的两步验证是否因连续失败被锁定
+func mfaLocked(user *models.User) bool {
+	return user.MfaLockedUntil != nil && time.Now().Before(*user.MfaLockedUntil)
+}
+
+// completeLogin 密码或第三方登录验证通过后调用：未开启两步验证时直接签发登录令牌，
+// 否则只返回 mfa_token，需要再提交验证码才能完成登录。
+// 每个用户短时间内获取的 mfa_token 数量有限，锁定期间不再发放，重复登录也无法获得更多尝试次数
+func completeLogin(db *gorm.DB, user *models.User, clientIP string, userAgent string) (*models.LoginResponse, error) {
+	if user.TotpEnabledAt == nil {
+		return issueLoginTokens(db, user, newSession(clientIP, userAgent))
+	}
+	if mfaLocked(user) {
+		return nil, ErrMfaLocked
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	// 顺便清理过期的登录挑战
+	if err := db.Where("expires_at < ?", time.Now()).Delete(&models.MfaChallenge{}).Error; err != nil {
+		return nil, err
+	}
+	var recent int64
+	if err := db.Model(&models.MfaChallenge{}).
+		Where("user_id = ? AND created_at > ?", user.Id, time.Now().Add(-mfaChallengeExpires)).
+		Count(&recent).Error; err != nil {
+		return nil, err
+	}
+	if recent >= mfaMaxChallenges {
+		return nil, ErrMfaLocked
+	}
+	if err := db.Create(&models.MfaChallenge{
+		UserId:       user.Id,
+		TokenHash:    hashUserToken(token),
+		TokenVersion: user.TokenVersion,
+		ExpiresAt:    time.Now().Add(mfaChallengeExpires),
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return &models.LoginResponse{
+		MfaRequired: true,
+		MfaToken:    token,
+	}, nil
+}
+
+// LoginMfa 两步验证的第二步，使用身份验证器中的验证码或恢复码完成登录
+func (s *UserService) LoginMfa(request *models.MfaLoginRequest, clientIP string, userAgent string) (*models.LoginResponse, error) {
+	if request.MfaToken == "" {
+		return nil, ErrInvalidMfaToken
+	}
+
+	var challenge models.MfaChallenge
+	if err := s.db.Where("token_hash = ?", hashUserToken(request.MfaToken)).First(&challenge).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrInvalidMfaToken
+		}
+		return nil, err
+	}
+	if time.Now().After(challenge.ExpiresAt) {
+		return nil, ErrInvalidMfaToken
+	}
+
+	// 先计入尝试次数再校验，并发请求也不能超过次数上限
+	result := s.db.Model(&models.MfaChallenge{}).
+		Where("id = ? AND used_at IS NULL AND attempts < ?", challenge.Id, mfaMaxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidMfaToken
+	}
+
+	var user models.User
+	if err := s.db.First(&user, challenge.UserId).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrInvalidMfaToken
+		}
+		return nil, err
+	}
+	if user.TotpEnabledAt == nil || user.TokenVersion != challenge.TokenVersion {
+		return nil, ErrInvalidMfaToken
+	}
+	if err := checkLoginAllowed(&user); err != nil {
+		return nil, err
+	}
+
+	// 按用户计入失败次数：先占用一次尝试再校验，锁定期间或达到上限时拒绝，并发请求也不能超过上限
+	now := time.Now()
+	result = s.db.Model(&models.User{}).
+		Where("id = ? AND mfa_failed_attempts < ? AND (mfa_locked_until IS NULL OR mfa_locked_until < ?)", user.Id, mfaMaxFailures, now).
+		Update("mfa_failed_attempts", gorm.Expr("mfa_failed_attempts + 1"))
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrMfaLocked
+	}
+
+	ok, err := verifyMfaCode(s.db, &user, request.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// 达到上限后锁定，锁定结束后重新计数
+		if err := s.db.Model(&models.User{}).
+			Where("id = ? AND mfa_failed_attempts >= ?", user.Id, mfaMaxFailures).
+			Updates(map[string]interface{}{
+				"mfa_failed_attempts": 0,
+				"mfa_locked_until":    now.Add(mfaLockout),
+			}).Error; err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidMfaCode
+	}
+	if err := s.db.Model(&models.User{}).Where("id = ?", user.Id).Update("mfa_failed_attempts", 0).Error; err != nil {
+		return nil, err
+	}
+
+	result = s.db.Model(&models.MfaChallenge{}).
+		Where("id = ? AND used_at IS NULL", challenge.Id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidMfaToken
+	}
+	return issueLoginTokens(s.db, &user, newSession(clientIP, userAgent))
+}
+
+// verifyMfaCode 校验验证码或恢复码，两者都只能使用一次
+func verifyMfaCode(db *gorm.DB, user *models.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		return verifyTotp(db, user, code)
+	}
+	return useRecoveryCode(db, user.Id, code)
+}
+
+// verifyTotp 校验身份验证器中的验证码，记录使用过的时间步，防止验证码在有效期内被重放
+func verifyTotp(db *gorm.DB, user *models.User, code string) (bool, error) {
+	counter, ok := totp.Validate(user.TotpSecret, code, time.Now(), totpSkew)
+	if !ok || counter <= user.TotpLastCounter {
+		return false, nil
+	}
+	result := db.Model(&models.User{}).
+		Where("id = ? AND totp_last_counter < ?", user.Id, counter).
+		Update("totp_last_counter", counter)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	user.TotpLastCounter = counter
+	return true, nil
+}
+
+// normalizeRecoveryCode 恢复码不区分大小写，忽略分隔符和空格
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// useRecoveryCode 使用一个恢复码
+func useRecoveryCode(db *gorm.DB, userId int, code string) (bool, error) {
+	code = normalizeRecoveryCode(code)
+	if code == "" {
+		return false, nil
+	}
+	result := db.Model(&models.MfaRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userId, hashUserToken(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// generateRecoveryCodes 生成新的恢复码并删除旧的，返回给用户的格式为 xxxx-xxxx
+func generateRecoveryCodes(tx *gorm.DB, userId int) ([]string, error) {
+	if err := tx.Where("user_id = ?", userId).Delete(&models.MfaRecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, recoveryCodeCount)
+	records := make([]models.MfaRecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(encoding.EncodeToString(buf))
+		codes[i] = code[:4] + "-" + code[4:]
+		records[i] = models.MfaRecoveryCode{
+			UserId:   userId,
+			CodeHash: hashUserToken(code),
+		}
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// MfaStatus 当前用户的两步验证状态
+func (s *UserService) MfaStatus(userId int) (*models.MfaStatusResponse, error) {
+	var user models.User
+	if err := s.db.First(&user, userId).Error; err != nil {
+		return nil, err
+	}
+
+	status := &models.MfaStatusResponse{
+		Enabled:   user.TotpEnabledAt != nil,
+		EnabledAt: user.TotpEnabledAt,
+	}
+	if status.Enabled {
+		var count int64
+		if err := s.db.Model(&models.MfaRecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userId).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		status.RecoveryCodesRemaining = int(count)
+	}
+	return status, nil
+}
+
+// SetupTotp 开始绑定身份验证器，生成新的密钥。需要用验证码确认后才会启用，重复调用时之前的密钥作废
+func (s *UserService) SetupTotp(userId int) (*models.TotpSetupResponse, error) {
+	var user models.User
+	if err := s.db.First(&user, userId).Error; err != nil {
+		return nil, err
+	}
+	if user.TotpEnabledAt != nil {
+		return nil, ErrMfaAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(&user).Update("totp_secret", secret).Error; err != nil {
+		return nil, err
+	}
+
+	return &models.TotpSetupResponse{
+		Secret: secret,
+		Uri:    totp.URI(templates.SiteName(), user.Email, secret),
+	}, nil
+}
+
+// ConfirmTotp 使用身份验证器中的验证码确认绑定，启用两步验证并返回恢复码
+func (s *UserService) ConfirmTotp(userId int, code string) (*models.MfaRecoveryCodesResponse, error) {
+	var user models.User
+	if err := s.db.First(&user, userId).Error; err != nil {
+		return nil, err
+	}
+	if user.TotpEnabledAt != nil {
+		return nil, ErrMfaAlreadyEnabled
+	}
+	if user.TotpSecret == "" {
+		return nil, ErrMfaSetupRequired
+	}
+
+	counter, ok := totp.Validate(user.TotpSecret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrInvalidMfaCode
+	}
+
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND totp_enabled_at IS NULL", user.Id).
+			Updates(map[string]interface{}{
+				"totp_enabled_at":   time.Now(),
+				"totp_last_counter": counter,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrMfaAlreadyEnabled
+		}
+
+		var err error
+		codes, err = generateRecoveryCodes(tx, user.Id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.MfaRecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，需要身份验证器中的验证码，旧的恢复码全部失效
+func (s *UserService) RegenerateRecoveryCodes(userId int, code string) (*models.MfaRecoveryCodesResponse, error) {
+	var user models.User
+	if err := s.db.First(&user, userId).Error; err != nil {
+		return nil, err
+	}
+	if user.TotpEnabledAt == nil {
+		return nil, ErrMfaNotEnabled
+	}
+
+	ok, err := verifyTotp(s.db, &user, strings.TrimSpace(code))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidMfaCode
+	}
+
+	var codes []string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = generateRecoveryCodes(tx, user.Id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.MfaRecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTotp 关闭两步验证，需要验证码或恢复码
+func (s *UserService) DisableTotp(userId int, code string) error {
+	var user models.User
+	if err := s.db.First(&user, userId).Error; err != nil {
+		return err
+	}
+	if user.TotpEnabledAt == nil {
+		return ErrMfaNotEnabled
+	}
+
+	ok, err := verifyMfaCode(s.db, &user, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidMfaCode
+	}
+	return disableMfa(s.db, &user)
+}
+
+// disableMfa 清除身份验证器密钥、恢复码和进行中的登录挑战，并通知用户
+func disableMfa(db *gorm.DB, user *models.User) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"totp_secret":         "",
+			"totp_enabled_at":     nil,
+			"totp_last_counter":   0,
+			"mfa_failed_attempts": 0,
+			"mfa_locked_until":    nil,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.Id).Delete(&models.MfaRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.Id).Delete(&models.MfaChallenge{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := queueEmail(db, user.Id, user.Email, user.Locale, "mfa_disabled", map[string]interface{}{
+		"Username": user.Username,
+	}); err != nil {
+		log.Printf("failed to send mfa disabled notice to user %d: %v", user.Id, err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"server/internal/models"
+	"server/pkg/totp"
+	"server/pkg/utils"
+	"testing"
+	"time"
+)
+
+// newMfaTest 创建一个已开启两步验证、密码为 secret-password 的用户
+func newMfaTest(t *testing.T) (*UserService, *models.User) {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-secret")
+	db := newTestDB(t, appTables...)
+
+	password, err := utils.HashPassword("secret-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	user := &models.User{
+		Username:      "alice",
+		Email:         "alice@example.com",
+		Password:      password,
+		Role:          models.RoleAuthor,
+		TotpSecret:    secret,
+		TotpEnabledAt: &now,
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return NewUserService(db), user
+}
+
+func totpCode(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	code, err := totp.Code(secret, totp.Counter(at))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// passwordLogin 密码登录，返回 mfa_token
+func passwordLogin(t *testing.T, service *UserService) (string, error) {
+	t.Helper()
+	response, err := service.Login(&models.LoginRequest{Email: "alice@example.com", Password: "secret-password"}, "203.0.113.7", "test")
+	if err != nil {
+		return "", err
+	}
+	if !response.MfaRequired || response.MfaToken == "" || response.Token != "" {
+		t.Fatalf("login response = %+v, want mfa_token only", response)
+	}
+	return response.MfaToken, nil
+}
+
+func TestVerifyTotpReplay(t *testing.T) {
+	service, user := newMfaTest(t)
+	now := time.Now()
+
+	code := totpCode(t, user.TotpSecret, now)
+	if ok, err := verifyTotp(service.db, user, code); err != nil || !ok {
+		t.Fatalf("verifyTotp: %v, %v", ok, err)
+	}
+	// 同一个验证码在有效期内不能再次使用
+	if ok, _ := verifyTotp(service.db, user, code); ok {
+		t.Error("reused code accepted")
+	}
+	// 已使用过的时间步之前的验证码（仍在允许的时钟偏差内）同样被拒绝
+	if ok, _ := verifyTotp(service.db, user, totpCode(t, user.TotpSecret, now.Add(-totp.Period*time.Second))); ok {
+		t.Error("code of an earlier step accepted")
+	}
+
+	// 计数器保存在数据库中，重新加载用户后依然有效
+	var reloaded models.User
+	if err := service.db.First(&reloaded, user.Id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.TotpLastCounter != totp.Counter(now) {
+		t.Errorf("totp_last_counter = %d, want %d", reloaded.TotpLastCounter, totp.Counter(now))
+	}
+	if ok, _ := verifyTotp(service.db, &reloaded, code); ok {
+		t.Error("reused code accepted after reload")
+	}
+	if ok, err := verifyTotp(service.db, &reloaded, totpCode(t, user.TotpSecret, now.Add(totp.Period*time.Second))); err != nil || !ok {
+		t.Errorf("code of the next step: %v, %v", ok, err)
+	}
+}
+
+func TestLoginMfaChallengeLimit(t *testing.T) {
+	service, _ := newMfaTest(t)
+
+	for i := 0; i < mfaMaxChallenges; i++ {
+		if _, err := passwordLogin(t, service); err != nil {
+			t.Fatalf("login %d: %v", i+1, err)
+		}
+	}
+	if _, err := passwordLogin(t, service); err != ErrMfaLocked {
+		t.Fatalf("login after %d challenges: %v, want ErrMfaLocked", mfaMaxChallenges, err)
+	}
+}
+
+func TestLoginMfaLockout(t *testing.T) {
+	service, user := newMfaTest(t)
+
+	// 失败次数按用户累计，换 mfa_token（重新登录）不会重置
+	failures := 0
+	for failures < mfaMaxFailures {
+		token, err := passwordLogin(t, service)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < mfaMaxAttempts && failures < mfaMaxFailures; i++ {
+			if _, err := service.LoginMfa(&models.MfaLoginRequest{MfaToken: token, Code: "000000"}, "203.0.113.7", "test"); err != ErrInvalidMfaCode {
+				t.Fatalf("attempt %d: %v, want ErrInvalidMfaCode", failures+1, err)
+			}
+			failures++
+		}
+	}
+
+	// 锁定期间不能获取新的 mfa_token，使用还有剩余尝试次数的 mfa_token 提交正确的验证码也被拒绝
+	if _, err := passwordLogin(t, service); err != ErrMfaLocked {
+		t.Fatalf("login while locked: %v, want ErrMfaLocked", err)
+	}
+	if err := service.db.Create(&models.MfaChallenge{
+		UserId:    user.Id,
+		TokenHash: hashUserToken("remaining-token"),
+		ExpiresAt: time.Now().Add(mfaChallengeExpires),
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.LoginMfa(&models.MfaLoginRequest{MfaToken: "remaining-token", Code: totpCode(t, user.TotpSecret, time.Now())}, "203.0.113.7", "test"); err != ErrMfaLocked {
+		t.Fatalf("correct code while locked: %v, want ErrMfaLocked", err)
+	}
+
+	// 锁定结束后可以正常登录，成功后失败次数清零
+	if err := service.db.Model(user).Update("mfa_locked_until", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+	response, err := service.LoginMfa(&models.MfaLoginRequest{MfaToken: "remaining-token", Code: totpCode(t, user.TotpSecret, time.Now())}, "203.0.113.7", "test")
+	if err != nil || response.Token == "" {
+		t.Fatalf("LoginMfa after lockout: %+v, %v", response, err)
+	}
+	var reloaded models.User
+	service.db.First(&reloaded, user.Id)
+	if reloaded.MfaFailedAttempts != 0 {
+		t.Errorf("mfa_failed_attempts = %d after successful login", reloaded.MfaFailedAttempts)
+	}
+}
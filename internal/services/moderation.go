@@ -0,0 +1,275 @@
+package services
+
+import (
+	"errors"
+	"server/internal/models"
+	"server/pkg/rbac"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidReportReason     = errors.New("invalid report reason")
+	ErrAlreadyReported         = errors.New("you have already reported this article")
+	ErrInvalidModerationAction = errors.New("invalid moderation action")
+)
+
+type ModerationService struct {
+	db *gorm.DB
+}
+
+func NewModerationService(db *gorm.DB) *ModerationService {
+	return &ModerationService{db: db}
+}
+
+// checkModerator 检查用户是否为审核人员
+func (s *ModerationService) checkModerator(userId int) error {
+	return requirePermission(s.db, userId, rbac.PermArticleModerate)
+}
+
+// Report 举报文章，同一用户对同一文章只能有一条待处理举报
+func (s *ModerationService) Report(userId int, articleId int, request *models.CreateReportRequest) (*models.Report, error) {
+	if !models.ValidReportReason(request.Reason) {
+		return nil, ErrInvalidReportReason
+	}
+
+	var article models.Article
+	if err := s.db.Select("id", "user_id", "hidden").First(&article, articleId).Error; err != nil {
+		return nil, err
+	}
+	// 隐藏的文章对普通用户不可见，也不能被举报
+	if article.Hidden && article.UserId != userId {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var count int64
+	if err := s.db.Model(&models.Report{}).
+		Where("article_id = ? AND reporter_id = ? AND status = ?", articleId, userId, models.ReportStatusPending).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrAlreadyReported
+	}
+
+	report := models.Report{
+		ArticleId:  articleId,
+		ReporterId: userId,
+		Reason:     request.Reason,
+		Detail:     request.Detail,
+		Status:     models.ReportStatusPending,
+	}
+	if err := s.db.Create(&report).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ListReports 审核队列，默认只返回待处理举报
+func (s *ModerationService) ListReports(userId int, request *models.ReportListRequest) (*models.ReportListResponse, error) {
+	if err := s.checkModerator(userId); err != nil {
+		return nil, err
+	}
+
+	status := request.Status
+	if status == "" {
+		status = models.ReportStatusPending
+	}
+
+	query := s.db.Model(&models.Report{}).Where("status = ?", status)
+	if request.Reason != "" {
+		query = query.Where("reason = ?", request.Reason)
+	}
+	if request.ArticleId > 0 {
+		query = query.Where("article_id = ?", request.ArticleId)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var reports []models.Report
+	offset := (request.Page - 1) * request.Size
+	if err := query.Order("created_at asc").Offset(offset).Limit(request.Size).Find(&reports).Error; err != nil {
+		return nil, err
+	}
+
+	return &models.ReportListResponse{
+		Reports: reports,
+		Total:   int(total),
+		Page:    request.Page,
+		Size:    request.Size,
+	}, nil
+}
+
+// Moderate 对文章执行审核操作并记录审核日志，同时处理该文章的待处理举报
+func (s *ModerationService) Moderate(userId int, articleId int, request *models.ModerateArticleRequest) (*models.ModerationLog, error) {
+	if err := s.checkModerator(userId); err != nil {
+		return nil, err
+	}
+
+	switch request.Action {
+	case models.ModerationActionHide, models.ModerationActionUnhide, models.ModerationActionDelete, models.ModerationActionWarn:
+	default:
+		return nil, ErrInvalidModerationAction
+	}
+
+	var article models.Article
+	if err := s.db.Preload("User").Preload("Tags").First(&article, articleId).Error; err != nil {
+		return nil, err
+	}
+
+	wasPublic := isPublicArticle(&article)
+
+	log := models.ModerationLog{
+		ModeratorId: userId,
+		ArticleId:   article.Id,
+		AuthorId:    article.UserId,
+		ReportId:    request.ReportId,
+		Action:      request.Action,
+		Note:        request.Note,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		switch request.Action {
+		case models.ModerationActionHide:
+			if err := tx.Model(&models.Article{Id: article.Id}).Update("hidden", true).Error; err != nil {
+				return err
+			}
+		case models.ModerationActionUnhide:
+			if err := tx.Model(&models.Article{Id: article.Id}).Update("hidden", false).Error; err != nil {
+				return err
+			}
+		case models.ModerationActionDelete:
+			if err := deleteArticle(tx, &article); err != nil {
+				return err
+			}
+		}
+
+		// 除取消隐藏外，其余操作都视为已处理该文章的待处理举报
+		if request.Action != models.ModerationActionUnhide {
+			if err := resolveReports(tx, userId, article.Id, models.ReportStatusResolved); err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(&log).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Action != models.ModerationActionWarn {
+		publishArticleChange(s.db, article.Id, wasPublic)
+	}
+	if request.Action == models.ModerationActionDelete {
+		enqueueWebhooks(s.db, models.EventArticleDeleted, article.UserId, articleEventData(&article))
+	}
+	return &log, nil
+}
+
+// DismissReport 驳回举报
+func (s *ModerationService) DismissReport(userId int, reportId int, note string) (*models.ModerationLog, error) {
+	if err := s.checkModerator(userId); err != nil {
+		return nil, err
+	}
+
+	var report models.Report
+	if err := s.db.First(&report, reportId).Error; err != nil {
+		return nil, err
+	}
+
+	var article models.Article
+	if err := s.db.Select("id", "user_id").First(&article, report.ArticleId).Error; err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	now := time.Now()
+	log := models.ModerationLog{
+		ModeratorId: userId,
+		ArticleId:   report.ArticleId,
+		AuthorId:    article.UserId,
+		ReportId:    report.Id,
+		Action:      models.ModerationActionDismiss,
+		Note:        note,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&report).Updates(map[string]interface{}{
+			"status":      models.ReportStatusDismissed,
+			"resolved_by": userId,
+			"resolved_at": &now,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&log).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+// ListLogs 查询审核日志
+func (s *ModerationService) ListLogs(userId int, request *models.ModerationLogListRequest) (*models.ModerationLogListResponse, error) {
+	if err := s.checkModerator(userId); err != nil {
+		return nil, err
+	}
+
+	query := s.db.Model(&models.ModerationLog{})
+	if request.ArticleId > 0 {
+		query = query.Where("article_id = ?", request.ArticleId)
+	}
+	if request.AuthorId > 0 {
+		query = query.Where("author_id = ?", request.AuthorId)
+	}
+	if request.ModeratorId > 0 {
+		query = query.Where("moderator_id = ?", request.ModeratorId)
+	}
+	if request.Action != "" {
+		query = query.Where("action = ?", request.Action)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var logs []models.ModerationLog
+	offset := (request.Page - 1) * request.Size
+	if err := query.Order("created_at desc").Offset(offset).Limit(request.Size).Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	return &models.ModerationLogListResponse{
+		Logs:  logs,
+		Total: int(total),
+		Page:  request.Page,
+		Size:  request.Size,
+	}, nil
+}
+
+// resolveReports 将文章的待处理举报标记为指定状态
+func resolveReports(tx *gorm.DB, moderatorId int, articleId int, status string) error {
+	now := time.Now()
+	return tx.Model(&models.Report{}).
+		Where("article_id = ? AND status = ?", articleId, models.ReportStatusPending).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"resolved_by": moderatorId,
+			"resolved_at": &now,
+		}).Error
+}
+
+// submitForModeration 为被自动拦截的文章生成一条系统举报，使其进入审核队列
+func submitForModeration(db *gorm.DB, articleId int, reason string, detail string) error {
+	report := models.Report{
+		ArticleId: articleId,
+		Reason:    reason,
+		Detail:    detail,
+		Status:    models.ReportStatusPending,
+	}
+	return db.Create(&report).Error
+}
@@ -0,0 +1,355 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"server/internal/models"
+	"server/pkg/oidc"
+	"server/pkg/utils"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOidcProviderNotFound  = errors.New("login provider not found")
+	ErrOidcInvalidState      = errors.New("invalid or expired login state")
+	ErrOidcLoginFailed       = errors.New("third-party login failed")
+	ErrOidcEmailNotVerified  = errors.New("the provider did not return a verified email")
+	ErrOidcEmailConflict     = errors.New("email is registered but not verified, log in with password and verify the email first")
+	ErrOidcProviderUnhealthy = errors.New("login provider is unavailable")
+)
+
+const (
+	oidcStateExpires  = 10 * time.Minute
+	oidcExchangeLimit = 15 * time.Second
+)
+
+// oidcProvider 配置的身份提供方，发现文档在第一次使用时读取，失败时下次重试
+type oidcProvider struct {
+	name        string
+	displayName string
+	config      oidc.Config
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+func (p *oidcProvider) get(ctx context.Context) (*oidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.provider == nil {
+		provider, err := oidc.Discover(ctx, p.config, nil)
+		if err != nil {
+			return nil, err
+		}
+		p.provider = provider
+	}
+	return p.provider, nil
+}
+
+var (
+	oidcProviders     map[string]*oidcProvider
+	oidcProviderNames []string
+	oidcProvidersOnce sync.Once
+)
+
+// loadOidcProviders 从环境变量读取身份提供方：OIDC_PROVIDERS 为逗号分隔的名字，
+// 每个提供方通过 OIDC_<NAME>_ISSUER、_CLIENT_ID、_CLIENT_SECRET、_REDIRECT_URL、_SCOPES、_DISPLAY_NAME 配置
+func loadOidcProviders() {
+	oidcProviders = make(map[string]*oidcProvider)
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || oidcProviders[name] != nil {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		config := oidc.Config{
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientId:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if config.Issuer == "" || config.ClientId == "" {
+			log.Printf("oidc provider %q is missing issuer or client id, skipped", name)
+			continue
+		}
+		if config.RedirectURL == "" {
+			config.RedirectURL = frontendURL("/oauth/callback/" + name)
+		}
+		displayName := os.Getenv(prefix + "DISPLAY_NAME")
+		if displayName == "" {
+			displayName = name
+		}
+		oidcProviders[name] = &oidcProvider{name: name, displayName: displayName, config: config}
+		oidcProviderNames = append(oidcProviderNames, name)
+	}
+}
+
+// getOidcProvider 按名字获取身份提供方
+func getOidcProvider(name string) (*oidcProvider, error) {
+	oidcProvidersOnce.Do(loadOidcProviders)
+	provider, ok := oidcProviders[name]
+	if !ok {
+		return nil, ErrOidcProviderNotFound
+	}
+	return provider, nil
+}
+
+type OidcService struct {
+	db    *gorm.DB
+	users *UserService
+}
+
+func NewOidcService(db *gorm.DB) *OidcService {
+	return &OidcService{
+		db:    db,
+		users: NewUserService(db),
+	}
+}
+
+// Providers 可用的身份提供方
+func (s *OidcService) Providers() []models.OidcProviderInfo {
+	oidcProvidersOnce.Do(loadOidcProviders)
+	list := make([]models.OidcProviderInfo, 0, len(oidcProviderNames))
+	for _, name := range oidcProviderNames {
+		list = append(list, models.OidcProviderInfo{Name: name, DisplayName: oidcProviders[name].displayName})
+	}
+	return list
+}
+
+// Authorize 发起登录：生成 state、nonce 和 PKCE code_verifier，返回身份提供方的授权地址
+func (s *OidcService) Authorize(ctx context.Context, providerName string) (*models.OidcAuthorizeResponse, error) {
+	configured, err := getOidcProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := configured.get(ctx)
+	if err != nil {
+		log.Printf("oidc provider %s: %v", providerName, err)
+		return nil, ErrOidcProviderUnhealthy
+	}
+
+	state, err := oidc.RandomString()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := oidc.RandomString()
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := oidc.RandomString()
+	if err != nil {
+		return nil, err
+	}
+
+	// 顺便清理过期的登录状态
+	if err := s.db.Where("expires_at < ?", time.Now()).Delete(&models.OidcLoginState{}).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(&models.OidcLoginState{
+		StateHash:    hashUserToken(state),
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(oidcStateExpires),
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return &models.OidcAuthorizeResponse{
+		AuthorizationUrl: provider.AuthCodeURL(state, nonce, oidc.CodeChallenge(verifier)),
+		State:            state,
+	}, nil
+}
+
+// consumeOidcState 取出并删除登录状态，state 只能使用一次
+func (s *OidcService) consumeOidcState(providerName string, state string) (*models.OidcLoginState, error) {
+	if state == "" {
+		return nil, ErrOidcInvalidState
+	}
+	var loginState models.OidcLoginState
+	if err := s.db.Where("state_hash = ? AND provider = ?", hashUserToken(state), providerName).First(&loginState).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrOidcInvalidState
+		}
+		return nil, err
+	}
+	result := s.db.Delete(&loginState)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 || time.Now().After(loginState.ExpiresAt) {
+		return nil, ErrOidcInvalidState
+	}
+	return &loginState, nil
+}
+
+// Callback 完成登录：用授权码换取令牌并校验 ID Token，找到或创建对应的用户后签发本站的登录令牌。
+// 开启两步验证的用户同样需要再提交验证码
+func (s *OidcService) Callback(ctx context.Context, providerName string, request *models.OidcCallbackRequest, clientIP string, userAgent string) (*models.LoginResponse, error) {
+	configured, err := getOidcProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	loginState, err := s.consumeOidcState(providerName, request.State)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := configured.get(ctx)
+	if err != nil {
+		log.Printf("oidc provider %s: %v", providerName, err)
+		return nil, ErrOidcProviderUnhealthy
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, oidcExchangeLimit)
+	defer cancel()
+	token, err := provider.Exchange(ctx, request.Code, loginState.CodeVerifier)
+	if err != nil {
+		log.Printf("oidc provider %s: %v", providerName, err)
+		return nil, ErrOidcLoginFailed
+	}
+	idToken, err := provider.VerifyIdToken(ctx, token.IdToken, loginState.Nonce)
+	if err != nil {
+		log.Printf("oidc provider %s: %v", providerName, err)
+		return nil, ErrOidcLoginFailed
+	}
+
+	user, err := s.resolveUser(providerName, idToken, clientIP)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLoginAllowed(user); err != nil {
+		return nil, err
+	}
+	return completeLogin(s.db, user, clientIP, userAgent)
+}
+
+// resolveUser 找到第三方身份对应的用户：已关联的直接返回；否则按已验证的邮箱关联到已有用户，没有时创建新用户
+func (s *OidcService) resolveUser(providerName string, idToken *oidc.IdToken, clientIP string) (*models.User, error) {
+	var identity models.UserIdentity
+	err := s.db.Where("provider = ? AND subject = ?", providerName, idToken.Subject).First(&identity).Error
+	if err == nil {
+		var user models.User
+		if err := s.db.First(&user, identity.UserId).Error; err != nil {
+			return nil, err
+		}
+		return &user, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	// 只信任身份提供方验证过的邮箱，否则任何人都可以声称拥有某个邮箱
+	email, err := normalizeEmail(idToken.Email)
+	if err != nil || !idToken.Verified() {
+		return nil, ErrOidcEmailNotVerified
+	}
+
+	var user models.User
+	err = s.db.Where("email = ?", email).First(&user).Error
+	switch {
+	case err == nil:
+		// 本站未验证的邮箱可能是他人抢注的，关联后对方可以用密码登录该账号
+		if user.EmailVerifiedAt == nil {
+			return nil, ErrOidcEmailConflict
+		}
+	case err == gorm.ErrRecordNotFound:
+		created, err := s.createOidcUser(idToken, email, clientIP)
+		if err != nil {
+			return nil, err
+		}
+		user = *created
+	default:
+		return nil, err
+	}
+
+	if err := s.db.Create(&models.UserIdentity{
+		UserId:   user.Id,
+		Provider: providerName,
+		Subject:  idToken.Subject,
+		Email:    email,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// createOidcUser 为第三方登录创建用户，密码为随机值（之后可通过找回密码设置），邮箱视为已验证
+func (s *OidcService) createOidcUser(idToken *oidc.IdToken, email string, clientIP string) (*models.User, error) {
+	username, err := s.availableUsername(idToken, email)
+	if err != nil {
+		return nil, err
+	}
+	password, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	check, err := s.users.spam.ScoreRegistration(username, email, clientIP)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user := &models.User{
+		Username:        username,
+		Email:           email,
+		Password:        hashedPassword,
+		Role:            models.RoleAuthor,
+		DisplayName:     truncateRunes(strings.TrimSpace(idToken.Name), maxDisplayNameLength),
+		Quarantined:     check.Quarantined,
+		EmailVerifiedAt: &now,
+	}
+	if err := s.users.createUser(user, check); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// availableUsername 依次尝试 preferred_username、name 和邮箱前缀，已被占用时追加随机后缀
+func (s *OidcService) availableUsername(idToken *oidc.IdToken, email string) (string, error) {
+	base := ""
+	for _, candidate := range []string{idToken.PreferredUsername, idToken.Name, strings.SplitN(email, "@", 2)[0]} {
+		if candidate = strings.TrimSpace(candidate); candidate != "" {
+			base = truncateRunes(candidate, 30)
+			break
+		}
+	}
+
+	username := base
+	for i := 0; i < 5; i++ {
+		var count int64
+		if err := s.db.Model(&models.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return username, nil
+		}
+		suffix, err := utils.GenerateRandomToken(3)
+		if err != nil {
+			return "", err
+		}
+		username = base + "-" + strings.ToLower(suffix)
+	}
+	return "", errors.New("failed to generate a unique username")
+}
+
+// truncateRunes 按字符截断
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"server/internal/models"
+	"server/pkg/oidc/oidctest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// useOidcProvider 将模拟身份提供方配置为名为 test 的登录方式
+func useOidcProvider(t *testing.T, idp *oidctest.Provider) {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("OIDC_PROVIDERS", "test")
+	t.Setenv("OIDC_TEST_ISSUER", idp.Server.URL)
+	t.Setenv("OIDC_TEST_CLIENT_ID", idp.ClientId)
+	t.Setenv("OIDC_TEST_CLIENT_SECRET", idp.ClientSecret)
+	t.Setenv("OIDC_TEST_REDIRECT_URL", "https://blog.example.com/oauth/callback/test")
+
+	reset := func() {
+		oidcProvidersOnce = sync.Once{}
+		oidcProviders = nil
+		oidcProviderNames = nil
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+// authorizeOidc 发起登录并模拟用户在身份提供方同意授权
+func authorizeOidc(t *testing.T, service *OidcService, idp *oidctest.Provider) *models.OidcCallbackRequest {
+	t.Helper()
+	authorize, err := service.Authorize(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	code, state, err := idp.Authorize(authorize.AuthorizationUrl)
+	if err != nil {
+		t.Fatalf("idp Authorize: %v", err)
+	}
+	if state != authorize.State {
+		t.Fatalf("state in authorization url = %q, want %q", state, authorize.State)
+	}
+	return &models.OidcCallbackRequest{Code: code, State: state}
+}
+
+func TestOidcLogin(t *testing.T) {
+	idp := oidctest.NewProvider(t)
+	useOidcProvider(t, idp)
+	db := newTestDB(t, appTables...)
+	service := NewOidcService(db)
+	ctx := context.Background()
+
+	request := authorizeOidc(t, service, idp)
+
+	// 登录状态只保存 state 的哈希，code_verifier 与授权地址中的 code_challenge 对应
+	var loginState models.OidcLoginState
+	if err := db.First(&loginState).Error; err != nil {
+		t.Fatal(err)
+	}
+	if loginState.StateHash != hashUserToken(request.State) || loginState.Nonce == "" || loginState.CodeVerifier == "" {
+		t.Errorf("login state = %+v", loginState)
+	}
+
+	// 错误的 state 不会消耗正确的登录状态
+	if _, err := service.Callback(ctx, "test", &models.OidcCallbackRequest{Code: request.Code, State: "forged"}, "203.0.113.7", "test"); err != ErrOidcInvalidState {
+		t.Fatalf("Callback with forged state: %v, want ErrOidcInvalidState", err)
+	}
+
+	response, err := service.Callback(ctx, "test", request, "203.0.113.7", "test")
+	if err != nil {
+		t.Fatalf("Callback: %v", err)
+	}
+	if response.Token == "" || response.RefreshToken == "" || response.User == nil || response.User.Email != "alice@example.com" {
+		t.Fatalf("login response = %+v", response)
+	}
+
+	var identity models.UserIdentity
+	if err := db.Where("provider = ? AND subject = ?", "test", "alice").First(&identity).Error; err != nil {
+		t.Fatalf("identity: %v", err)
+	}
+	if identity.UserId != response.User.Id {
+		t.Errorf("identity user = %d, want %d", identity.UserId, response.User.Id)
+	}
+
+	// state 只能使用一次
+	if _, err := service.Callback(ctx, "test", request, "203.0.113.7", "test"); err != ErrOidcInvalidState {
+		t.Errorf("replayed Callback: %v, want ErrOidcInvalidState", err)
+	}
+
+	// 再次登录时使用已关联的账号
+	again, err := service.Callback(ctx, "test", authorizeOidc(t, service, idp), "203.0.113.7", "test")
+	if err != nil || again.User.Id != response.User.Id {
+		t.Fatalf("second login: %+v, %v", again, err)
+	}
+}
+
+func TestOidcCallbackRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims func(claims jwt.MapClaims)
+		state  func(db *gorm.DB)
+		want   error
+	}{
+		{name: "wrong nonce", claims: func(c jwt.MapClaims) { c["nonce"] = "replayed-nonce" }, want: ErrOidcLoginFailed},
+		{name: "bad issuer", claims: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, want: ErrOidcLoginFailed},
+		{name: "bad audience", claims: func(c jwt.MapClaims) { c["aud"] = "another-client" }, want: ErrOidcLoginFailed},
+		{name: "expired token", claims: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, want: ErrOidcLoginFailed},
+		{name: "unverified email", claims: func(c jwt.MapClaims) { c["email_verified"] = false }, want: ErrOidcEmailNotVerified},
+		{name: "expired state", state: func(db *gorm.DB) {
+			db.Model(&models.OidcLoginState{}).Where("1 = 1").Update("expires_at", time.Now().Add(-time.Minute))
+		}, want: ErrOidcInvalidState},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			idp := oidctest.NewProvider(t)
+			idp.Claims = test.claims
+			useOidcProvider(t, idp)
+			db := newTestDB(t, appTables...)
+			service := NewOidcService(db)
+
+			request := authorizeOidc(t, service, idp)
+			if test.state != nil {
+				test.state(db)
+			}
+			if _, err := service.Callback(context.Background(), "test", request, "203.0.113.7", "test"); err != test.want {
+				t.Fatalf("Callback: %v, want %v", err, test.want)
+			}
+
+			var users int64
+			db.Model(&models.User{}).Count(&users)
+			if users != 0 {
+				t.Errorf("%d users created", users)
+			}
+		})
+	}
+}
+
+func TestOidcLoginPasswordResetRequired(t *testing.T) {
+	idp := oidctest.NewProvider(t)
+	useOidcProvider(t, idp)
+	db := newTestDB(t, appTables...)
+	service := NewOidcService(db)
+	ctx := context.Background()
+
+	response, err := service.Callback(ctx, "test", authorizeOidc(t, service, idp), "203.0.113.7", "test")
+	if err != nil {
+		t.Fatalf("Callback: %v", err)
+	}
+
+	// 管理员强制重置密码后，第三方登录与密码登录一样被拒绝
+	admin := &models.User{Username: "admin", Email: "admin@example.com", Role: models.RoleAdmin}
+	if err := db.Create(admin).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := NewAdminService(db).ForcePasswordReset(admin.Id, response.User.Id); err != nil {
+		t.Fatalf("ForcePasswordReset: %v", err)
+	}
+	if _, err := service.Callback(ctx, "test", authorizeOidc(t, service, idp), "203.0.113.7", "test"); err != ErrPasswordResetRequired {
+		t.Fatalf("Callback after forced reset: %v, want ErrPasswordResetRequired", err)
+	}
+
+	var sessions int64
+	db.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", response.User.Id).Count(&sessions)
+	if sessions != 0 {
+		t.Errorf("%d active sessions after forced reset", sessions)
+	}
+}
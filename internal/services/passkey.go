@@ -0,0 +1,385 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"server/internal/models"
+	"server/internal/templates"
+	"server/pkg/utils"
+	"server/pkg/webauthn"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPasskeyNotFound     = errors.New("passkey not found")
+	ErrPasskeyExists       = errors.New("passkey is already registered")
+	ErrInvalidPasskeyName  = errors.New("passkey name must be at most 100 characters")
+	ErrTooManyPasskeys     = errors.New("too many passkeys")
+	ErrPasskeyVerification = errors.New("passkey verification failed")
+	ErrPasskeyLoginFailed  = errors.New("passkey login failed")
+)
+
+const (
+	webauthnChallengeExpires = 5 * time.Minute
+	maxPasskeyNameLength     = 100
+	maxPasskeysPerUser       = 20
+)
+
+// webauthnConfig 依赖方配置：WEBAUTHN_RP_ID 默认为 FRONTEND_URL 的域名，
+// WEBAUTHN_ORIGINS（逗号分隔）默认为 FRONTEND_URL，WEBAUTHN_RP_NAME 默认为站点名称
+func webauthnConfig() *webauthn.Config {
+	frontend, _ := url.Parse(frontendURL(""))
+
+	config := &webauthn.Config{
+		RPID:   os.Getenv("WEBAUTHN_RP_ID"),
+		RPName: os.Getenv("WEBAUTHN_RP_NAME"),
+	}
+	if config.RPID == "" && frontend != nil {
+		config.RPID = frontend.Hostname()
+	}
+	if config.RPName == "" {
+		config.RPName = templates.SiteName()
+	}
+	for _, origin := range strings.Split(os.Getenv("WEBAUTHN_ORIGINS"), ",") {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			config.Origins = append(config.Origins, origin)
+		}
+	}
+	if len(config.Origins) == 0 && frontend != nil {
+		config.Origins = []string{frontend.Scheme + "://" + frontend.Host}
+	}
+	return config
+}
+
+// passkeyUserHandle 认证器中保存的用户标识，只包含用户ID
+func passkeyUserHandle(userId int) []byte {
+	return []byte(strconv.Itoa(userId))
+}
+
+// passkeyResponse 通行密钥列表项
+func passkeyResponse(passkey *models.Passkey) models.PasskeyResponse {
+	transports := passkey.Transports
+	if transports == nil {
+		transports = []string{}
+	}
+	return models.PasskeyResponse{
+		Id:         passkey.Id,
+		Name:       passkey.Name,
+		Synced:     passkey.BackupState,
+		Transports: transports,
+		CreatedAt:  passkey.CreatedAt,
+		LastUsedAt: passkey.LastUsedAt,
+	}
+}
+
+// passkeyName 校验通行密钥名称，为空时使用默认名称
+func passkeyName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "Passkey", nil
+	}
+	if utf8.RuneCountInString(name) > maxPasskeyNameLength {
+		return "", ErrInvalidPasskeyName
+	}
+	return name, nil
+}
+
+// formatAaguid 认证器型号，按 UUID 格式显示
+func formatAaguid(aaguid []byte) string {
+	if len(aaguid) != 16 {
+		return ""
+	}
+	return fmt.Sprintf("%x-%x-%x-%x-%x", aaguid[0:4], aaguid[4:6], aaguid[6:8], aaguid[8:10], aaguid[10:16])
+}
+
+// issueWebauthnChallenge 生成并保存 challenge，登录时 userId 为 0
+func issueWebauthnChallenge(db *gorm.DB, userId int, purpose string) ([]byte, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return nil, err
+	}
+	// 顺便清理过期的 challenge
+	if err := db.Where("expires_at < ?", time.Now()).Delete(&models.WebauthnChallenge{}).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Create(&models.WebauthnChallenge{
+		UserId:        userId,
+		Purpose:       purpose,
+		ChallengeHash: hashUserToken(string(challenge)),
+		ExpiresAt:     time.Now().Add(webauthnChallengeExpires),
+	}).Error; err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// consumeWebauthnChallenge 按 clientDataJSON 中的 challenge 取出并删除服务端保存的 challenge，只能使用一次
+func consumeWebauthnChallenge(db *gorm.DB, clientDataJSON string, userId int, purpose string) ([]byte, bool, error) {
+	challenge, err := webauthn.ClientChallenge(clientDataJSON)
+	if err != nil || len(challenge) == 0 {
+		return nil, false, nil
+	}
+
+	var record models.WebauthnChallenge
+	err = db.Where("challenge_hash = ? AND user_id = ? AND purpose = ?", hashUserToken(string(challenge)), userId, purpose).
+		First(&record).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	result := db.Delete(&record)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 0 || time.Now().After(record.ExpiresAt) {
+		return nil, false, nil
+	}
+	return challenge, true, nil
+}
+
+// PasskeyRegisterOptions 注册通行密钥的选项，已注册的通行密钥会被排除，避免在同一个认证器上重复注册
+func (s *UserService) PasskeyRegisterOptions(userId int) (*webauthn.CreationOptions, error) {
+	var user models.User
+	if err := s.db.First(&user, userId).Error; err != nil {
+		return nil, err
+	}
+
+	var passkeys []models.Passkey
+	if err := s.db.Where("user_id = ?", userId).Find(&passkeys).Error; err != nil {
+		return nil, err
+	}
+	if len(passkeys) >= maxPasskeysPerUser {
+		return nil, ErrTooManyPasskeys
+	}
+	exclude := make([]webauthn.CredentialDescriptor, len(passkeys))
+	for i, passkey := range passkeys {
+		exclude[i] = webauthn.CredentialDescriptor{
+			Type:       "public-key",
+			Id:         passkey.CredentialId,
+			Transports: passkey.Transports,
+		}
+	}
+
+	challenge, err := issueWebauthnChallenge(s.db, userId, models.WebauthnPurposeRegister)
+	if err != nil {
+		return nil, err
+	}
+	displayName := user.DisplayName
+	if displayName == "" {
+		displayName = user.Username
+	}
+	return webauthnConfig().NewCreationOptions(challenge, webauthn.UserEntity{
+		Id:          webauthn.EncodeBase64(passkeyUserHandle(user.Id)),
+		Name:        user.Email,
+		DisplayName: displayName,
+	}, exclude), nil
+}
+
+// RegisterPasskey 校验认证器返回的凭据并保存通行密钥。通行密钥可以代替密码登录，需要验证当前密码，
+// 防止令牌被盗用时添加攻击者自己的通行密钥
+func (s *UserService) RegisterPasskey(userId int, request *models.RegisterPasskeyRequest) (*models.PasskeyResponse, error) {
+	name, err := passkeyName(request.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := s.db.First(&user, userId).Error; err != nil {
+		return nil, err
+	}
+	if !utils.ValidatePassword(request.Password, user.Password) {
+		return nil, ErrIncorrectPassword
+	}
+
+	challenge, ok, err := consumeWebauthnChallenge(s.db, request.Credential.Response.ClientDataJSON, userId, models.WebauthnPurposeRegister)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrPasskeyVerification
+	}
+	credential, err := webauthnConfig().VerifyRegistration(&request.Credential, challenge)
+	if err != nil {
+		log.Printf("passkey registration of user %d failed: %v", userId, err)
+		return nil, ErrPasskeyVerification
+	}
+
+	credentialId := webauthn.EncodeBase64(credential.Id)
+	passkey := models.Passkey{
+		UserId:         userId,
+		Name:           name,
+		CredentialId:   credentialId,
+		CredentialHash: hashUserToken(credentialId),
+		PublicKey:      credential.PublicKey,
+		Algorithm:      credential.Algorithm,
+		SignCount:      int64(credential.SignCount),
+		Aaguid:         formatAaguid(credential.AAGUID),
+		Transports:     credential.Transports,
+		BackupEligible: credential.BackupEligible,
+		BackupState:    credential.BackupState,
+	}
+	// 获取选项时的数量检查可以被并发请求绕过，锁定用户后重新计数再保存
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.User{}, userId).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.Passkey{}).Where("credential_hash = ?", passkey.CredentialHash).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrPasskeyExists
+		}
+		if err := tx.Model(&models.Passkey{}).Where("user_id = ?", userId).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= maxPasskeysPerUser {
+			return ErrTooManyPasskeys
+		}
+
+		return tx.Create(&passkey).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := passkeyResponse(&passkey)
+	return &response, nil
+}
+
+// PasskeyLoginOptions 通行密钥登录选项，不指定凭据，由用户在认证器中选择
+func (s *UserService) PasskeyLoginOptions() (*webauthn.RequestOptions, error) {
+	challenge, err := issueWebauthnChallenge(s.db, 0, models.WebauthnPurposeLogin)
+	if err != nil {
+		return nil, err
+	}
+	return webauthnConfig().NewRequestOptions(challenge, nil), nil
+}
+
+// LoginWithPasskey 使用通行密钥登录。认证器已验证用户（PIN、指纹等），相当于两个因素，不再要求两步验证
+func (s *UserService) LoginWithPasskey(request *models.PasskeyLoginRequest, clientIP string, userAgent string) (*models.LoginResponse, error) {
+	credential := &request.Credential
+	challenge, ok, err := consumeWebauthnChallenge(s.db, credential.Response.ClientDataJSON, 0, models.WebauthnPurposeLogin)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrPasskeyLoginFailed
+	}
+
+	rawId, err := webauthn.DecodeBase64(credential.RawId)
+	if err != nil {
+		return nil, ErrPasskeyLoginFailed
+	}
+	var passkey models.Passkey
+	if err := s.db.Where("credential_hash = ?", hashUserToken(webauthn.EncodeBase64(rawId))).First(&passkey).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrPasskeyLoginFailed
+		}
+		return nil, err
+	}
+
+	result, err := webauthnConfig().VerifyAssertion(credential, challenge, passkey.PublicKey, uint32(passkey.SignCount))
+	if err != nil {
+		if err == webauthn.ErrSignCount {
+			log.Printf("passkey %d of user %d: sign counter did not increase, possible cloned authenticator", passkey.Id, passkey.UserId)
+		} else {
+			log.Printf("passkey login with passkey %d failed: %v", passkey.Id, err)
+		}
+		return nil, ErrPasskeyLoginFailed
+	}
+	if len(result.UserHandle) > 0 && string(result.UserHandle) != string(passkeyUserHandle(passkey.UserId)) {
+		return nil, ErrPasskeyLoginFailed
+	}
+
+	// 条件更新防止并发登录时计数器被旧值覆盖
+	update := s.db.Model(&models.Passkey{}).
+		Where("id = ? AND sign_count = ?", passkey.Id, passkey.SignCount).
+		Updates(map[string]interface{}{
+			"sign_count":   int64(result.SignCount),
+			"backup_state": result.BackupState,
+			"last_used_at": time.Now(),
+		})
+	if update.Error != nil {
+		return nil, update.Error
+	}
+	if update.RowsAffected == 0 {
+		return nil, ErrPasskeyLoginFailed
+	}
+
+	var user models.User
+	if err := s.db.First(&user, passkey.UserId).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrPasskeyLoginFailed
+		}
+		return nil, err
+	}
+	if err := checkLoginAllowed(&user); err != nil {
+		return nil, err
+	}
+	return issueLoginTokens(s.db, &user, newSession(clientIP, userAgent))
+}
+
+// ListPasskeys 当前用户的通行密钥
+func (s *UserService) ListPasskeys(userId int) ([]models.PasskeyResponse, error) {
+	var passkeys []models.Passkey
+	if err := s.db.Where("user_id = ?", userId).Order("id").Find(&passkeys).Error; err != nil {
+		return nil, err
+	}
+
+	list := make([]models.PasskeyResponse, len(passkeys))
+	for i := range passkeys {
+		list[i] = passkeyResponse(&passkeys[i])
+	}
+	return list, nil
+}
+
+// getPasskey 获取当前用户的通行密钥
+func (s *UserService) getPasskey(userId int, passkeyId int) (*models.Passkey, error) {
+	var passkey models.Passkey
+	if err := s.db.Where("id = ? AND user_id = ?", passkeyId, userId).First(&passkey).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrPasskeyNotFound
+		}
+		return nil, err
+	}
+	return &passkey, nil
+}
+
+// RenamePasskey 重命名通行密钥
+func (s *UserService) RenamePasskey(userId int, passkeyId int, name string) (*models.PasskeyResponse, error) {
+	passkey, err := s.getPasskey(userId, passkeyId)
+	if err != nil {
+		return nil, err
+	}
+	if passkey.Name, err = passkeyName(name); err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(passkey).Update("name", passkey.Name).Error; err != nil {
+		return nil, err
+	}
+
+	response := passkeyResponse(passkey)
+	return &response, nil
+}
+
+// DeletePasskey 删除通行密钥，之后无法再用它登录。认证器中的凭据需要用户自行删除
+func (s *UserService) DeletePasskey(userId int, passkeyId int) error {
+	passkey, err := s.getPasskey(userId, passkeyId)
+	if err != nil {
+		return err
+	}
+	return s.db.Delete(passkey).Error
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"fmt"
+	"server/internal/models"
+	"server/pkg/utils"
+	"server/pkg/webauthn"
+	"server/pkg/webauthn/webauthntest"
+	"testing"
+)
+
+// newPasskeyTest 配置依赖方并创建一个密码为 old-password 的用户
+func newPasskeyTest(t *testing.T) (*UserService, *models.User, *webauthntest.Authenticator) {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("WEBAUTHN_RP_ID", "blog.example.com")
+	t.Setenv("WEBAUTHN_ORIGINS", "https://blog.example.com")
+	db := newTestDB(t, appTables...)
+
+	password, err := utils.HashPassword("old-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{Username: "alice", Email: "alice@example.com", Password: password, Role: models.RoleAuthor}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return NewUserService(db), user, webauthntest.NewAuthenticator("blog.example.com", "https://blog.example.com")
+}
+
+// registerPasskey 获取注册选项并使用软件认证器创建凭据
+func registerPasskey(t *testing.T, service *UserService, userId int, authenticator *webauthntest.Authenticator, password string) (*models.PasskeyResponse, error) {
+	t.Helper()
+	options, err := service.PasskeyRegisterOptions(userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userHandle, err := webauthn.DecodeBase64(options.User.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service.RegisterPasskey(userId, &models.RegisterPasskeyRequest{
+		Password:   password,
+		Name:       "MacBook",
+		Credential: *authenticator.Create(options.Challenge, userHandle),
+	})
+}
+
+func loginWithPasskey(t *testing.T, service *UserService, authenticator *webauthntest.Authenticator) (*models.LoginResponse, error) {
+	t.Helper()
+	options, err := service.PasskeyLoginOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service.LoginWithPasskey(&models.PasskeyLoginRequest{Credential: *authenticator.Get(options.Challenge)}, "203.0.113.7", "test")
+}
+
+func TestRegisterPasskeyRequiresPassword(t *testing.T) {
+	service, user, authenticator := newPasskeyTest(t)
+
+	for _, password := range []string{"", "wrong-password"} {
+		if _, err := registerPasskey(t, service, user.Id, authenticator, password); err != ErrIncorrectPassword {
+			t.Fatalf("RegisterPasskey with password %q: %v, want ErrIncorrectPassword", password, err)
+		}
+	}
+	if list, _ := service.ListPasskeys(user.Id); len(list) != 0 {
+		t.Fatalf("%d passkeys registered without password", len(list))
+	}
+
+	passkey, err := registerPasskey(t, service, user.Id, authenticator, "old-password")
+	if err != nil {
+		t.Fatalf("RegisterPasskey: %v", err)
+	}
+	if passkey.Name != "MacBook" || !passkey.Synced {
+		t.Errorf("passkey = %+v", passkey)
+	}
+
+	response, err := loginWithPasskey(t, service, authenticator)
+	if err != nil {
+		t.Fatalf("LoginWithPasskey: %v", err)
+	}
+	if response.Token == "" || response.User.Id != user.Id {
+		t.Errorf("login response = %+v", response)
+	}
+}
+
+func TestPasskeyLoginPasswordResetRequired(t *testing.T) {
+	service, user, authenticator := newPasskeyTest(t)
+	if _, err := registerPasskey(t, service, user.Id, authenticator, "old-password"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.db.Model(user).Update("password_reset_required", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loginWithPasskey(t, service, authenticator); err != ErrPasswordResetRequired {
+		t.Fatalf("LoginWithPasskey: %v, want ErrPasswordResetRequired", err)
+	}
+}
+
+func TestForcePasswordResetDeletesPasskeys(t *testing.T) {
+	service, user, authenticator := newPasskeyTest(t)
+	if _, err := registerPasskey(t, service, user.Id, authenticator, "old-password"); err != nil {
+		t.Fatal(err)
+	}
+
+	admin := &models.User{Username: "admin", Email: "admin@example.com", Role: models.RoleAdmin}
+	if err := service.db.Create(admin).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := NewAdminService(service.db).ForcePasswordReset(admin.Id, user.Id); err != nil {
+		t.Fatalf("ForcePasswordReset: %v", err)
+	}
+
+	if list, _ := service.ListPasskeys(user.Id); len(list) != 0 {
+		t.Errorf("%d passkeys left after forced reset", len(list))
+	}
+	if _, err := loginWithPasskey(t, service, authenticator); err != ErrPasskeyLoginFailed {
+		t.Errorf("LoginWithPasskey: %v, want ErrPasskeyLoginFailed", err)
+	}
+}
+
+func TestRegisterPasskeyLimit(t *testing.T) {
+	service, user, _ := newPasskeyTest(t)
+	for i := 0; i < maxPasskeysPerUser-1; i++ {
+		credentialId := fmt.Sprintf("credential-%d", i)
+		passkey := models.Passkey{UserId: user.Id, Name: "key", CredentialId: credentialId, CredentialHash: hashUserToken(credentialId)}
+		if err := service.db.Create(&passkey).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 两个请求都在达到上限前获取了选项，只有一个可以注册成功
+	var requests []*models.RegisterPasskeyRequest
+	for i := 0; i < 2; i++ {
+		options, err := service.PasskeyRegisterOptions(user.Id)
+		if err != nil {
+			t.Fatalf("PasskeyRegisterOptions: %v", err)
+		}
+		userHandle, err := webauthn.DecodeBase64(options.User.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		authenticator := webauthntest.NewAuthenticator("blog.example.com", "https://blog.example.com")
+		requests = append(requests, &models.RegisterPasskeyRequest{
+			Password:   "old-password",
+			Name:       "MacBook",
+			Credential: *authenticator.Create(options.Challenge, userHandle),
+		})
+	}
+	if _, err := service.RegisterPasskey(user.Id, requests[0]); err != nil {
+		t.Fatalf("RegisterPasskey: %v", err)
+	}
+	if _, err := service.RegisterPasskey(user.Id, requests[1]); err != ErrTooManyPasskeys {
+		t.Fatalf("RegisterPasskey over limit: %v, want ErrTooManyPasskeys", err)
+	}
+	if _, err := service.PasskeyRegisterOptions(user.Id); err != ErrTooManyPasskeys {
+		t.Errorf("PasskeyRegisterOptions over limit: %v, want ErrTooManyPasskeys", err)
+	}
+
+	var count int64
+	service.db.Model(&models.Passkey{}).Where("user_id = ?", user.Id).Count(&count)
+	if count != maxPasskeysPerUser {
+		t.Errorf("%d passkeys, want %d", count, maxPasskeysPerUser)
+	}
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"server/internal/models"
+	"server/pkg/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPasswordMismatch = errors.New("passwords do not match")
+	ErrSessionRevoked   = errors.New("session has been revoked")
+)
+
+const defaultPasswordResetExpires = time.Hour
+
+// CheckToken 检查登录令牌的版本，重置密码后之前签发的令牌全部失效，用户被删除或停用时令牌也失效
+func (s *UserService) CheckToken(claims *utils.JwtClaims) error {
+	var user models.User
+	if err := s.db.Select("id", "token_version", "suspended_at").First(&user, claims.UserId).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ErrSessionRevoked
+		}
+		return err
+	}
+	if user.SuspendedAt != nil {
+		return ErrAccountSuspended
+	}
+	if user.TokenVersion != claims.TokenVersion {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// ForgotPassword 向邮箱发送重置密码邮件。为避免暴露邮箱是否注册，调用方对任何邮箱都应返回相同的结果，
+// 因此查找用户和发送邮件在后台进行，错误只记录日志
+func (s *UserService) ForgotPassword(email string) {
+	go func() {
+		var user models.User
+		if err := s.db.Where("email = ?", email).First(&user).Error; err != nil {
+			if err != gorm.ErrRecordNotFound {
+				log.Printf("forgot password: failed to load user: %v", err)
+			}
+			return
+		}
+
+		// 同一用户每分钟最多发送一封
+		last, err := lastUserToken(s.db, user.Id, models.TokenPurposeResetPassword)
+		if err != nil && err != gorm.ErrRecordNotFound {
+			log.Printf("forgot password: %v", err)
+			return
+		}
+		if last != nil && time.Since(last.CreatedAt) < verificationResendInterval {
+			return
+		}
+
+		if err := sendPasswordResetEmail(s.db, &user); err != nil {
+			log.Printf("forgot password: %v", err)
+		}
+	}()
+}
+
+// sendPasswordResetEmail 签发重置密码令牌并发送邮件
+func sendPasswordResetEmail(db *gorm.DB, user *models.User) error {
+	expires := durationFromEnv("PASSWORD_RESET_EXPIRES", defaultPasswordResetExpires)
+	token, err := issueUserToken(db, user, models.TokenPurposeResetPassword, "", expires)
+	if err != nil {
+		return err
+	}
+	if _, err := queueEmail(db, user.Id, user.Email, user.Locale, "reset_password", map[string]interface{}{
+		"Username":       user.Username,
+		"Link":           frontendLink("/reset-password", token),
+		"ExpiresMinutes": int(expires.Minutes()),
+	}); err != nil {
+		return fmt.Errorf("failed to queue email for user %d: %w", user.Id, err)
+	}
+	return nil
+}
+
+// ResetPassword 使用邮件中的令牌重置密码，并使该用户所有已登录的会话和个人访问令牌失效
+func (s *UserService) ResetPassword(request *models.ResetPasswordRequest) error {
+	if request.Password == "" || request.Password != request.RepeatPassword {
+		return ErrPasswordMismatch
+	}
+
+	user, _, err := consumeUserToken(s.db, request.Token, models.TokenPurposeResetPassword)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := utils.HashPassword(request.Password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	updates := map[string]interface{}{
+		"password":                hashedPassword,
+		"token_version":           gorm.Expr("token_version + 1"),
+		"password_reset_required": false,
+	}
+	// 能收到重置邮件说明用户拥有该邮箱
+	if user.EmailVerifiedAt == nil {
+		updates["email_verified_at"] = time.Now()
+	}
+	if err := s.db.Model(user).Updates(updates).Error; err != nil {
+		return err
+	}
+	if err := deleteAccessTokens(s.db, user.Id); err != nil {
+		return err
+	}
+	return revokeSessions(s.db, "user_id = ?", user.Id)
+}
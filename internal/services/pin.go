@@ -0,0 +1,91 @@
+package services
+
+import (
+	"server/internal/models"
+	"server/pkg/rbac"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PinService struct {
+	db *gorm.DB
+}
+
+func NewPinService(db *gorm.DB) *PinService {
+	return &PinService{db: db}
+}
+
+// activePins 获取指定范围内未过期的置顶记录，按位置排序
+func activePins(db *gorm.DB, tag string) ([]models.ArticlePin, error) {
+	var pins []models.ArticlePin
+	err := db.Where("tag = ?", tag).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Order("position asc, id asc").
+		Find(&pins).Error
+	return pins, err
+}
+
+// checkEditor 检查用户是否有置顶权限（编辑或管理员）
+func (s *PinService) checkEditor(userId int) error {
+	return requirePermission(s.db, userId, rbac.PermArticlePin)
+}
+
+// Pin 置顶文章，同一范围内重复置顶会更新位置和过期时间
+func (s *PinService) Pin(userId int, articleId int, request *models.PinArticleRequest) (*models.ArticlePin, error) {
+	if err := s.checkEditor(userId); err != nil {
+		return nil, err
+	}
+
+	// 确认文章存在
+	var article models.Article
+	if err := s.db.Select("id").First(&article, articleId).Error; err != nil {
+		return nil, err
+	}
+
+	tag := strings.TrimSpace(request.Tag)
+	var pin models.ArticlePin
+	err := s.db.Where("article_id = ? AND tag = ?", articleId, tag).First(&pin).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	pin.ArticleId = articleId
+	pin.Tag = tag
+	pin.Position = request.Position
+	pin.ExpiresAt = request.ExpiresAt
+	pin.PinnedBy = userId
+
+	if err := s.db.Save(&pin).Error; err != nil {
+		return nil, err
+	}
+	return &pin, nil
+}
+
+// Unpin 取消置顶
+func (s *PinService) Unpin(userId int, articleId int, tag string) error {
+	if err := s.checkEditor(userId); err != nil {
+		return err
+	}
+
+	result := s.db.Where("article_id = ? AND tag = ?", articleId, strings.TrimSpace(tag)).Delete(&models.ArticlePin{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// List 获取指定范围内的置顶记录（含已过期记录，便于编辑管理）
+func (s *PinService) List(userId int, tag string) ([]models.ArticlePin, error) {
+	if err := s.checkEditor(userId); err != nil {
+		return nil, err
+	}
+
+	var pins []models.ArticlePin
+	err := s.db.Where("tag = ?", strings.TrimSpace(tag)).Order("position asc, id asc").Find(&pins).Error
+	return pins, err
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"server/internal/models"
+	"server/pkg/identicon"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 500
+	maxProfileUrlLength  = 512
+	maxSocialLinks       = 10
+	maxSocialLabelLength = 30
+	identiconSize        = 256
+)
+
+var (
+	ErrInvalidProfile = errors.New("invalid profile")
+)
+
+// avatarUrl 用户未设置头像时返回自动生成的头像地址
+func avatarUrl(user *models.User) string {
+	if user.AvatarUrl != "" {
+		return user.AvatarUrl
+	}
+	return fmt.Sprintf("/api/users/%d/avatar", user.Id)
+}
+
+// socialLinks 保证返回的 JSON 中为数组而不是 null
+func socialLinks(user *models.User) []models.SocialLink {
+	if user.SocialLinks == nil {
+		return []models.SocialLink{}
+	}
+	return user.SocialLinks
+}
+
+// profileError 带具体原因的资料校验错误
+func profileError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidProfile, fmt.Sprintf(format, args...))
+}
+
+// validateProfileUrl 校验资料中的链接，只允许 http/https
+func validateProfileUrl(field, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+	if len(raw) > maxProfileUrlLength {
+		return "", profileError("%s must be at most %d characters", field, maxProfileUrlLength)
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", profileError("%s must be an http or https URL", field)
+	}
+	return raw, nil
+}
+
+// UpdateProfile 编辑个人资料
+func (s *UserService) UpdateProfile(userId int, request *models.UpdateProfileRequest) (*models.BaseUser, error) {
+	var user models.User
+	if err := s.db.First(&user, userId).Error; err != nil {
+		return nil, err
+	}
+
+	displayName := strings.TrimSpace(request.DisplayName)
+	if utf8.RuneCountInString(displayName) > maxDisplayNameLength {
+		return nil, profileError("display_name must be at most %d characters", maxDisplayNameLength)
+	}
+	bio := strings.TrimSpace(request.Bio)
+	if utf8.RuneCountInString(bio) > maxBioLength {
+		return nil, profileError("bio must be at most %d characters", maxBioLength)
+	}
+	avatar, err := validateProfileUrl("avatar_url", request.AvatarUrl)
+	if err != nil {
+		return nil, err
+	}
+	website, err := validateProfileUrl("website", request.Website)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(request.SocialLinks) > maxSocialLinks {
+		return nil, profileError("at most %d social links are allowed", maxSocialLinks)
+	}
+	links := make([]models.SocialLink, 0, len(request.SocialLinks))
+	for _, link := range request.SocialLinks {
+		label := strings.TrimSpace(link.Label)
+		if label == "" || utf8.RuneCountInString(label) > maxSocialLabelLength {
+			return nil, profileError("social link label must be 1 to %d characters", maxSocialLabelLength)
+		}
+		linkUrl, err := validateProfileUrl("social link url", link.Url)
+		if err != nil {
+			return nil, err
+		}
+		if linkUrl == "" {
+			return nil, profileError("social link url is required")
+		}
+		links = append(links, models.SocialLink{Label: label, Url: linkUrl})
+	}
+
+	user.DisplayName = displayName
+	user.Bio = bio
+	user.AvatarUrl = avatar
+	user.Website = website
+	user.SocialLinks = links
+	if err := s.db.Model(&user).Select("display_name", "bio", "avatar_url", "website", "social_links").Updates(&user).Error; err != nil {
+		return nil, err
+	}
+
+	base := baseUser(&user)
+	return &base, nil
+}
+
+// Avatar 生成用户的默认头像（identicon），同一用户每次生成的图片相同
+func (s *UserService) Avatar(userId int) ([]byte, error) {
+	var count int64
+	if err := s.db.Model(&models.User{}).Where("id = ?", userId).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, errors.New("user not found")
+	}
+	return identicon.PNG(fmt.Sprintf("user:%d", userId), identiconSize)
+}
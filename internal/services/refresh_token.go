@@ -0,0 +1,126 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"server/internal/models"
+	"server/pkg/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used, please log in again")
+)
+
+const defaultRefreshTokenExpires = 30 * 24 * time.Hour
+
+// issueLoginTokens 在会话中签发登录令牌和刷新令牌。session.Id 为 0 时表示新的登录，先创建会话
+func issueLoginTokens(db *gorm.DB, user *models.User, session *models.Session) (*models.LoginResponse, error) {
+	now := time.Now()
+	session.UserId = user.Id
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(durationFromEnv("REFRESH_TOKEN_EXPIRES", defaultRefreshTokenExpires))
+	if session.Id == 0 {
+		if err := db.Create(session).Error; err != nil {
+			return nil, err
+		}
+	} else if err := db.Model(session).Select("last_seen_at", "expires_at").Updates(session).Error; err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateToken(user.Id, user.TokenVersion, session.Id, user.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	expires, err := utils.AccessTokenExpires()
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Create(&models.RefreshToken{
+		UserId:       user.Id,
+		SessionId:    session.Id,
+		TokenHash:    hashUserToken(refreshToken),
+		TokenVersion: user.TokenVersion,
+		ExpiresAt:    session.ExpiresAt,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	base := baseUser(user)
+	return &models.LoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(expires.Seconds()),
+		User:         &base,
+	}, nil
+}
+
+// Refresh 使用刷新令牌换取新的登录令牌和刷新令牌，旧的刷新令牌随即失效。
+// 已换发过的令牌再次出现说明令牌可能被盗用，此时作废整个会话，合法用户和攻击者都需要重新登录
+func (s *UserService) Refresh(token string) (*models.LoginResponse, error) {
+	if token == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	var refreshToken models.RefreshToken
+	if err := s.db.Where("token_hash = ?", hashUserToken(token)).First(&refreshToken).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	if refreshToken.RevokedAt != nil || time.Now().After(refreshToken.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// 条件更新保证并发请求中只有一个能换发成功，其余视为重复使用
+	result := s.db.Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", refreshToken.Id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		if err := revokeSessions(s.db, "id = ?", refreshToken.SessionId); err != nil {
+			return nil, err
+		}
+		log.Printf("refresh token reused: user %d, session %d revoked", refreshToken.UserId, refreshToken.SessionId)
+		return nil, ErrRefreshTokenReused
+	}
+
+	var session models.Session
+	if err := s.db.First(&session, refreshToken.SessionId).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	if session.RevokedAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	var user models.User
+	if err := s.db.First(&user, refreshToken.UserId).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	if user.TokenVersion != refreshToken.TokenVersion {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err := checkLoginAllowed(&user); err != nil {
+		return nil, err
+	}
+
+	return issueLoginTokens(s.db, &user, &session)
+}
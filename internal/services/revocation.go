@@ -0,0 +1,131 @@
+package services
+
+import (
+	"log"
+	"os"
+	"server/internal/models"
+	"server/pkg/revocation"
+	"server/pkg/utils"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 数据库中过期注销记录的清理间隔
+const revokedTokenSweepInterval = time.Hour
+
+// dbRevocationStore 数据库实现，多实例部署和重启后仍然有效
+type dbRevocationStore struct {
+	db        *gorm.DB
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func (s *dbRevocationStore) Revoke(jti string, expiresAt time.Time) error {
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RevokedToken{
+		Jti:       jti,
+		ExpiresAt: expiresAt,
+	}).Error; err != nil {
+		return err
+	}
+
+	// 顺便清理已过期的记录
+	s.mu.Lock()
+	sweep := time.Since(s.lastSweep) >= revokedTokenSweepInterval
+	if sweep {
+		s.lastSweep = time.Now()
+	}
+	s.mu.Unlock()
+	if sweep {
+		if err := s.db.Where("expires_at < ?", time.Now()).Delete(&models.RevokedToken{}).Error; err != nil {
+			log.Printf("failed to clean up revoked tokens: %v", err)
+		}
+	}
+	return nil
+}
+
+func (s *dbRevocationStore) IsRevoked(jti string) (bool, error) {
+	var count int64
+	if err := s.db.Model(&models.RevokedToken{}).Where("jti = ? AND expires_at > ?", jti, time.Now()).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+var (
+	revocationStore     revocation.Store
+	revocationStoreOnce sync.Once
+)
+
+// getRevocationStore 按 TOKEN_REVOCATION_STORE 选择注销记录的存储方式：memory 或 database（默认）
+func getRevocationStore(db *gorm.DB) revocation.Store {
+	revocationStoreOnce.Do(func() {
+		if os.Getenv("TOKEN_REVOCATION_STORE") == "memory" {
+			revocationStore = revocation.NewMemoryStore()
+			return
+		}
+		revocationStore = &dbRevocationStore{db: db}
+	})
+	return revocationStore
+}
+
+// revokeAccessToken 注销单个登录令牌，保留到令牌本身过期为止
+func revokeAccessToken(db *gorm.DB, claims *utils.JwtClaims) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+	return getRevocationStore(db).Revoke(claims.ID, claims.ExpiresAt.Time)
+}
+
+// CheckRevoked 检查登录令牌是否已注销（退出登录）
+func (s *UserService) CheckRevoked(claims *utils.JwtClaims) error {
+	if claims.ID == "" {
+		return nil
+	}
+	revoked, err := getRevocationStore(s.db).IsRevoked(claims.ID)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// Logout 退出登录：注销当前令牌并作废当前会话。传入刷新令牌时同时作废其所属的会话
+func (s *UserService) Logout(claims *utils.JwtClaims, refreshToken string) error {
+	if err := revokeAccessToken(s.db, claims); err != nil {
+		return err
+	}
+	if claims.SessionId != 0 {
+		if err := revokeSessions(s.db, "id = ? AND user_id = ?", claims.SessionId, claims.UserId); err != nil {
+			return err
+		}
+	}
+	if refreshToken == "" {
+		return nil
+	}
+
+	var token models.RefreshToken
+	if err := s.db.Where("token_hash = ? AND user_id = ?", hashUserToken(refreshToken), claims.UserId).First(&token).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+	return revokeSessions(s.db, "id = ?", token.SessionId)
+}
+
+// LogoutAll 退出所有设备：令牌版本递增使所有登录令牌失效，同时作废所有会话并删除个人访问令牌
+func (s *UserService) LogoutAll(userId int) error {
+	if err := s.db.Model(&models.User{}).Where("id = ?", userId).
+		Update("token_version", gorm.Expr("token_version + 1")).Error; err != nil {
+		return err
+	}
+	if err := deleteAccessTokens(s.db, userId); err != nil {
+		return err
+	}
+	return revokeSessions(s.db, "user_id = ?", userId)
+}
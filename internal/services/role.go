@@ -0,0 +1,75 @@
+package services
+
+import (
+	"errors"
+	"server/internal/models"
+	"server/pkg/rbac"
+
+	"gorm.io/gorm"
+)
+
+var ErrPermissionDenied = errors.New("permission denied")
+
+// userRole 查询用户当前的角色，用户不存在时返回空字符串（没有任何权限）
+func userRole(db *gorm.DB, userId int) (string, error) {
+	if userId <= 0 {
+		return "", nil
+	}
+	var user models.User
+	if err := db.Select("id", "role").First(&user, userId).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+	return user.Role, nil
+}
+
+// userCan 检查用户是否拥有指定权限。以数据库中的角色为准，角色变更后立即生效
+func userCan(db *gorm.DB, userId int, permission rbac.Permission) (bool, error) {
+	role, err := userRole(db, userId)
+	if err != nil {
+		return false, err
+	}
+	return rbac.Has(role, permission), nil
+}
+
+// requirePermission 没有权限时返回 ErrPermissionDenied
+func requirePermission(db *gorm.DB, userId int, permission rbac.Permission) error {
+	ok, err := userCan(db, userId, permission)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// isModerator 检查用户是否为审核人员（编辑或管理员），匿名用户直接返回 false
+func isModerator(db *gorm.DB, userId int) (bool, error) {
+	return userCan(db, userId, rbac.PermArticleModerate)
+}
+
+// canManageArticle 文章的所有权策略：作者本人总是可以，其他人需要对应的 *_any 权限。
+// 返回值 onBehalf 表示操作者不是作者本人，而是以编辑/管理员身份操作
+func canManageArticle(db *gorm.DB, userId int, article *models.Article, anyPermission rbac.Permission) (allowed bool, onBehalf bool, err error) {
+	if userId > 0 && article.UserId == userId {
+		return true, false, nil
+	}
+	ok, err := userCan(db, userId, anyPermission)
+	if err != nil {
+		return false, false, err
+	}
+	return ok, ok, nil
+}
+
+// logModeration 记录编辑/管理员对他人文章的直接操作
+func logModeration(db *gorm.DB, moderatorId int, article *models.Article, action string) error {
+	return db.Create(&models.ModerationLog{
+		ModeratorId: moderatorId,
+		ArticleId:   article.Id,
+		AuthorId:    article.UserId,
+		Action:      action,
+	}).Error
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"errors"
+	"server/internal/models"
+	"server/pkg/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// 最后活跃时间的更新间隔，避免每个请求都写数据库
+const sessionTouchInterval = time.Minute
+
+// newSession 新登录会话，客户端信息过长时截断
+func newSession(clientIP string, userAgent string) *models.Session {
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+	if len(clientIP) > 64 {
+		clientIP = clientIP[:64]
+	}
+	return &models.Session{
+		UserAgent: userAgent,
+		Ip:        clientIP,
+	}
+}
+
+// revokeSessions 作废满足条件的会话及其刷新令牌，会话内已签发的登录令牌由 CheckSession 拒绝
+func revokeSessions(db *gorm.DB, query string, args ...interface{}) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var ids []int
+		if err := tx.Model(&models.Session{}).Where(query, args...).Where("revoked_at IS NULL").Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		now := time.Now()
+		if err := tx.Model(&models.Session{}).Where("id IN ?", ids).Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("session_id IN ? AND revoked_at IS NULL", ids).
+			Update("revoked_at", now).Error
+	})
+}
+
+// CheckSession 检查登录令牌所属的会话是否仍然有效，并更新会话的最后活跃时间
+func (s *UserService) CheckSession(claims *utils.JwtClaims) error {
+	if claims.SessionId == 0 {
+		return nil
+	}
+
+	var session models.Session
+	if err := s.db.Select("id", "user_id", "last_seen_at", "revoked_at").First(&session, claims.SessionId).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ErrSessionRevoked
+		}
+		return err
+	}
+	if session.RevokedAt != nil || session.UserId != claims.UserId {
+		return ErrSessionRevoked
+	}
+
+	if time.Since(session.LastSeenAt) >= sessionTouchInterval {
+		s.db.Model(&session).Update("last_seen_at", time.Now())
+	}
+	return nil
+}
+
+// ListSessions 当前用户仍然有效的登录会话，按最后活跃时间倒序
+func (s *UserService) ListSessions(userId int, currentSessionId int) ([]models.SessionResponse, error) {
+	var sessions []models.Session
+	if err := s.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userId, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	list := make([]models.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		list = append(list, models.SessionResponse{
+			Id:         session.Id,
+			UserAgent:  session.UserAgent,
+			Ip:         session.Ip,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.Id == currentSessionId,
+		})
+	}
+	return list, nil
+}
+
+// RevokeSession 作废自己的某个会话，该设备需要重新登录
+func (s *UserService) RevokeSession(userId int, sessionId int) error {
+	var session models.Session
+	if err := s.db.Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionId, userId).First(&session).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	return revokeSessions(s.db, "id = ?", session.Id)
+}
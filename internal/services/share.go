@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"server/internal/models"
+	"server/pkg/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidShareExpiry = errors.New("expires_at must be in the future")
+
+type ShareService struct {
+	db *gorm.DB
+}
+
+func NewShareService(db *gorm.DB) *ShareService {
+	return &ShareService{db: db}
+}
+
+// checkAuthor 检查文章是否存在且属于该用户
+func (s *ShareService) checkAuthor(userId int, articleId int) error {
+	var article models.Article
+	if err := s.db.Select("id", "user_id").First(&article, articleId).Error; err != nil {
+		return err
+	}
+	if article.UserId != userId {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// Create 为文章创建分享链接
+func (s *ShareService) Create(userId int, articleId int, request *models.CreateShareRequest) (*models.ArticleShare, error) {
+	if err := s.checkAuthor(userId, articleId); err != nil {
+		return nil, err
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidShareExpiry
+	}
+
+	token, err := utils.GenerateRandomToken(24)
+	if err != nil {
+		return nil, err
+	}
+
+	share := models.ArticleShare{
+		ArticleId: articleId,
+		Token:     token,
+		ExpiresAt: request.ExpiresAt,
+		CreatedBy: userId,
+	}
+	if err := s.db.Create(&share).Error; err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// List 获取文章的分享链接
+func (s *ShareService) List(userId int, articleId int) ([]models.ArticleShare, error) {
+	if err := s.checkAuthor(userId, articleId); err != nil {
+		return nil, err
+	}
+
+	var shares []models.ArticleShare
+	err := s.db.Where("article_id = ?", articleId).Order("id desc").Find(&shares).Error
+	return shares, err
+}
+
+// Revoke 撤销分享链接
+func (s *ShareService) Revoke(userId int, articleId int, shareId int) error {
+	if err := s.checkAuthor(userId, articleId); err != nil {
+		return err
+	}
+
+	var share models.ArticleShare
+	if err := s.db.Where("id = ? AND article_id = ?", shareId, articleId).First(&share).Error; err != nil {
+		return err
+	}
+	if share.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	return s.db.Model(&share).Update("revoked_at", &now).Error
+}
+
+// sharedArticle 分享链接对应的文章，链接无效、已过期、已撤销或文章被隐藏时返回 gorm.ErrRecordNotFound
+func (s *ShareService) sharedArticle(token string, query *gorm.DB) (*models.ArticleShare, *models.Article, error) {
+	var share models.ArticleShare
+	if err := s.db.Where("token = ?", token).First(&share).Error; err != nil {
+		return nil, nil, err
+	}
+	if share.RevokedAt != nil || (share.ExpiresAt != nil && share.ExpiresAt.Before(time.Now())) {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
+
+	var article models.Article
+	if err := query.First(&article, share.ArticleId).Error; err != nil {
+		return nil, nil, err
+	}
+	if article.Hidden {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
+	return &share, &article, nil
+}
+
+// GetByToken 通过分享链接获取文章，受密码保护的文章仍需要访问令牌才返回正文
+func (s *ShareService) GetByToken(token string, accessToken string) (*models.Article, error) {
+	share, article, err := s.sharedArticle(token, s.db.Preload("User").Preload("Tags"))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.db.Model(share).Update("last_used_at", &now).Error; err != nil {
+		return nil, err
+	}
+
+	// 填充用户信息（不含密码）
+	article.UserInfo = userInfo(&article.User)
+	lockIfProtected(article, 0, accessToken)
+
+	return article, nil
+}
+
+// Unlock 通过分享链接解锁受密码保护的文章，不公开和私密的文章只能这样解锁
+func (s *ShareService) Unlock(token string, password string) (*models.UnlockArticleResponse, error) {
+	_, article, err := s.sharedArticle(token, s.db.Select("id", "password", "hidden"))
+	if err != nil {
+		return nil, err
+	}
+	return unlockArticle(article, password)
+}
@@ -0,0 +1,416 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"server/internal/models"
+	"server/pkg/filter"
+	"server/pkg/rbac"
+	"server/pkg/spam"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidSpamLabel = errors.New("invalid spam label")
+
+const (
+	defaultSpamThreshold  = 0.9
+	defaultSpamMinSamples = 20
+)
+
+// 分类器在所有 SpamService 实例间共享，首次使用时从训练样本加载
+var (
+	spamMu         sync.Mutex
+	spamClassifier *spam.Classifier
+)
+
+type SpamService struct {
+	db *gorm.DB
+}
+
+func NewSpamService(db *gorm.DB) *SpamService {
+	return &SpamService{db: db}
+}
+
+// spamThreshold 自动隔离阈值，可通过 SPAM_THRESHOLD 配置
+func spamThreshold() float64 {
+	if value, err := strconv.ParseFloat(os.Getenv("SPAM_THRESHOLD"), 64); err == nil && value > 0 && value <= 1 {
+		return value
+	}
+	return defaultSpamThreshold
+}
+
+// spamMinSamples 每个类别至少需要的样本数，样本不足时只打分不隔离，可通过 SPAM_MIN_SAMPLES 配置
+func spamMinSamples() int {
+	if value, err := strconv.Atoi(os.Getenv("SPAM_MIN_SAMPLES")); err == nil && value >= 0 {
+		return value
+	}
+	return defaultSpamMinSamples
+}
+
+// classifier 获取分类器，必要时从数据库中的训练样本重建
+func (s *SpamService) classifier() (*spam.Classifier, error) {
+	spamMu.Lock()
+	defer spamMu.Unlock()
+
+	if spamClassifier != nil {
+		return spamClassifier, nil
+	}
+
+	classifier := spam.NewClassifier()
+	var samples []models.SpamSample
+	if err := s.db.Find(&samples).Error; err != nil {
+		return nil, err
+	}
+	for _, sample := range samples {
+		classifier.Learn(strings.Fields(sample.Features), labelClass(sample.Label))
+	}
+
+	spamClassifier = classifier
+	return classifier, nil
+}
+
+// labelClass 标注转为分类器类别
+func labelClass(label string) spam.Class {
+	if label == models.SpamLabelSpam {
+		return spam.Spam
+	}
+	return spam.Ham
+}
+
+// bucket 将数值按阈值分段，返回形如 name:<阈值 或 name:>=最大阈值 的特征
+func bucket(name string, value float64, bounds ...float64) string {
+	for _, bound := range bounds {
+		if value < bound {
+			return fmt.Sprintf("%s:<%g", name, bound)
+		}
+	}
+	return fmt.Sprintf("%s:>=%g", name, bounds[len(bounds)-1])
+}
+
+// accountAgeFeature 账号注册时长特征
+func accountAgeFeature(user *models.User) string {
+	if user.CreatedAt.IsZero() {
+		return "__age:unknown"
+	}
+	return bucket("__age_hours", time.Since(user.CreatedAt).Hours(), 1, 24, 24*7, 24*30)
+}
+
+// ArticleFeatures 提取文章特征：文本词、链接数量和密度、账号注册时长、发文频率
+func (s *SpamService) ArticleFeatures(user *models.User, title string, content string) ([]string, error) {
+	text := title + "\n" + content
+	features := spam.Tokenize(text)
+
+	links := filter.ExtractLinks(text)
+	for _, link := range links {
+		features = append(features, "__host:"+link.Host)
+	}
+	features = append(features, bucket("__links", float64(len(links)), 1, 2, 4, 8))
+
+	// 链接密度：每 1000 字中的链接数
+	length := utf8.RuneCountInString(text)
+	if length > 0 {
+		features = append(features, bucket("__link_density", float64(len(links))*1000/float64(length), 0.5, 2, 5, 10))
+	}
+
+	features = append(features, accountAgeFeature(user))
+
+	// 发文频率：最近一小时内的发文数
+	var recent int64
+	if err := s.db.Model(&models.Article{}).
+		Where("user_id = ? AND created_at > ?", user.Id, time.Now().Add(-time.Hour)).
+		Count(&recent).Error; err != nil {
+		return nil, err
+	}
+	features = append(features, bucket("__articles_per_hour", float64(recent), 1, 2, 5, 10))
+
+	return features, nil
+}
+
+// RegistrationFeatures 提取注册特征：用户名、邮箱域名、用户名中数字占比、同一IP的注册频率
+func (s *SpamService) RegistrationFeatures(username string, email string, ip string) ([]string, error) {
+	features := spam.Tokenize(username)
+
+	if i := strings.LastIndex(email, "@"); i >= 0 {
+		features = append(features, "__email_domain:"+strings.ToLower(email[i+1:]))
+	}
+
+	digits := 0
+	for _, r := range username {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	if length := utf8.RuneCountInString(username); length > 0 {
+		features = append(features, bucket("__username_digits", float64(digits)/float64(length), 0.1, 0.3, 0.5))
+	}
+
+	// 注册频率：同一IP最近一小时内的注册数
+	if ip != "" {
+		var recent int64
+		if err := s.db.Model(&models.SpamCheck{}).
+			Where("kind = ? AND ip = ? AND created_at > ?", models.SpamKindUser, ip, time.Now().Add(-time.Hour)).
+			Count(&recent).Error; err != nil {
+			return nil, err
+		}
+		features = append(features, bucket("__registrations_per_hour", float64(recent), 1, 2, 5, 10))
+	}
+
+	return features, nil
+}
+
+// Score 对特征打分，样本数达到要求且分数超过阈值时标记为隔离
+func (s *SpamService) Score(kind string, features []string) (*models.SpamCheck, error) {
+	classifier, err := s.classifier()
+	if err != nil {
+		return nil, err
+	}
+
+	score := classifier.SpamProbability(features)
+	ham, spamDocs := classifier.Docs()
+	minSamples := spamMinSamples()
+	active := ham >= minSamples && spamDocs >= minSamples
+
+	return &models.SpamCheck{
+		Kind:        kind,
+		Score:       score,
+		Quarantined: active && score >= spamThreshold(),
+		Features:    strings.Join(features, " "),
+	}, nil
+}
+
+// ScoreArticle 对新文章打分
+func (s *SpamService) ScoreArticle(user *models.User, title string, content string) (*models.SpamCheck, error) {
+	features, err := s.ArticleFeatures(user, title, content)
+	if err != nil {
+		return nil, err
+	}
+	return s.Score(models.SpamKindArticle, features)
+}
+
+// ScoreRegistration 对注册请求打分
+func (s *SpamService) ScoreRegistration(username string, email string, ip string) (*models.SpamCheck, error) {
+	features, err := s.RegistrationFeatures(username, email, ip)
+	if err != nil {
+		return nil, err
+	}
+	check, err := s.Score(models.SpamKindUser, features)
+	if err != nil {
+		return nil, err
+	}
+	check.Ip = ip
+	return check, nil
+}
+
+// checkModerator 检查用户是否为审核人员
+func (s *SpamService) checkModerator(userId int) error {
+	return requirePermission(s.db, userId, rbac.PermArticleModerate)
+}
+
+// Mark 人工标注文章或用户为正常/垃圾，更新训练样本并增量训练分类器。
+// 标注为垃圾时隐藏文章或隔离用户，标注为正常时解除自动隔离
+func (s *SpamService) Mark(moderatorId int, kind string, targetId int, label string) (*models.SpamSample, error) {
+	if err := s.checkModerator(moderatorId); err != nil {
+		return nil, err
+	}
+	if label != models.SpamLabelHam && label != models.SpamLabelSpam {
+		return nil, ErrInvalidSpamLabel
+	}
+
+	// 优先使用打分时记录的特征，保证训练与打分时的特征一致
+	var check models.SpamCheck
+	err := s.db.Where("kind = ? AND target_id = ?", kind, targetId).Order("id desc").First(&check).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	hasCheck := err == nil
+
+	var sample models.SpamSample
+	var visibilityChanged, wasPublic bool
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		features := check.Features
+		// 补充提取特征时的查询同样在事务中进行
+		service := NewSpamService(tx)
+
+		switch kind {
+		case models.SpamKindArticle:
+			var article models.Article
+			if err := tx.Preload("User").First(&article, targetId).Error; err != nil {
+				return err
+			}
+			if !hasCheck {
+				tokens, err := service.ArticleFeatures(&article.User, article.Title, article.Content)
+				if err != nil {
+					return err
+				}
+				features = strings.Join(tokens, " ")
+			}
+
+			// 标注为垃圾时隐藏；标注为正常时只解除由分类器造成的隐藏
+			hidden := article.Hidden
+			if label == models.SpamLabelSpam {
+				hidden = true
+			} else if hasCheck && check.Quarantined {
+				hidden = false
+			}
+			if hidden != article.Hidden {
+				visibilityChanged, wasPublic = true, isPublicArticle(&article)
+				if err := tx.Model(&article).Update("hidden", hidden).Error; err != nil {
+					return err
+				}
+				action := models.ModerationActionUnhide
+				if hidden {
+					action = models.ModerationActionHide
+				}
+				log := models.ModerationLog{
+					ModeratorId: moderatorId,
+					ArticleId:   article.Id,
+					AuthorId:    article.UserId,
+					Action:      action,
+					Note:        "marked as " + label,
+				}
+				if err := tx.Create(&log).Error; err != nil {
+					return err
+				}
+			}
+		case models.SpamKindUser:
+			var user models.User
+			if err := tx.First(&user, targetId).Error; err != nil {
+				return err
+			}
+			if !hasCheck {
+				tokens, err := service.RegistrationFeatures(user.Username, user.Email, "")
+				if err != nil {
+					return err
+				}
+				features = strings.Join(tokens, " ")
+			}
+			if err := tx.Model(&user).Update("quarantined", label == models.SpamLabelSpam).Error; err != nil {
+				return err
+			}
+		default:
+			return ErrInvalidSpamLabel
+		}
+
+		if hasCheck {
+			if err := tx.Model(&check).Update("label", label).Error; err != nil {
+				return err
+			}
+		}
+
+		// 更新训练样本
+		if err := tx.Where("kind = ? AND target_id = ?", kind, targetId).First(&sample).Error; err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+		previous := sample
+		sample.Kind = kind
+		sample.TargetId = targetId
+		sample.Label = label
+		sample.Features = features
+		sample.LabeledBy = moderatorId
+		if err := tx.Save(&sample).Error; err != nil {
+			return err
+		}
+
+		// 增量训练：撤销旧标注后学习新标注
+		classifier, err := s.classifier()
+		if err != nil {
+			return err
+		}
+		if previous.Id != 0 {
+			classifier.Forget(strings.Fields(previous.Features), labelClass(previous.Label))
+		}
+		classifier.Learn(strings.Fields(features), labelClass(label))
+		return nil
+	})
+	if err != nil {
+		// 事务失败时分类器可能已与数据库不一致，下次使用时重新加载
+		resetSpamClassifier()
+		return nil, err
+	}
+
+	if visibilityChanged {
+		publishArticleChange(s.db, targetId, wasPublic)
+	}
+	return &sample, nil
+}
+
+// resetSpamClassifier 清除内存中的分类器
+func resetSpamClassifier() {
+	spamMu.Lock()
+	spamClassifier = nil
+	spamMu.Unlock()
+}
+
+// Retrain 根据全部训练样本重新训练分类器
+func (s *SpamService) Retrain(userId int) (*models.SpamStatsResponse, error) {
+	if err := s.checkModerator(userId); err != nil {
+		return nil, err
+	}
+	resetSpamClassifier()
+	return s.Stats(userId)
+}
+
+// Stats 获取分类器状态
+func (s *SpamService) Stats(userId int) (*models.SpamStatsResponse, error) {
+	if err := s.checkModerator(userId); err != nil {
+		return nil, err
+	}
+
+	classifier, err := s.classifier()
+	if err != nil {
+		return nil, err
+	}
+	ham, spamDocs := classifier.Docs()
+	minSamples := spamMinSamples()
+
+	return &models.SpamStatsResponse{
+		HamSamples:  ham,
+		SpamSamples: spamDocs,
+		Threshold:   spamThreshold(),
+		MinSamples:  minSamples,
+		Active:      ham >= minSamples && spamDocs >= minSamples,
+	}, nil
+}
+
+// ListChecks 获取检测记录
+func (s *SpamService) ListChecks(userId int, request *models.SpamCheckListRequest) (*models.SpamCheckListResponse, error) {
+	if err := s.checkModerator(userId); err != nil {
+		return nil, err
+	}
+
+	query := s.db.Model(&models.SpamCheck{})
+	if request.Kind != "" {
+		query = query.Where("kind = ?", request.Kind)
+	}
+	if request.Quarantined != nil {
+		query = query.Where("quarantined = ?", *request.Quarantined)
+	}
+	if request.Unlabeled {
+		query = query.Where("label = ?", "")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var checks []models.SpamCheck
+	offset := (request.Page - 1) * request.Size
+	if err := query.Order("score desc, id desc").Offset(offset).Limit(request.Size).Find(&checks).Error; err != nil {
+		return nil, err
+	}
+
+	return &models.SpamCheckListResponse{
+		Checks: checks,
+		Total:  int(total),
+		Page:   request.Page,
+		Size:   request.Size,
+	}, nil
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"server/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpamMark(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("SPAM_MIN_SAMPLES", "1")
+	db := newTestDB(t, appTables...)
+	resetSpamClassifier()
+	t.Cleanup(resetSpamClassifier)
+
+	now := time.Now()
+	editor := &models.User{Username: "editor", Email: "editor@example.com", Role: models.RoleEditor}
+	author := &models.User{Username: "alice", Email: "alice@example.com", Role: models.RoleAuthor, EmailVerifiedAt: &now}
+	if err := db.Create([]*models.User{editor, author}).Error; err != nil {
+		t.Fatal(err)
+	}
+	articles := NewArticleService(db)
+	service := NewSpamService(db)
+
+	spamArticle, err := articles.Create(author.Id, &models.CreateArticleRequest{Title: "免费领取大奖", Content: "点击链接免费领取大奖"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if spamArticle.Hidden {
+		t.Fatal("article hidden before the classifier has samples")
+	}
+	var check models.SpamCheck
+	if err := db.Where("kind = ? AND target_id = ?", models.SpamKindArticle, spamArticle.Id).First(&check).Error; err != nil {
+		t.Fatalf("spam check: %v", err)
+	}
+
+	// 标注为垃圾：隐藏文章，使用打分时记录的特征训练
+	sample, err := service.Mark(editor.Id, models.SpamKindArticle, spamArticle.Id, models.SpamLabelSpam)
+	if err != nil {
+		t.Fatalf("Mark spam: %v", err)
+	}
+	if sample.Features != check.Features || sample.Label != models.SpamLabelSpam {
+		t.Errorf("sample = %+v, want features of the spam check", sample)
+	}
+	hidden := func(articleId int) bool {
+		var article models.Article
+		if err := db.First(&article, articleId).Error; err != nil {
+			t.Fatal(err)
+		}
+		return article.Hidden
+	}
+	db.First(&check, check.Id)
+	if !hidden(spamArticle.Id) || check.Label != models.SpamLabelSpam {
+		t.Errorf("hidden = %v, check label = %q", hidden(spamArticle.Id), check.Label)
+	}
+
+	// 没有检测记录的文章在标注时提取特征
+	hamArticle := &models.Article{UserId: author.Id, Title: "项目会议记录", Content: "今天讨论了发布计划", Visibility: models.VisibilityPublic}
+	if err := db.Create(hamArticle).Error; err != nil {
+		t.Fatal(err)
+	}
+	sample, err = service.Mark(editor.Id, models.SpamKindArticle, hamArticle.Id, models.SpamLabelHam)
+	if err != nil {
+		t.Fatalf("Mark ham: %v", err)
+	}
+	if !strings.Contains(sample.Features, "会议") || !strings.Contains(sample.Features, "__articles_per_hour:") {
+		t.Errorf("extracted features = %q", sample.Features)
+	}
+
+	// 两类都有样本后，相似的新文章被隔离并进入审核队列
+	quarantined, err := articles.Create(author.Id, &models.CreateArticleRequest{Title: "免费领取", Content: "点击领取大奖"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	var reports int64
+	db.Model(&models.Report{}).Where("article_id = ? AND reason = ?", quarantined.Id, models.ReportReasonSpam).Count(&reports)
+	if !quarantined.Hidden || reports != 1 {
+		t.Errorf("similar article hidden = %v with %d reports", quarantined.Hidden, reports)
+	}
+
+	// 重新标注为正常：撤销原标注，解除分类器造成的隐藏
+	if _, err := service.Mark(editor.Id, models.SpamKindArticle, quarantined.Id, models.SpamLabelHam); err != nil {
+		t.Fatalf("Mark ham: %v", err)
+	}
+	if _, err := service.Mark(editor.Id, models.SpamKindArticle, spamArticle.Id, models.SpamLabelHam); err != nil {
+		t.Fatalf("relabel: %v", err)
+	}
+	if hidden(quarantined.Id) {
+		t.Error("quarantined article is still hidden after marked as ham")
+	}
+	if !hidden(spamArticle.Id) {
+		t.Error("manually hidden article was unhidden")
+	}
+	var samples int64
+	db.Model(&models.SpamSample{}).Count(&samples)
+	stats, err := service.Stats(editor.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samples != 3 || stats.HamSamples != 3 || stats.SpamSamples != 0 {
+		t.Errorf("%d samples, classifier ham %d spam %d, want 3, 3, 0", samples, stats.HamSamples, stats.SpamSamples)
+	}
+
+	// 增量训练的结果与从样本重新训练一致
+	probability := func() float64 {
+		classifier, err := service.classifier()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return classifier.SpamProbability(strings.Fields(check.Features))
+	}
+	incremental := probability()
+	if _, err := service.Retrain(editor.Id); err != nil {
+		t.Fatal(err)
+	}
+	if retrained := probability(); retrained != incremental {
+		t.Errorf("retrained probability %v, incremental %v", retrained, incremental)
+	}
+
+	if _, err := service.Mark(author.Id, models.SpamKindArticle, spamArticle.Id, models.SpamLabelSpam); err == nil {
+		t.Error("author marked an article")
+	}
+	if _, err := service.Mark(editor.Id, models.SpamKindArticle, spamArticle.Id, "maybe"); err != ErrInvalidSpamLabel {
+		t.Errorf("invalid label: %v, want ErrInvalidSpamLabel", err)
+	}
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"server/internal/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// normalizeTagNames 去除空白与重复的标签名
+func normalizeTagNames(names []string) []string {
+	seen := make(map[string]bool)
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	return result
+}
+
+// findOrCreateTags 根据标签名查找标签，不存在则创建
+func findOrCreateTags(db *gorm.DB, names []string) ([]models.Tag, error) {
+	names = normalizeTagNames(names)
+	tags := make([]models.Tag, 0, len(names))
+	for _, name := range names {
+		tag := models.Tag{Name: name}
+		if err := db.Where("name = ?", name).FirstOrCreate(&tag).Error; err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
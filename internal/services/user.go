@@ -4,31 +4,69 @@ import (
 	"errors"
 	"fmt"
 	"server/internal/models"
+	"server/internal/templates"
+	"server/pkg/rbac"
 	"server/pkg/utils"
 
 	"gorm.io/gorm"
 )
 
 type UserService struct {
-	db *gorm.DB
+	db   *gorm.DB
+	spam *SpamService
 }
 
 func NewUserService(db *gorm.DB) *UserService {
-	return &UserService{db: db}
+	return &UserService{
+		db:   db,
+		spam: NewSpamService(db),
+	}
+}
+
+// baseUser 基础用户信息
+func baseUser(user *models.User) models.BaseUser {
+	return models.BaseUser{
+		Id:            user.Id,
+		Username:      user.Username,
+		Email:         user.Email,
+		Role:          user.Role,
+		Permissions:   rbac.Permissions(user.Role),
+		EmailVerified: user.EmailVerifiedAt != nil,
+		MfaEnabled:    user.TotpEnabledAt != nil,
+		DisplayName:   user.DisplayName,
+		Bio:           user.Bio,
+		AvatarUrl:     avatarUrl(user),
+		Website:       user.Website,
+		SocialLinks:   socialLinks(user),
+	}
+}
+
+// userInfo 文章等响应中附带的作者信息
+func userInfo(user *models.User) models.UserInfo {
+	return models.UserInfo{
+		Id:          user.Id,
+		Username:    user.Username,
+		Email:       user.Email,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		AvatarUrl:   avatarUrl(user),
+		Website:     user.Website,
+		SocialLinks: socialLinks(user),
+	}
 }
 
 // 用户注册
-func (s *UserService) Register(request *models.RegisterRequest) (*models.BaseUser, error) {
+func (s *UserService) Register(request *models.RegisterRequest, clientIP string) (*models.BaseUser, error) {
 	// 验证密码是否匹配
 	if request.Password != request.RepeatPassword {
-		return nil, errors.New("passwords do not match")
+		return nil, ErrPasswordMismatch
 	}
 
 	// 检查邮箱是否已存在
 	var existingUser models.User
 	result := s.db.Where("email = ?", request.Email).First(&existingUser)
 	if result.Error == nil {
-		return nil, errors.New("email already exists")
+		return nil, ErrEmailExists
 	}
 
 	// 检查用户名是否已存在
@@ -43,28 +81,63 @@ func (s *UserService) Register(request *models.RegisterRequest) (*models.BaseUse
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
+	// 邮件语言
+	var locale string
+	if request.Locale != "" {
+		locale = templates.ResolveLocale(request.Locale)
+	}
+
+	// 垃圾注册检测，分数过高的用户会被隔离
+	check, err := s.spam.ScoreRegistration(request.Username, request.Email, clientIP)
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建新用户
 	user := &models.User{
-		Username: request.Username,
-		Email:    request.Email,
-		Password: hashedPassword,
+		Username:    request.Username,
+		Email:       request.Email,
+		Password:    hashedPassword,
+		Role:        models.RoleAuthor,
+		Locale:      locale,
+		Quarantined: check.Quarantined,
 	}
 
 	// 保存到数据库
-	if err := s.db.Create(user).Error; err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+	if err := s.createUser(user, check); err != nil {
+		return nil, err
 	}
 
 	// 返回基础用户信息
-	return &models.BaseUser{
-		Id:       user.Id,
-		Username: user.Username,
-		Email:    user.Email,
-	}, nil
+	base := baseUser(user)
+	return &base, nil
+}
+
+// createUser 保存新用户和注册时的垃圾检测记录，邮箱未验证时发送验证邮件，并推送注册事件
+func (s *UserService) createUser(user *models.User, check *models.SpamCheck) error {
+	if err := s.db.Create(user).Error; err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	check.TargetId = user.Id
+	if err := s.db.Create(check).Error; err != nil {
+		return err
+	}
+
+	if user.EmailVerifiedAt == nil {
+		sendRegistrationVerification(s.db, user)
+	}
+	publishUserRegistered(user)
+	enqueueWebhooks(s.db, models.EventUserRegistered, 0, models.UserEvent{
+		Id:        user.Id,
+		Username:  user.Username,
+		CreatedAt: user.CreatedAt,
+	})
+	return nil
 }
 
 // 用户登录
-func (s *UserService) Login(request *models.LoginRequest) (*models.LoginResponse, error) {
+func (s *UserService) Login(request *models.LoginRequest, clientIP string, userAgent string) (*models.LoginResponse, error) {
 	// 查找用户
 	var user models.User
 	result := s.db.Where("email = ?", request.Email).First(&user)
@@ -77,21 +150,13 @@ func (s *UserService) Login(request *models.LoginRequest) (*models.LoginResponse
 		return nil, errors.New("invalid email or password")
 	}
 
-	// 生成 JWT token
-	token, err := utils.GenerateToken(user.Id)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+	// 检查账号状态
+	if err := checkLoginAllowed(&user); err != nil {
+		return nil, err
 	}
 
-	// 返回登录响应
-	return &models.LoginResponse{
-		Token: token,
-		User: models.BaseUser{
-			Id:       user.Id,
-			Username: user.Username,
-			Email:    user.Email,
-		},
-	}, nil
+	// 创建登录会话，生成登录令牌和刷新令牌；开启两步验证时先返回 mfa_token
+	return completeLogin(s.db, &user, clientIP, userAgent)
 }
 
 // GetUserById 根据用户ID获取用户信息
@@ -104,11 +169,8 @@ func (s *UserService) GetUserById(userId int) (*models.BaseUser, error) {
 		return nil, err
 	}
 
-	return &models.BaseUser{
-		Id:       user.Id,
-		Username: user.Username,
-		Email:    user.Email,
-	}, nil
+	base := baseUser(&user)
+	return &base, nil
 }
 
 // GetUserDetail 获取用户详情（包含文章统计）
@@ -128,11 +190,7 @@ func (s *UserService) GetUserDetail(userId int) (*models.UserDetailResponse, err
 	}
 
 	return &models.UserDetailResponse{
-		BaseUser: models.BaseUser{
-			Id:       user.Id,
-			Username: user.Username,
-			Email:    user.Email,
-		},
+		BaseUser:     baseUser(&user),
 		ArticleCount: int(articleCount),
 	}, nil
 }
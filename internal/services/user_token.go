@@ -0,0 +1,126 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"os"
+	"server/internal/models"
+	"server/pkg/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidUserToken = errors.New("invalid or expired token")
+
+// hashUserToken 令牌只以哈希形式保存，数据库泄露时无法直接使用
+func hashUserToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueUserToken 签发一次性令牌，同一用户同一用途之前未使用的令牌全部作废。newEmail 只用于修改邮箱
+func issueUserToken(db *gorm.DB, user *models.User, purpose string, newEmail string, ttl time.Duration) (string, error) {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.Id, purpose).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.UserToken{
+			UserId:    user.Id,
+			Purpose:   purpose,
+			TokenHash: hashUserToken(token),
+			Email:     user.Email,
+			NewEmail:  newEmail,
+			ExpiresAt: now.Add(ttl),
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// consumeUserToken 校验并使用令牌，令牌只能成功使用一次。
+// 令牌签发后用户邮箱发生变化时视为无效，返回令牌对应的用户和令牌记录
+func consumeUserToken(db *gorm.DB, token string, purpose string) (*models.User, *models.UserToken, error) {
+	if token == "" {
+		return nil, nil, ErrInvalidUserToken
+	}
+
+	var userToken models.UserToken
+	if err := db.Where("token_hash = ? AND purpose = ?", hashUserToken(token), purpose).First(&userToken).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, ErrInvalidUserToken
+		}
+		return nil, nil, err
+	}
+	if userToken.UsedAt != nil || time.Now().After(userToken.ExpiresAt) {
+		return nil, nil, ErrInvalidUserToken
+	}
+
+	var user models.User
+	if err := db.First(&user, userToken.UserId).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, ErrInvalidUserToken
+		}
+		return nil, nil, err
+	}
+	if user.Email != userToken.Email {
+		return nil, nil, ErrInvalidUserToken
+	}
+
+	// 条件更新保证并发请求中只有一个能使用成功
+	result := db.Model(&models.UserToken{}).
+		Where("id = ? AND used_at IS NULL", userToken.Id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil, ErrInvalidUserToken
+	}
+	return &user, &userToken, nil
+}
+
+// lastUserToken 用户某种用途最近一次签发的令牌，用于限制发送频率
+func lastUserToken(db *gorm.DB, userId int, purpose string) (*models.UserToken, error) {
+	var userToken models.UserToken
+	err := db.Where("user_id = ? AND purpose = ?", userId, purpose).Order("id desc").First(&userToken).Error
+	if err != nil {
+		return nil, err
+	}
+	return &userToken, nil
+}
+
+// durationFromEnv 读取时长配置，未配置或格式错误时使用默认值
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// frontendLink 生成前端页面链接，前端地址通过 FRONTEND_URL 配置
+func frontendLink(path string, token string) string {
+	return frontendURL(path) + "?token=" + url.QueryEscape(token)
+}
+
+// frontendURL 前端页面地址，由 FRONTEND_URL 配置
+func frontendURL(path string) string {
+	base := os.Getenv("FRONTEND_URL")
+	if base == "" {
+		base = "http://localhost:3000"
+	}
+	return strings.TrimRight(base, "/") + path
+}
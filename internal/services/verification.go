@@ -0,0 +1,92 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"os"
+	"server/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEmailNotVerified        = errors.New("please verify your email address first")
+	ErrEmailAlreadyVerified    = errors.New("email address is already verified")
+	ErrVerificationTooFrequent = errors.New("verification email was sent recently, please try again later")
+)
+
+const (
+	defaultEmailVerificationExpires = 24 * time.Hour
+	// 重新发送验证邮件的最短间隔
+	verificationResendInterval = time.Minute
+)
+
+// requireEmailVerification 是否要求验证邮箱后才能发布文章，通过 REQUIRE_EMAIL_VERIFICATION=true 开启
+func requireEmailVerification() bool {
+	return os.Getenv("REQUIRE_EMAIL_VERIFICATION") == "true"
+}
+
+// sendVerificationEmail 签发验证令牌并发送验证邮件
+func sendVerificationEmail(db *gorm.DB, user *models.User) error {
+	expires := durationFromEnv("EMAIL_VERIFICATION_EXPIRES", defaultEmailVerificationExpires)
+	token, err := issueUserToken(db, user, models.TokenPurposeVerifyEmail, "", expires)
+	if err != nil {
+		return err
+	}
+
+	_, err = queueEmail(db, user.Id, user.Email, user.Locale, "verify_email", map[string]interface{}{
+		"Username":     user.Username,
+		"Link":         frontendLink("/verify-email", token),
+		"Token":        token,
+		"ExpiresHours": int(expires.Hours()),
+	})
+	return err
+}
+
+// VerifyEmail 使用邮件中的令牌验证邮箱
+func (s *UserService) VerifyEmail(token string) (*models.BaseUser, error) {
+	user, _, err := consumeUserToken(s.db, token, models.TokenPurposeVerifyEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		if err := s.db.Model(user).Update("email_verified_at", now).Error; err != nil {
+			return nil, err
+		}
+		user.EmailVerifiedAt = &now
+	}
+
+	base := baseUser(user)
+	return &base, nil
+}
+
+// ResendVerification 重新发送验证邮件，之前的验证链接失效
+func (s *UserService) ResendVerification(userId int) error {
+	var user models.User
+	if err := s.db.First(&user, userId).Error; err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	last, err := lastUserToken(s.db, user.Id, models.TokenPurposeVerifyEmail)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+	if last != nil && time.Since(last.CreatedAt) < verificationResendInterval {
+		return ErrVerificationTooFrequent
+	}
+
+	return sendVerificationEmail(s.db, &user)
+}
+
+// sendRegistrationVerification 注册后发送验证邮件，失败只记录日志，用户可以稍后重新发送
+func sendRegistrationVerification(db *gorm.DB, user *models.User) {
+	if err := sendVerificationEmail(db, user); err != nil {
+		log.Printf("failed to send verification email to user %d: %v", user.Id, err)
+	}
+}
@@ -0,0 +1,537 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"server/internal/models"
+	"server/pkg/rbac"
+	"server/pkg/utils"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidWebhookUrl        = errors.New("invalid webhook url, must be an absolute http or https url")
+	ErrInvalidWebhookEvent      = errors.New("invalid webhook event")
+	ErrWebhookAddressNotAllowed = errors.New("webhook address is not allowed")
+)
+
+// 签名相关请求头，签名为 HMAC-SHA256(secret, timestamp + "." + body) 的十六进制
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+const (
+	webhookTimeout       = 10 * time.Second
+	webhookResponseLimit = 1024 // 投递记录中保存的响应内容长度
+	webhookBatchSize     = 20
+	webhookPollInterval  = 5 * time.Second
+	// 投递进行中时占用该记录的时长，避免被再次取出
+	webhookLease              = 2 * webhookTimeout
+	webhookRetryBase          = 30 * time.Second
+	webhookRetryMax           = 6 * time.Hour
+	defaultWebhookMaxAttempts = 8
+)
+
+// 各范围可订阅的事件类型
+var (
+	userWebhookEvents     = []string{models.EventArticleCreated, models.EventArticleUpdated, models.EventArticleDeleted}
+	siteWideWebhookEvents = []string{models.EventArticleCreated, models.EventArticleUpdated, models.EventArticleDeleted, models.EventUserRegistered}
+)
+
+// webhookMaxAttempts 最大投递次数，可通过 WEBHOOK_MAX_ATTEMPTS 配置
+func webhookMaxAttempts() int {
+	if value, err := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS")); err == nil && value > 0 {
+		return value
+	}
+	return defaultWebhookMaxAttempts
+}
+
+// 除 netip 能识别的私有、回环等地址外，webhook 同样不允许连接的保留网段
+var webhookBlockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // 本网络
+	netip.MustParsePrefix("100.64.0.0/10"), // 运营商级 NAT（CGNAT），也常用于云厂商内部网络
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF 协议分配
+	netip.MustParsePrefix("198.18.0.0/15"), // 网络基准测试
+	netip.MustParsePrefix("240.0.0.0/4"),   // 保留地址和广播地址
+	netip.MustParsePrefix("fc00::/7"),      // IPv6 唯一本地地址
+}
+
+// webhookAddressAllowed 地址是否允许作为 webhook 的投递目标，IPv4 映射的 IPv6 地址按 IPv4 检查
+func webhookAddressAllowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsPrivate() || addr.IsLoopback() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range webhookBlockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// webhookDialControl 拒绝连接内网、回环等地址，防止通过 webhook 访问内部服务。
+// 在建立连接时检查解析后的地址，可以防御 DNS 重绑定；WEBHOOK_ALLOW_PRIVATE=true 时不检查（用于本地开发）
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	if os.Getenv("WEBHOOK_ALLOW_PRIVATE") == "true" {
+		return nil
+	}
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !webhookAddressAllowed(addrPort.Addr()) {
+		return ErrWebhookAddressNotAllowed
+	}
+	return nil
+}
+
+var webhookClient = &http.Client{
+	Timeout: webhookTimeout,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: webhookTimeout,
+			Control: webhookDialControl,
+		}).DialContext,
+		TLSHandshakeTimeout: webhookTimeout,
+		MaxIdleConnsPerHost: 2,
+	},
+	// 不跟随重定向，3xx 视为投递失败
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// 投递协程，入队后唤醒，新投递不必等到下一次轮询
+var webhookWorker = newBackgroundWorker()
+
+// StartWebhookWorker 启动后台投递协程，定期取出到期的投递记录进行投递
+func StartWebhookWorker(db *gorm.DB) {
+	webhookWorker.Start(webhookPollInterval, func() {
+		processWebhookDeliveries(db)
+	})
+}
+
+// processWebhookDeliveries 投递所有到期的记录，同一批次并发投递
+func processWebhookDeliveries(db *gorm.DB) {
+	for {
+		var deliveries []models.WebhookDelivery
+		if err := db.Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, time.Now()).
+			Order("next_attempt_at asc").Limit(webhookBatchSize).Find(&deliveries).Error; err != nil {
+			log.Printf("webhook: failed to load deliveries: %v", err)
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+
+		var wg sync.WaitGroup
+		for i := range deliveries {
+			// 先占用记录，多个实例同时运行时只有一个会投递成功占用的记录
+			delivery := &deliveries[i]
+			leaseUntil := time.Now().Add(webhookLease)
+			result := db.Model(&models.WebhookDelivery{}).
+				Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.Id, models.WebhookDeliveryPending, delivery.NextAttemptAt).
+				Update("next_attempt_at", leaseUntil)
+			if result.Error != nil || result.RowsAffected == 0 {
+				continue
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				deliverWebhook(db, delivery)
+			}()
+		}
+		wg.Wait()
+
+		if len(deliveries) < webhookBatchSize {
+			return
+		}
+	}
+}
+
+// webhookSignature 计算投递签名
+func webhookSignature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliverWebhook 投递一次并记录结果，失败时按指数退避安排重试
+func deliverWebhook(db *gorm.DB, delivery *models.WebhookDelivery) {
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseCode = 0
+	delivery.ResponseBody = ""
+	delivery.Error = ""
+
+	var webhook models.Webhook
+	err := db.First(&webhook, delivery.WebhookId).Error
+	if err == nil && !webhook.Active {
+		err = errors.New("webhook is inactive")
+	}
+	if err == nil {
+		err = sendWebhook(&webhook, delivery)
+	}
+
+	switch {
+	case err == nil:
+		delivery.Status = models.WebhookDeliverySuccess
+	case delivery.Attempts >= webhookMaxAttempts() || !webhook.Active:
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.Error = err.Error()
+	default:
+		delivery.Status = models.WebhookDeliveryPending
+		delivery.NextAttemptAt = now.Add(exponentialBackoff(webhookRetryBase, webhookRetryMax, delivery.Attempts))
+		delivery.Error = err.Error()
+	}
+	if len(delivery.Error) > 1024 {
+		delivery.Error = delivery.Error[:1024]
+	}
+
+	// 只更新投递结果，记录在投递期间被删除时不会重新创建
+	if err := db.Model(delivery).
+		Select("status", "attempts", "next_attempt_at", "last_attempt_at", "response_code", "response_body", "error").
+		Updates(delivery).Error; err != nil {
+		log.Printf("webhook: failed to save delivery %d: %v", delivery.Id, err)
+	}
+}
+
+// sendWebhook 发送签名后的请求，返回非 2xx 响应视为失败
+func sendWebhook(webhook *models.Webhook, delivery *models.WebhookDelivery) error {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "server-webhook/1.0")
+	request.Header.Set(WebhookEventHeader, delivery.EventType)
+	request.Header.Set(WebhookDeliveryHeader, strconv.Itoa(delivery.Id))
+	request.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(WebhookSignatureHeader, webhookSignature(webhook.Secret, timestamp, body))
+
+	resp, err := webhookClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	delivery.ResponseCode = resp.StatusCode
+	delivery.ResponseBody = strings.ToValidUTF8(string(responseBody), "")
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// enqueueWebhooks 为订阅了该事件的 webhook 创建投递记录：全站 webhook 接收所有事件，
+// 用户的 webhook 只接收 ownerId 为自己的事件。失败只记录日志，不影响触发事件的操作
+func enqueueWebhooks(db *gorm.DB, eventType string, ownerId int, data interface{}) {
+	var webhooks []models.Webhook
+	if err := db.Where("active = ? AND (site_wide = ? OR user_id = ?)", true, true, ownerId).Find(&webhooks).Error; err != nil {
+		log.Printf("webhook: failed to load webhooks for %s: %v", eventType, err)
+		return
+	}
+
+	var deliveries []models.WebhookDelivery
+	for _, webhook := range webhooks {
+		if slices.Contains(strings.Split(webhook.Events, ","), eventType) {
+			deliveries = append(deliveries, models.WebhookDelivery{
+				WebhookId:     webhook.Id,
+				EventType:     eventType,
+				Status:        models.WebhookDeliveryPending,
+				NextAttemptAt: time.Now(),
+			})
+		}
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+
+	eventId, err := utils.GenerateRandomToken(12)
+	if err != nil {
+		log.Printf("webhook: %v", err)
+		return
+	}
+	payload, err := json.Marshal(models.WebhookPayload{
+		Id:        eventId,
+		Type:      eventType,
+		CreatedAt: time.Now(),
+		Data:      data,
+	})
+	if err != nil {
+		log.Printf("webhook: failed to encode %s payload: %v", eventType, err)
+		return
+	}
+	for i := range deliveries {
+		deliveries[i].EventId = eventId
+		deliveries[i].Payload = string(payload)
+	}
+
+	if err := db.Create(&deliveries).Error; err != nil {
+		log.Printf("webhook: failed to enqueue %s deliveries: %v", eventType, err)
+		return
+	}
+	webhookWorker.Wake()
+}
+
+type WebhookService struct {
+	db *gorm.DB
+}
+
+func NewWebhookService(db *gorm.DB) *WebhookService {
+	return &WebhookService{db: db}
+}
+
+// validateWebhookUrl 检查地址格式，内网地址在投递时拒绝
+func validateWebhookUrl(rawUrl string) error {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || len(rawUrl) > 2048 {
+		return ErrInvalidWebhookUrl
+	}
+	return nil
+}
+
+// webhookEvents 校验并规范化订阅的事件，为空时订阅该范围的所有事件
+func webhookEvents(events []string, siteWide bool) (string, error) {
+	allowed := userWebhookEvents
+	if siteWide {
+		allowed = siteWideWebhookEvents
+	}
+	if len(events) == 0 {
+		return strings.Join(allowed, ","), nil
+	}
+
+	result := make([]string, 0, len(events))
+	for _, event := range events {
+		event = strings.TrimSpace(event)
+		if !slices.Contains(allowed, event) {
+			return "", ErrInvalidWebhookEvent
+		}
+		if !slices.Contains(result, event) {
+			result = append(result, event)
+		}
+	}
+	return strings.Join(result, ","), nil
+}
+
+// fillWebhook 填充事件列表，清除签名密钥
+func fillWebhook(webhook *models.Webhook) {
+	webhook.EventList = strings.Split(webhook.Events, ",")
+	webhook.Secret = ""
+}
+
+// getWebhook 获取用户可以管理的 webhook：自己创建的，或管理员管理全站 webhook
+func (s *WebhookService) getWebhook(userId int, webhookId int) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := s.db.First(&webhook, webhookId).Error; err != nil {
+		return nil, err
+	}
+	if webhook.UserId == userId {
+		return &webhook, nil
+	}
+	if webhook.SiteWide {
+		isAdmin, err := userCan(s.db, userId, rbac.PermSiteManage)
+		if err != nil {
+			return nil, err
+		}
+		if isAdmin {
+			return &webhook, nil
+		}
+	}
+	// 不暴露其他用户的 webhook 是否存在
+	return nil, gorm.ErrRecordNotFound
+}
+
+// List 获取用户的 webhook，管理员同时可以看到全站 webhook
+func (s *WebhookService) List(userId int) ([]models.Webhook, error) {
+	isAdmin, err := userCan(s.db, userId, rbac.PermSiteManage)
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.db.Where("user_id = ?", userId)
+	if isAdmin {
+		query = s.db.Where("user_id = ? OR site_wide = ?", userId, true)
+	}
+	var webhooks []models.Webhook
+	if err := query.Order("id asc").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	for i := range webhooks {
+		fillWebhook(&webhooks[i])
+	}
+	return webhooks, nil
+}
+
+// Create 创建 webhook，返回的记录中包含签名密钥（之后不再返回）
+func (s *WebhookService) Create(userId int, request *models.CreateWebhookRequest) (*models.Webhook, error) {
+	if request.SiteWide {
+		if err := requirePermission(s.db, userId, rbac.PermSiteManage); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateWebhookUrl(request.Url); err != nil {
+		return nil, err
+	}
+	events, err := webhookEvents(request.Events, request.SiteWide)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := utils.GenerateRandomToken(24)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := models.Webhook{
+		UserId:   userId,
+		Url:      request.Url,
+		Secret:   secret,
+		Events:   events,
+		SiteWide: request.SiteWide,
+		Active:   true,
+	}
+	if err := s.db.Create(&webhook).Error; err != nil {
+		return nil, err
+	}
+
+	webhook.EventList = strings.Split(webhook.Events, ",")
+	return &webhook, nil
+}
+
+// Update 更新 webhook 的地址、订阅的事件或启用状态
+func (s *WebhookService) Update(userId int, webhookId int, request *models.UpdateWebhookRequest) (*models.Webhook, error) {
+	webhook, err := s.getWebhook(userId, webhookId)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Url != "" {
+		if err := validateWebhookUrl(request.Url); err != nil {
+			return nil, err
+		}
+		webhook.Url = request.Url
+	}
+	if request.Events != nil {
+		if webhook.Events, err = webhookEvents(request.Events, webhook.SiteWide); err != nil {
+			return nil, err
+		}
+	}
+	if request.Active != nil {
+		webhook.Active = *request.Active
+	}
+	if err := s.db.Save(webhook).Error; err != nil {
+		return nil, err
+	}
+
+	fillWebhook(webhook)
+	return webhook, nil
+}
+
+// Delete 删除 webhook 及其投递记录
+func (s *WebhookService) Delete(userId int, webhookId int) error {
+	webhook, err := s.getWebhook(userId, webhookId)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", webhook.Id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(webhook).Error
+	})
+}
+
+// ListDeliveries 获取 webhook 的投递记录
+func (s *WebhookService) ListDeliveries(userId int, webhookId int, request *models.WebhookDeliveryListRequest) (*models.WebhookDeliveryListResponse, error) {
+	webhook, err := s.getWebhook(userId, webhookId)
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.db.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhook.Id)
+	if request.Status != "" {
+		query = query.Where("status = ?", request.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var deliveries []models.WebhookDelivery
+	offset := (request.Page - 1) * request.Size
+	if err := query.Order("id desc").Offset(offset).Limit(request.Size).Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+
+	return &models.WebhookDeliveryListResponse{
+		Deliveries: deliveries,
+		Total:      int(total),
+		Page:       request.Page,
+		Size:       request.Size,
+	}, nil
+}
+
+// Redeliver 重新投递某条记录的内容，创建一条新的投递记录并立即投递
+func (s *WebhookService) Redeliver(userId int, webhookId int, deliveryId int) (*models.WebhookDelivery, error) {
+	webhook, err := s.getWebhook(userId, webhookId)
+	if err != nil {
+		return nil, err
+	}
+
+	var original models.WebhookDelivery
+	if err := s.db.Where("id = ? AND webhook_id = ?", deliveryId, webhook.Id).First(&original).Error; err != nil {
+		return nil, err
+	}
+
+	delivery := models.WebhookDelivery{
+		WebhookId:     webhook.Id,
+		EventId:       original.EventId,
+		EventType:     original.EventType,
+		Payload:       original.Payload,
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: time.Now(),
+		RedeliveryOf:  original.Id,
+	}
+	if err := s.db.Create(&delivery).Error; err != nil {
+		return nil, err
+	}
+
+	webhookWorker.Wake()
+	return &delivery, nil
+}
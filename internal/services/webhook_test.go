@@ -0,0 +1,213 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"server/internal/models"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// webhookReceiver 记录收到的投递，按 statuses 依次返回状态码，用完后返回 200
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+	w.Write([]byte("received"))
+}
+
+func newWebhookTest(t *testing.T, statuses ...int) (*gorm.DB, *webhookReceiver, *models.Webhook) {
+	t.Helper()
+	// 接收方是本机的 httptest 服务，需要允许投递到回环地址
+	t.Setenv("WEBHOOK_ALLOW_PRIVATE", "true")
+
+	receiver := &webhookReceiver{statuses: statuses}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+
+	db := newTestDB(t, &models.Webhook{}, &models.WebhookDelivery{})
+	webhook := &models.Webhook{
+		UserId: 1,
+		Url:    server.URL + "/hook",
+		Secret: "test-secret",
+		Events: models.EventArticleCreated + "," + models.EventArticleUpdated,
+		Active: true,
+	}
+	if err := db.Create(webhook).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db, receiver, webhook
+}
+
+func loadDelivery(t *testing.T, db *gorm.DB) models.WebhookDelivery {
+	t.Helper()
+	var delivery models.WebhookDelivery
+	if err := db.First(&delivery).Error; err != nil {
+		t.Fatal(err)
+	}
+	return delivery
+}
+
+func TestWebhookDeliverySignature(t *testing.T) {
+	db, receiver, webhook := newWebhookTest(t)
+
+	enqueueWebhooks(db, models.EventArticleCreated, webhook.UserId, map[string]int{"id": 42})
+	processWebhookDeliveries(db)
+
+	if len(receiver.requests) != 1 {
+		t.Fatalf("received %d requests, want 1", len(receiver.requests))
+	}
+	request, body := receiver.requests[0], receiver.bodies[0]
+	if request.Header.Get(WebhookEventHeader) != models.EventArticleCreated {
+		t.Errorf("event header = %q", request.Header.Get(WebhookEventHeader))
+	}
+
+	// 按文档中的算法独立计算签名
+	timestamp := request.Header.Get(WebhookTimestampHeader)
+	mac := hmac.New(sha256.New, []byte(webhook.Secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := request.Header.Get(WebhookSignatureHeader); got != want {
+		t.Errorf("signature = %q, want %q", got, want)
+	}
+
+	var payload struct {
+		Type string         `json:"type"`
+		Data map[string]int `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Type != models.EventArticleCreated || payload.Data["id"] != 42 {
+		t.Errorf("payload = %s", body)
+	}
+
+	delivery := loadDelivery(t, db)
+	if delivery.Status != models.WebhookDeliverySuccess || delivery.Attempts != 1 || delivery.ResponseCode != http.StatusOK {
+		t.Errorf("delivery = %+v", delivery)
+	}
+	if request.Header.Get(WebhookDeliveryHeader) != strconv.Itoa(delivery.Id) {
+		t.Errorf("delivery header = %q", request.Header.Get(WebhookDeliveryHeader))
+	}
+}
+
+func TestWebhookDeliveryRetry(t *testing.T) {
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "3")
+	db, receiver, webhook := newWebhookTest(t, 500, 503, 502)
+
+	enqueueWebhooks(db, models.EventArticleUpdated, webhook.UserId, nil)
+
+	for attempt, backoff := range []time.Duration{webhookRetryBase, 2 * webhookRetryBase} {
+		before := time.Now()
+		processWebhookDeliveries(db)
+
+		delivery := loadDelivery(t, db)
+		if delivery.Status != models.WebhookDeliveryPending || delivery.Attempts != attempt+1 {
+			t.Fatalf("attempt %d: delivery = %+v", attempt+1, delivery)
+		}
+		if delivery.ResponseCode < 500 || delivery.Error == "" {
+			t.Errorf("attempt %d: response code %d, error %q", attempt+1, delivery.ResponseCode, delivery.Error)
+		}
+		wait := delivery.NextAttemptAt.Sub(before)
+		if wait < backoff || wait > backoff+time.Minute {
+			t.Errorf("attempt %d: next attempt in %v, want about %v", attempt+1, wait, backoff)
+		}
+
+		// 未到重试时间时不会投递
+		processWebhookDeliveries(db)
+		if len(receiver.requests) != attempt+1 {
+			t.Fatalf("delivered before next_attempt_at: %d requests", len(receiver.requests))
+		}
+		db.Model(&delivery).Update("next_attempt_at", time.Now().Add(-time.Second))
+	}
+
+	// 第三次失败后不再重试
+	processWebhookDeliveries(db)
+	delivery := loadDelivery(t, db)
+	if delivery.Status != models.WebhookDeliveryFailed || delivery.Attempts != 3 {
+		t.Fatalf("delivery = %+v", delivery)
+	}
+	db.Model(&delivery).Update("next_attempt_at", time.Now().Add(-time.Second))
+	processWebhookDeliveries(db)
+	if len(receiver.requests) != 3 {
+		t.Errorf("received %d requests, want 3", len(receiver.requests))
+	}
+}
+
+func TestWebhookPrivateAddressRejected(t *testing.T) {
+	db, receiver, webhook := newWebhookTest(t)
+	t.Setenv("WEBHOOK_ALLOW_PRIVATE", "false")
+
+	enqueueWebhooks(db, models.EventArticleCreated, webhook.UserId, nil)
+	processWebhookDeliveries(db)
+
+	if len(receiver.requests) != 0 {
+		t.Fatalf("delivered to loopback address")
+	}
+	delivery := loadDelivery(t, db)
+	if delivery.Status != models.WebhookDeliveryPending || delivery.Attempts != 1 || delivery.Error == "" {
+		t.Errorf("delivery = %+v", delivery)
+	}
+}
+
+func TestWebhookAddressAllowed(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"100.127.255.254", false},
+		{"100.128.0.1", true},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"198.18.0.1", false},
+		{"255.255.255.255", false},
+		{"224.0.0.1", false},
+		{"::1", false},
+		{"::", false},
+		{"fe80::1", false},
+		{"fc00::1", false},
+		{"fd12:3456::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:100.64.0.1", false},
+		{"::ffff:93.184.216.34", true},
+	}
+	for _, test := range tests {
+		if got := webhookAddressAllowed(netip.MustParseAddr(test.address)); got != test.allowed {
+			t.Errorf("webhookAddressAllowed(%s) = %v, want %v", test.address, got, test.allowed)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// backgroundWorker 处理持久化队列的后台协程：按固定间隔轮询，入队时也可以唤醒立即处理
+type backgroundWorker struct {
+	wake  chan struct{}
+	start sync.Once
+}
+
+func newBackgroundWorker() *backgroundWorker {
+	return &backgroundWorker{wake: make(chan struct{}, 1)}
+}
+
+// Start 启动协程，重复调用只启动一次
+func (w *backgroundWorker) Start(interval time.Duration, process func()) {
+	w.start.Do(func() {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				process()
+				select {
+				case <-ticker.C:
+				case <-w.wake:
+				}
+			}
+		}()
+	})
+}
+
+// Wake 唤醒协程，协程正在处理时会在处理完后再处理一次
+func (w *backgroundWorker) Wake() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// exponentialBackoff 第 attempts 次失败后的重试等待时间，从 base 开始翻倍，不超过 max
+func exponentialBackoff(base time.Duration, max time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
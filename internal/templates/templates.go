@@ -0,0 +1,127 @@
+// Package templates 内置的邮件模板，按语言分目录存放：
+// <locale>/<name>.txt 定义 subject 和 text，<locale>/<name>.html 定义 content 并套用 layout.html
+package templates
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"os"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+)
+
+//go:embed email
+var emailFS embed.FS
+
+// DefaultLocale 默认语言，可通过 MAIL_DEFAULT_LOCALE 配置
+func DefaultLocale() string {
+	if locale := os.Getenv("MAIL_DEFAULT_LOCALE"); locale != "" {
+		return locale
+	}
+	return "zh-CN"
+}
+
+// SiteName 邮件中显示的站点名称，可通过 SITE_NAME 配置
+func SiteName() string {
+	if name := os.Getenv("SITE_NAME"); name != "" {
+		return name
+	}
+	return "Blog"
+}
+
+// EmailLocales 支持的邮件语言
+func EmailLocales() []string {
+	entries, _ := emailFS.ReadDir("email")
+	locales := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			locales = append(locales, entry.Name())
+		}
+	}
+	return locales
+}
+
+// ResolveLocale 选择最接近的语言：完全匹配、语言前缀匹配（如 en-US 匹配 en），否则使用默认语言
+func ResolveLocale(locale string) string {
+	locales := EmailLocales()
+	for _, candidate := range locales {
+		if strings.EqualFold(candidate, locale) {
+			return candidate
+		}
+	}
+	if language, _, _ := strings.Cut(locale, "-"); language != "" {
+		for _, candidate := range locales {
+			if prefix, _, _ := strings.Cut(candidate, "-"); strings.EqualFold(prefix, language) {
+				return candidate
+			}
+		}
+	}
+	return DefaultLocale()
+}
+
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// 已解析的模板，按 locale/name 缓存
+var emailTemplates sync.Map
+
+func loadEmailTemplate(locale string, name string) (*emailTemplate, error) {
+	key := locale + "/" + name
+	if cached, ok := emailTemplates.Load(key); ok {
+		return cached.(*emailTemplate), nil
+	}
+
+	text, err := texttemplate.ParseFS(emailFS, "email/"+key+".txt")
+	if err != nil {
+		return nil, err
+	}
+	html, err := htmltemplate.ParseFS(emailFS, "email/layout.html", "email/"+key+".html")
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := &emailTemplate{text: text, html: html}
+	emailTemplates.Store(key, tmpl)
+	return tmpl, nil
+}
+
+// RenderEmail 渲染邮件的标题、纯文本和 HTML 正文。data 中会自动加入 SiteName 和 Locale
+func RenderEmail(locale string, name string, data map[string]interface{}) (subject string, text string, html string, err error) {
+	locale = ResolveLocale(locale)
+	tmpl, err := loadEmailTemplate(locale, name)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	values := map[string]interface{}{
+		"SiteName": SiteName(),
+		"Locale":   locale,
+	}
+	for key, value := range data {
+		values[key] = value
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&buf, "subject", values); err != nil {
+		return "", "", "", err
+	}
+	subject = strings.TrimSpace(buf.String())
+
+	buf.Reset()
+	if err := tmpl.text.ExecuteTemplate(&buf, "text", values); err != nil {
+		return "", "", "", err
+	}
+	text = strings.TrimSpace(buf.String()) + "\n"
+
+	buf.Reset()
+	if err := tmpl.html.ExecuteTemplate(&buf, "layout", values); err != nil {
+		return "", "", "", err
+	}
+	html = buf.String()
+
+	return subject, text, html, nil
+}
@@ -5,6 +5,9 @@ import (
 	"os"
 	"server/internal/models"
 	"server/internal/routes"
+	"server/internal/services"
+	"strings"
+	_ "time/tzdata" // 内置时区数据，保证归档等按时区统计的功能在没有系统时区库的环境中可用
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -34,13 +37,52 @@ func main() {
 	}
 
 	// 自动迁移表结构
-	db.AutoMigrate(&models.User{}, &models.Article{})
+	db.AutoMigrate(
+		&models.User{},
+		&models.Article{},
+		&models.Tag{},
+		&models.ArticlePin{},
+		&models.Report{},
+		&models.ModerationLog{},
+		&models.FilterRule{},
+		&models.SpamCheck{},
+		&models.SpamSample{},
+		&models.ArticleShare{},
+		&models.ArticleAutosave{},
+		&models.ArticleCollaborator{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.EmailOutbox{},
+		&models.UserToken{},
+		&models.Session{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.UserIdentity{},
+		&models.OidcLoginState{},
+		&models.MfaRecoveryCode{},
+		&models.MfaChallenge{},
+		&models.Passkey{},
+		&models.WebauthnChallenge{},
+		&models.PersonalAccessToken{},
+	)
+
+	// 启动 webhook 投递和邮件发送协程
+	services.StartWebhookWorker(db)
+	services.StartMailWorker(db)
 
 	// 初始化路由
 	router := gin.Default()
 
-	// 设置代理
-	// router.SetTrustedProxies([]string{"127.0.0.1"})
+	// 设置可信代理，只有来自这些地址的请求才采用 X-Forwarded-For 中的客户端IP，未配置时不信任任何代理
+	var trustedProxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		panic("invalid TRUSTED_PROXIES: " + err.Error())
+	}
 
 	// 设置路由
 	routes.SetupRoutes(router, db)
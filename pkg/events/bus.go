@@ -0,0 +1,145 @@
+// Package events 进程内的事件总线：发布的事件按递增ID保存在固定大小的环形缓冲区中，
+// 订阅者可以从某个事件ID之后继续接收，用于 SSE 的断线续传
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event 一个事件，Data 为事件内容，需可序列化为JSON
+type Event struct {
+	Id        uint64      `json:"id"`
+	Type      string      `json:"type"`
+	ArticleId int         `json:"article_id,omitempty"`
+	UserId    int         `json:"user_id,omitempty"`
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// Filter 订阅过滤条件，返回 true 表示接收该事件，为 nil 表示接收所有事件
+type Filter func(event *Event) bool
+
+// Subscription 一个订阅，事件从 C 中读取。订阅者处理过慢导致缓冲区满时订阅被关闭，C 随之关闭，
+// 订阅者可以用最后收到的事件ID重新订阅
+type Subscription struct {
+	C <-chan Event
+
+	bus    *Bus
+	ch     chan Event
+	filter Filter
+	closed bool // 由 Bus.mu 保护
+}
+
+// Bus 事件总线
+type Bus struct {
+	mu          sync.Mutex
+	lastId      uint64
+	ring        []Event // 最近的事件，ring[head] 为最早的一条
+	head        int
+	count       int
+	subscribers map[*Subscription]struct{}
+	bufferSize  int
+}
+
+// NewBus 创建事件总线，history 为保留的最近事件数，bufferSize 为每个订阅者的待读事件上限
+func NewBus(history int, bufferSize int) *Bus {
+	if history <= 0 {
+		history = 1
+	}
+	if bufferSize <= 0 {
+		bufferSize = 1
+	}
+	return &Bus{
+		// 事件ID从启动时间开始递增，重启前的事件ID都小于新进程的ID，续传时会被识别为已丢失
+		lastId:      uint64(time.Now().UnixMilli()),
+		ring:        make([]Event, history),
+		subscribers: make(map[*Subscription]struct{}),
+		bufferSize:  bufferSize,
+	}
+}
+
+// Publish 发布事件，分配事件ID和时间后返回
+func (b *Bus) Publish(event Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastId++
+	event.Id = b.lastId
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	if b.count < len(b.ring) {
+		b.ring[(b.head+b.count)%len(b.ring)] = event
+		b.count++
+	} else {
+		b.ring[b.head] = event
+		b.head = (b.head + 1) % len(b.ring)
+	}
+
+	for sub := range b.subscribers {
+		if sub.filter != nil && !sub.filter(&event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			b.close(sub)
+		}
+	}
+	return event
+}
+
+// Subscribe 订阅事件。lastId 大于0时先返回缓冲区中该ID之后符合条件的事件；
+// 如果该ID之后的事件已经不在缓冲区中（或ID不属于本进程），complete 为 false，订阅者可能漏掉了事件
+func (b *Bus) Subscribe(lastId uint64, filter Filter) (sub *Subscription, missed []Event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	complete = true
+	if lastId > 0 {
+		oldest := b.lastId - uint64(b.count) + 1
+		if lastId > b.lastId || lastId+1 < oldest {
+			complete = false
+		}
+		for i := 0; i < b.count; i++ {
+			event := b.ring[(b.head+i)%len(b.ring)]
+			if event.Id <= lastId {
+				continue
+			}
+			if filter == nil || filter(&event) {
+				missed = append(missed, event)
+			}
+		}
+	}
+
+	ch := make(chan Event, b.bufferSize)
+	sub = &Subscription{C: ch, bus: b, ch: ch, filter: filter}
+	b.subscribers[sub] = struct{}{}
+	return sub, missed, complete
+}
+
+// Unsubscribe 取消订阅，可以重复调用
+func (s *Subscription) Unsubscribe() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.close(s)
+}
+
+// close 移除订阅并关闭通道，调用方需持有 b.mu
+func (b *Bus) close(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(b.subscribers, sub)
+	close(sub.ch)
+}
+
+// LastId 最近发布的事件ID
+func (b *Bus) LastId() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lastId
+}
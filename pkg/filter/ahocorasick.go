@@ -0,0 +1,129 @@
+package filter
+
+import "unicode"
+
+// Match 一次匹配结果，Start/End 为 rune 下标，区间为 [Start, End)
+type Match struct {
+	Pattern int // 命中的模式下标
+	Start   int
+	End     int
+}
+
+type node struct {
+	next    map[rune]int
+	fail    int
+	outputs []int // 以该节点结尾的模式下标（含失败链上的模式）
+}
+
+// Matcher Aho-Corasick 多模式匹配器
+// 按 rune 匹配并忽略大小写，匹配耗时与文本长度线性相关，与词库大小无关，适合大词库和中文
+type Matcher struct {
+	nodes    []node
+	patterns []string
+	lengths  []int
+}
+
+// NewMatcher 根据模式列表构建匹配器，空模式会被忽略
+func NewMatcher(patterns []string) *Matcher {
+	m := &Matcher{
+		nodes:    []node{{next: make(map[rune]int)}},
+		patterns: patterns,
+		lengths:  make([]int, len(patterns)),
+	}
+
+	// 构建字典树
+	for i, pattern := range patterns {
+		runes := normalize(pattern)
+		if len(runes) == 0 {
+			continue
+		}
+		m.lengths[i] = len(runes)
+		cur := 0
+		for _, r := range runes {
+			child, ok := m.nodes[cur].next[r]
+			if !ok {
+				m.nodes = append(m.nodes, node{next: make(map[rune]int)})
+				child = len(m.nodes) - 1
+				m.nodes[cur].next[r] = child
+			}
+			cur = child
+		}
+		m.nodes[cur].outputs = append(m.nodes[cur].outputs, i)
+	}
+
+	// 广度优先构建失败指针
+	queue := make([]int, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range m.nodes[cur].next {
+			queue = append(queue, child)
+			fail := m.nodes[cur].fail
+			for fail > 0 {
+				if _, ok := m.nodes[fail].next[r]; ok {
+					break
+				}
+				fail = m.nodes[fail].fail
+			}
+			if target, ok := m.nodes[fail].next[r]; ok && target != child {
+				m.nodes[child].fail = target
+			}
+			failOutputs := m.nodes[m.nodes[child].fail].outputs
+			m.nodes[child].outputs = append(m.nodes[child].outputs, failOutputs...)
+		}
+	}
+
+	return m
+}
+
+// Pattern 返回指定下标的原始模式
+func (m *Matcher) Pattern(i int) string {
+	return m.patterns[i]
+}
+
+// FindAll 查找文本中所有命中（允许重叠）
+func (m *Matcher) FindAll(text string) []Match {
+	var matches []Match
+	cur := 0
+	for i, r := range normalize(text) {
+		for cur > 0 {
+			if _, ok := m.nodes[cur].next[r]; ok {
+				break
+			}
+			cur = m.nodes[cur].fail
+		}
+		if next, ok := m.nodes[cur].next[r]; ok {
+			cur = next
+		}
+		for _, p := range m.nodes[cur].outputs {
+			matches = append(matches, Match{Pattern: p, Start: i + 1 - m.lengths[p], End: i + 1})
+		}
+	}
+	return matches
+}
+
+// normalize 转为小写 rune 序列，转换前后 rune 一一对应，保证下标可用于原文
+func normalize(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
+
+// Mask 将命中的区间替换为 *
+func Mask(text string, matches []Match) string {
+	if len(matches) == 0 {
+		return text
+	}
+	runes := []rune(text)
+	for _, match := range matches {
+		for i := match.Start; i < match.End && i < len(runes); i++ {
+			runes[i] = '*'
+		}
+	}
+	return string(runes)
+}
@@ -0,0 +1,82 @@
+package filter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindAll(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		text     string
+		want     []Match
+	}{
+		{
+			name:     "overlapping patterns",
+			patterns: []string{"he", "she", "his", "hers"},
+			text:     "ushers",
+			want:     []Match{{Pattern: 1, Start: 1, End: 4}, {Pattern: 0, Start: 2, End: 4}, {Pattern: 3, Start: 2, End: 6}},
+		},
+		{
+			name:     "case insensitive",
+			patterns: []string{"Spam"},
+			text:     "SPAM and spam",
+			want:     []Match{{Pattern: 0, Start: 0, End: 4}, {Pattern: 0, Start: 9, End: 13}},
+		},
+		{
+			name:     "cjk",
+			patterns: []string{"赌博", "网络赌博", "博彩"},
+			text:     "禁止网络赌博彩票",
+			want:     []Match{{Pattern: 1, Start: 2, End: 6}, {Pattern: 0, Start: 4, End: 6}, {Pattern: 2, Start: 5, End: 7}},
+		},
+		{
+			name:     "repeated pattern",
+			patterns: []string{"aa"},
+			text:     "aaa",
+			want:     []Match{{Pattern: 0, Start: 0, End: 2}, {Pattern: 0, Start: 1, End: 3}},
+		},
+		{
+			name:     "empty pattern ignored",
+			patterns: []string{"", "b"},
+			text:     "abc",
+			want:     []Match{{Pattern: 1, Start: 1, End: 2}},
+		},
+		{
+			name:     "no match",
+			patterns: []string{"违禁"},
+			text:     "正常内容",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := NewMatcher(test.patterns).FindAll(test.text)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("FindAll = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		text     string
+		want     string
+	}{
+		{name: "mixed ascii and cjk", patterns: []string{"敏感", "bad"}, text: "a敏感词b BAD话", want: "a**词b ***话"},
+		{name: "overlapping matches", patterns: []string{"she", "hers"}, text: "ushers!", want: "u*****!"},
+		{name: "emoji kept intact", patterns: []string{"坏"}, text: "😀坏😀", want: "😀*😀"},
+		{name: "no match", patterns: []string{"违禁"}, text: "正常内容", want: "正常内容"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Mask(test.text, NewMatcher(test.patterns).FindAll(test.text)); got != test.want {
+				t.Errorf("Mask = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
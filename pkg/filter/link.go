@@ -0,0 +1,66 @@
+package filter
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// 链接只匹配 ASCII 字符，避免把紧跟在链接后的中文标点和文字当作链接的一部分
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[a-z0-9\-._~:/?#@!$&*+,;=%]+`)
+
+// Link 文本中的一个链接，Start/End 为 rune 下标
+type Link struct {
+	URL   string
+	Host  string
+	Start int
+	End   int
+}
+
+// ExtractLinks 提取文本中的 http(s) 链接以及以 www. 开头的链接
+func ExtractLinks(text string) []Link {
+	var links []Link
+	for _, loc := range linkPattern.FindAllStringIndex(text, -1) {
+		raw := text[loc[0]:loc[1]]
+		links = append(links, Link{
+			URL:   raw,
+			Host:  hostOf(raw),
+			Start: utf8.RuneCountInString(text[:loc[0]]),
+			End:   utf8.RuneCountInString(text[:loc[1]]),
+		})
+	}
+	return links
+}
+
+// hostOf 从链接中取出小写主机名
+func hostOf(raw string) string {
+	host := raw
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.IndexAny(host, "/?#"); i >= 0 {
+		host = host[:i]
+	}
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		host = host[i+1:]
+	}
+	if i := strings.LastIndex(host, ":"); i >= 0 {
+		host = host[:i]
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// MatchDomain 判断主机名是否属于域名列表中的某个域名（含子域名），返回命中的域名
+func MatchDomain(host string, domains map[string]bool) (string, bool) {
+	for host != "" {
+		if domains[host] {
+			return host, true
+		}
+		i := strings.Index(host, ".")
+		if i < 0 {
+			break
+		}
+		host = host[i+1:]
+	}
+	return "", false
+}
@@ -0,0 +1,44 @@
+package filter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractLinks(t *testing.T) {
+	text := "访问https://User@Sub.Example.com:8080/path?q=1，或者 www.test.org。"
+	want := []Link{
+		{URL: "https://User@Sub.Example.com:8080/path?q=1", Host: "sub.example.com", Start: 2, End: 44},
+		{URL: "www.test.org", Host: "www.test.org", Start: 48, End: 60},
+	}
+	if got := ExtractLinks(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractLinks = %+v, want %+v", got, want)
+	}
+	if got := Mask(text, []Match{{Start: want[1].Start, End: want[1].End}}); got != "访问https://User@Sub.Example.com:8080/path?q=1，或者 ************。" {
+		t.Errorf("Mask = %q", got)
+	}
+}
+
+func TestMatchDomain(t *testing.T) {
+	domains := map[string]bool{"example.com": true, "spam.net": true}
+	tests := []struct {
+		host string
+		want string
+		ok   bool
+	}{
+		{host: "example.com", want: "example.com", ok: true},
+		{host: "a.b.example.com", want: "example.com", ok: true},
+		{host: "www.spam.net", want: "spam.net", ok: true},
+		{host: "notexample.com", ok: false},
+		{host: "example.com.cn", ok: false},
+		{host: "com", ok: false},
+		{host: "", ok: false},
+	}
+
+	for _, test := range tests {
+		got, ok := MatchDomain(test.host, domains)
+		if got != test.want || ok != test.ok {
+			t.Errorf("MatchDomain(%q) = %q, %v, want %q, %v", test.host, got, ok, test.want, test.ok)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Package identicon 根据字符串生成确定性的对称像素头像
+package identicon
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+const (
+	grid    = 5 // 5x5 网格，左右对称
+	padding = 1 // 四周留白，单位为格
+)
+
+var background = color.NRGBA{R: 240, G: 240, B: 240, A: 255}
+
+// Image 生成 size×size 的头像，相同的 key 总是得到相同的图案和颜色
+func Image(key string, size int) *image.NRGBA {
+	sum := sha256.Sum256([]byte(key))
+	fg := hslColor(float64(uint16(sum[29])<<8|uint16(sum[30]))/65536*360, 0.45+float64(sum[31]%20)/100, 0.5)
+
+	// 只需要左边 3 列，右边 2 列镜像
+	var cells [grid][grid]bool
+	for row := 0; row < grid; row++ {
+		for col := 0; col < (grid+1)/2; col++ {
+			on := sum[row*3+col]%2 == 0
+			cells[row][col] = on
+			cells[row][grid-1-col] = on
+		}
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	cell := float64(size) / float64(grid+2*padding)
+	for y := 0; y < size; y++ {
+		row := int(float64(y)/cell) - padding
+		for x := 0; x < size; x++ {
+			col := int(float64(x)/cell) - padding
+			if row >= 0 && row < grid && col >= 0 && col < grid && cells[row][col] {
+				img.SetNRGBA(x, y, fg)
+			} else {
+				img.SetNRGBA(x, y, background)
+			}
+		}
+	}
+	return img
+}
+
+// PNG 生成 PNG 编码的头像
+func PNG(key string, size int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, Image(key, size)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// hslColor HSL 转 RGB，h 取值 0~360，s、l 取值 0~1
+func hslColor(h, s, l float64) color.NRGBA {
+	c := (1 - abs(2*l-1)) * s
+	hp := h / 60
+	x := c * (1 - abs(mod2(hp)-1))
+	var r, g, b float64
+	switch {
+	case hp < 1:
+		r, g = c, x
+	case hp < 2:
+		r, g = x, c
+	case hp < 3:
+		g, b = c, x
+	case hp < 4:
+		g, b = x, c
+	case hp < 5:
+		r, b = x, c
+	default:
+		r, b = c, x
+	}
+	m := l - c/2
+	return color.NRGBA{
+		R: uint8((r + m) * 255),
+		G: uint8((g + m) * 255),
+		B: uint8((b + m) * 255),
+		A: 255,
+	}
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// mod2 浮点数对 2 取模
+func mod2(v float64) float64 {
+	for v >= 2 {
+		v -= 2
+	}
+	return v
+}
@@ -0,0 +1,34 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LogMailer 开发环境使用：Dir 不为空时将邮件保存为 .eml 文件，否则输出到日志
+type LogMailer struct {
+	Dir string
+}
+
+func (m *LogMailer) Send(_ context.Context, message *Message) error {
+	data, err := message.Bytes()
+	if err != nil {
+		return err
+	}
+
+	if m.Dir == "" {
+		log.Printf("mail to %s: %s\n%s", strings.Join(message.To, ", "), message.Subject, message.Text)
+		return nil
+	}
+
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405.000"), randomHex(4))
+	return os.WriteFile(filepath.Join(m.Dir, name), data, 0o644)
+}
@@ -0,0 +1,51 @@
+// Package mailer 发送邮件：SMTP 实现用于生产环境，日志实现将邮件写入目录或日志，用于开发环境
+package mailer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+)
+
+var ErrNoRecipient = errors.New("mail has no recipient")
+
+// Message 一封邮件，Text 和 HTML 至少一个不为空，同时存在时以 multipart/alternative 发送
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	Send(ctx context.Context, message *Message) error
+}
+
+// NewFromEnv 根据环境变量创建 Mailer：MAIL_DRIVER=smtp 时使用 SMTP_* 配置，否则使用日志实现（MAIL_LOG_DIR）
+func NewFromEnv() Mailer {
+	if os.Getenv("MAIL_DRIVER") == "smtp" {
+		port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+		if err != nil || port <= 0 {
+			port = 587
+		}
+		return &SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			TLS:      os.Getenv("SMTP_TLS"),
+		}
+	}
+	return &LogMailer{Dir: os.Getenv("MAIL_LOG_DIR")}
+}
+
+// DefaultFrom 默认发件人，可通过 MAIL_FROM 配置
+func DefaultFrom() string {
+	if from := os.Getenv("MAIL_FROM"); from != "" {
+		return from
+	}
+	return "no-reply@localhost"
+}
@@ -0,0 +1,99 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Bytes 按 RFC 5322 编码邮件，正文使用 quoted-printable，标题按 RFC 2047 编码
+func (m *Message) Bytes() ([]byte, error) {
+	if len(m.To) == 0 {
+		return nil, ErrNoRecipient
+	}
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender: %w", err)
+	}
+	to := make([]string, 0, len(m.To))
+	for _, address := range m.To {
+		parsed, err := mail.ParseAddress(address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient: %w", err)
+		}
+		to = append(to, parsed.String())
+	}
+
+	var buf bytes.Buffer
+	writeHeader := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	writeHeader("From", from.String())
+	writeHeader("To", strings.Join(to, ", "))
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	writeHeader("Date", time.Now().Format(time.RFC1123Z))
+	writeHeader("Message-ID", messageId(from.Address))
+	writeHeader("MIME-Version", "1.0")
+
+	switch {
+	case m.Text != "" && m.HTML != "":
+		boundary := randomHex(12)
+		writeHeader("Content-Type", `multipart/alternative; boundary="`+boundary+`"`)
+		buf.WriteString("\r\n")
+		for _, part := range []struct{ contentType, body string }{
+			{"text/plain", m.Text},
+			{"text/html", m.HTML},
+		} {
+			fmt.Fprintf(&buf, "--%s\r\n", boundary)
+			if err := writePart(&buf, part.contentType, part.body); err != nil {
+				return nil, err
+			}
+		}
+		fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+	case m.HTML != "":
+		if err := writePart(&buf, "text/html", m.HTML); err != nil {
+			return nil, err
+		}
+	default:
+		if err := writePart(&buf, "text/plain", m.Text); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// writePart 写入一个 quoted-printable 编码的正文部分（含头部）
+func writePart(buf *bytes.Buffer, contentType string, body string) error {
+	fmt.Fprintf(buf, "Content-Type: %s; charset=utf-8\r\n", contentType)
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	writer := quotedprintable.NewWriter(buf)
+	if _, err := writer.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	buf.WriteString("\r\n")
+	return nil
+}
+
+// messageId 生成 Message-ID，域名取发件人地址的域名
+func messageId(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), randomHex(8), domain)
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
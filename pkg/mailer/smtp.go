@@ -0,0 +1,111 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// TLS 模式
+const (
+	TLSStartTLS = "starttls" // 服务器支持时升级为 TLS（默认）
+	TLSImplicit = "tls"      // 直接建立 TLS 连接，通常为 465 端口
+	TLSNone     = "none"     // 不使用 TLS，只应用于本地测试
+)
+
+const smtpTimeout = 30 * time.Second
+
+// SMTPMailer 通过 SMTP 发送邮件
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string // 为空时不认证
+	Password string
+	TLS      string // starttls、tls 或 none，为空时为 starttls
+}
+
+// Send 发送邮件，ctx 的截止时间同时作为连接的超时时间
+func (m *SMTPMailer) Send(ctx context.Context, message *Message) error {
+	data, err := message.Bytes()
+	if err != nil {
+		return err
+	}
+	if m.Host == "" {
+		return errors.New("smtp host is not configured")
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	address := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	tlsConfig := &tls.Config{ServerName: m.Host}
+
+	dialer := &net.Dialer{Deadline: deadline}
+	var conn net.Conn
+	if m.TLS == TLSImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, m.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if m.TLS == "" || m.TLS == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		}
+	}
+	if m.Username != "" {
+		// PlainAuth 拒绝在未加密的连接上发送密码（localhost 除外）
+		if err := client.Auth(smtp.PlainAuth("", m.Username, m.Password, m.Host)); err != nil {
+			return err
+		}
+	}
+
+	from, err := mail.ParseAddress(message.From)
+	if err != nil {
+		return err
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, to := range message.To {
+		recipient, err := mail.ParseAddress(to)
+		if err != nil {
+			return err
+		}
+		if err := client.Rcpt(recipient.Address); err != nil {
+			return fmt.Errorf("recipient %s rejected: %w", recipient.Address, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,226 @@
+package mailer
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpEnvelope 测试 SMTP 服务收到的一封邮件
+type smtpEnvelope struct {
+	Auth string // AUTH PLAIN 解码后的 "\x00用户名\x00密码"
+	From string
+	To   []string
+	Data string
+}
+
+// smtpStub 进程内的最小 SMTP 服务，只实现 Send 使用到的命令，用于测试
+type smtpStub struct {
+	listener net.Listener
+	reject   map[string]bool // 拒绝的收件人
+
+	mu        sync.Mutex
+	envelopes []smtpEnvelope
+}
+
+func newSMTPStub(t *testing.T, reject ...string) *smtpStub {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &smtpStub{listener: listener, reject: make(map[string]bool)}
+	for _, address := range reject {
+		stub.reject[address] = true
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go stub.serve(conn)
+		}
+	}()
+	return stub
+}
+
+// mailer 连接到测试服务的 SMTPMailer
+func (s *smtpStub) mailer() *SMTPMailer {
+	return &SMTPMailer{Host: "127.0.0.1", Port: s.listener.Addr().(*net.TCPAddr).Port, Username: "user", Password: "secret", TLS: TLSNone}
+}
+
+func (s *smtpStub) received() []smtpEnvelope {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]smtpEnvelope(nil), s.envelopes...)
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	reply := func(code int, message string) {
+		text.PrintfLine("%d %s", code, message)
+	}
+
+	var envelope smtpEnvelope
+	reply(220, "stub ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command, argument, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(command) {
+		case "EHLO", "HELO":
+			text.PrintfLine("250-stub")
+			text.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			mechanism, initial, _ := strings.Cut(argument, " ")
+			decoded, err := base64.StdEncoding.DecodeString(initial)
+			if mechanism != "PLAIN" || err != nil {
+				reply(504, "unsupported")
+				continue
+			}
+			envelope.Auth = string(decoded)
+			reply(235, "authenticated")
+		case "MAIL":
+			envelope.From = strings.Trim(strings.TrimPrefix(argument, "FROM:"), "<>")
+			reply(250, "ok")
+		case "RCPT":
+			to := strings.Trim(strings.TrimPrefix(argument, "TO:"), "<>")
+			if s.reject[to] {
+				reply(550, "no such user")
+				continue
+			}
+			envelope.To = append(envelope.To, to)
+			reply(250, "ok")
+		case "DATA":
+			reply(354, "go ahead")
+			data, err := io.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+			envelope.Data = string(data)
+			s.mu.Lock()
+			s.envelopes = append(s.envelopes, envelope)
+			s.mu.Unlock()
+			envelope = smtpEnvelope{}
+			reply(250, "queued")
+		case "RSET":
+			envelope = smtpEnvelope{}
+			reply(250, "ok")
+		case "QUIT":
+			reply(221, "bye")
+			return
+		default:
+			reply(502, "not implemented")
+		}
+	}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	stub := newSMTPStub(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := stub.mailer().Send(ctx, &Message{
+		From:    "Blog <no-reply@example.com>",
+		To:      []string{"Alice <alice@example.com>"},
+		Subject: "欢迎注册",
+		Text:    "你好，Alice\n请验证邮箱",
+		HTML:    "<p>你好，Alice</p>",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	envelopes := stub.received()
+	if len(envelopes) != 1 {
+		t.Fatalf("received %d emails, want 1", len(envelopes))
+	}
+	envelope := envelopes[0]
+	if envelope.Auth != "\x00user\x00secret" {
+		t.Errorf("auth = %q", envelope.Auth)
+	}
+	if envelope.From != "no-reply@example.com" || len(envelope.To) != 1 || envelope.To[0] != "alice@example.com" {
+		t.Errorf("envelope from %q to %v", envelope.From, envelope.To)
+	}
+
+	message, err := mail.ReadMessage(strings.NewReader(envelope.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	if err != nil || subject != "欢迎注册" {
+		t.Errorf("subject = %q, %v", subject, err)
+	}
+
+	// multipart/alternative，纯文本和 HTML 各一部分
+	_, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	var parts []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		// multipart.Reader 会自动解码 quoted-printable
+		body, _ := io.ReadAll(part)
+		parts = append(parts, fmt.Sprintf("%s|%s", part.Header.Get("Content-Type"), body))
+	}
+	// DotReader 将换行统一为 \n，每部分末尾的换行属于分隔符
+	want := []string{
+		"text/plain; charset=utf-8|你好，Alice\n请验证邮箱",
+		"text/html; charset=utf-8|<p>你好，Alice</p>",
+	}
+	if strings.Join(parts, "\n") != strings.Join(want, "\n") {
+		t.Errorf("parts = %q, want %q", parts, want)
+	}
+}
+
+func TestSMTPMailerRejectedRecipient(t *testing.T) {
+	stub := newSMTPStub(t, "nobody@example.com")
+
+	err := stub.mailer().Send(context.Background(), &Message{
+		From: "no-reply@example.com",
+		To:   []string{"nobody@example.com"},
+		Text: "hello",
+	})
+	if err == nil || !strings.Contains(err.Error(), "nobody@example.com rejected") {
+		t.Fatalf("Send error = %v, want recipient rejected", err)
+	}
+	if len(stub.received()) != 0 {
+		t.Error("email delivered to rejected recipient")
+	}
+}
+
+func TestSMTPMailerConnectionRefused(t *testing.T) {
+	stub := newSMTPStub(t)
+	smtpMailer := stub.mailer()
+	stub.listener.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := smtpMailer.Send(ctx, &Message{From: "no-reply@example.com", To: []string{"alice@example.com"}, Text: "hello"}); err == nil {
+		t.Fatal("Send succeeded without a server")
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"server/pkg/rbac"
 	"server/pkg/response"
 	"server/pkg/utils"
 	"strings"
@@ -26,6 +27,13 @@ func validateToken(c *gin.Context) (*utils.JwtClaims, bool) {
 		return nil, false
 	}
 
+	// 个人访问令牌只能用于允许的接口
+	if utils.IsAccessToken(parts[1]) {
+		c.JSON(403, response.Error(response.StatusForbidden, "Personal access tokens are not allowed for this endpoint"))
+		c.Abort()
+		return nil, false
+	}
+
 	// 解析并验证Token
 	claims, err := utils.ParseToken(parts[1])
 	if err != nil {
@@ -37,15 +45,145 @@ func validateToken(c *gin.Context) (*utils.JwtClaims, bool) {
 	return claims, true
 }
 
-func AuthMiddleware() gin.HandlerFunc {
+// TokenChecker 对已通过签名校验的令牌做额外检查（如是否已注销），返回错误时令牌无效
+type TokenChecker func(claims *utils.JwtClaims) error
+
+// checkToken 依次执行检查
+func checkToken(claims *utils.JwtClaims, checkers []TokenChecker) error {
+	for _, checker := range checkers {
+		if err := checker(claims); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func AuthMiddleware(checkers ...TokenChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		claims, ok := validateToken(c)
 		if !ok {
 			return
 		}
+		if err := checkToken(claims, checkers); err != nil {
+			c.JSON(401, response.Error(response.StatusUnauthorized, "Invalid token"))
+			c.Abort()
+			return
+		}
 
-		// 将用户ID存入上下文
+		// 将用户ID和令牌信息存入上下文
 		c.Set("user_id", claims.UserId)
+		c.Set("claims", claims)
+		c.Next()
+	}
+}
+
+// OptionalAuthMiddleware 可选认证：携带有效token时将用户ID存入上下文，否则按匿名用户继续处理
+func OptionalAuthMiddleware(checkers ...TokenChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			if claims, err := utils.ParseToken(parts[1]); err == nil && checkToken(claims, checkers) == nil {
+				c.Set("user_id", claims.UserId)
+			}
+		}
+		c.Next()
+	}
+}
+
+// AccessTokenChecker 校验个人访问令牌，返回令牌所属的用户ID和权限范围
+type AccessTokenChecker func(token string, clientIP string) (int, []string, error)
+
+// bearerToken 取出 Authorization 中的 Bearer Token
+func bearerToken(c *gin.Context) (string, bool) {
+	parts := strings.Split(c.GetHeader("Authorization"), " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// hasScope 令牌是否拥有指定的权限范围，scope 为空时不限制
+func hasScope(scopes []string, scope string) bool {
+	if scope == "" {
+		return true
+	}
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AccessTokenAuthMiddleware 与 AuthMiddleware 相同，另外接受拥有 scope 权限范围的个人访问令牌
+func AccessTokenAuthMiddleware(scope string, checkAccessToken AccessTokenChecker, checkers ...TokenChecker) gin.HandlerFunc {
+	auth := AuthMiddleware(checkers...)
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c)
+		if !ok || !utils.IsAccessToken(token) {
+			auth(c)
+			return
+		}
+
+		userId, scopes, err := checkAccessToken(token, GetClientIP(c))
+		if err != nil {
+			c.JSON(401, response.Error(response.StatusUnauthorized, "Invalid token"))
+			c.Abort()
+			return
+		}
+		if !hasScope(scopes, scope) {
+			c.JSON(403, response.Error(response.StatusForbidden, "Token does not have the required scope: "+scope))
+			c.Abort()
+			return
+		}
+
+		// 使用个人访问令牌时上下文中没有 claims
+		c.Set("user_id", userId)
 		c.Next()
 	}
 }
+
+// OptionalAccessTokenAuthMiddleware 与 OptionalAuthMiddleware 相同，另外接受拥有 scope 权限范围的个人访问令牌
+func OptionalAccessTokenAuthMiddleware(scope string, checkAccessToken AccessTokenChecker, checkers ...TokenChecker) gin.HandlerFunc {
+	optionalAuth := OptionalAuthMiddleware(checkers...)
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c)
+		if !ok || !utils.IsAccessToken(token) {
+			optionalAuth(c)
+			return
+		}
+
+		if userId, scopes, err := checkAccessToken(token, GetClientIP(c)); err == nil && hasScope(scopes, scope) {
+			c.Set("user_id", userId)
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission 检查令牌中的角色是否拥有指定权限，需放在 AuthMiddleware 之后
+func RequirePermission(permission rbac.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := GetClaims(c)
+		if claims == nil {
+			c.JSON(401, response.Error(response.StatusUnauthorized, "Authorization header is required"))
+			c.Abort()
+			return
+		}
+		if !rbac.Has(claims.Role, permission) {
+			c.JSON(403, response.Error(response.StatusForbidden, "Permission denied"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetClaims 获取 AuthMiddleware 解析出的令牌信息
+func GetClaims(c *gin.Context) *utils.JwtClaims {
+	if value, ok := c.Get("claims"); ok {
+		if claims, ok := value.(*utils.JwtClaims); ok {
+			return claims
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAccessTokenScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tokens := map[string][]string{
+		"blogpat_reader":   {"profile:read"},
+		"blogpat_articles": {"articles:read", "articles:write"},
+	}
+	checkAccessToken := func(token string, clientIP string) (int, []string, error) {
+		scopes, ok := tokens[token]
+		if !ok {
+			return 0, nil, errors.New("invalid token")
+		}
+		return 7, scopes, nil
+	}
+
+	router := gin.New()
+	router.GET("/me", AccessTokenAuthMiddleware("profile:read", checkAccessToken), func(c *gin.Context) {
+		c.String(200, "%d", c.GetInt("user_id"))
+	})
+
+	tests := []struct {
+		token string
+		code  int
+	}{
+		{token: "blogpat_reader", code: 200},
+		{token: "blogpat_articles", code: 403},
+		{token: "blogpat_unknown", code: 401},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest("GET", "/me", nil)
+		request.Header.Set("Authorization", "Bearer "+test.token)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != test.code {
+			t.Errorf("%s: status %d, want %d", test.token, recorder.Code, test.code)
+		}
+		if test.code == 200 && recorder.Body.String() != "7" {
+			t.Errorf("%s: user id %q", test.token, recorder.Body.String())
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"net/http"
 	"os"
 	"strings"
 
@@ -13,13 +14,7 @@ import (
 func CORS() gin.HandlerFunc {
 	_ = godotenv.Load() // 加载 .env 文件，忽略错误
 
-	origins := os.Getenv("CORS_ALLOW_ORIGINS")
-	var allowOrigins []string
-	if origins == "" || origins == "*" {
-		allowOrigins = []string{"*"}
-	} else {
-		allowOrigins = strings.Split(origins, ",")
-	}
+	allowOrigins := allowedOrigins()
 
 	config := cors.DefaultConfig()
 	if len(allowOrigins) == 1 && allowOrigins[0] == "*" {
@@ -28,9 +23,33 @@ func CORS() gin.HandlerFunc {
 		config.AllowOrigins = allowOrigins
 	}
 	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "HEAD"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "Accept", "Cache-Control"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "Accept", "Cache-Control", "X-Article-Token", "Last-Event-ID"}
 	config.AllowCredentials = true
 	config.ExposeHeaders = []string{"Content-Length"}
 
 	return cors.New(config)
 }
+
+// allowedOrigins 读取 CORS_ALLOW_ORIGINS，未配置时允许所有来源
+func allowedOrigins() []string {
+	origins := os.Getenv("CORS_ALLOW_ORIGINS")
+	if origins == "" || origins == "*" {
+		return []string{"*"}
+	}
+	return strings.Split(origins, ",")
+}
+
+// CheckOrigin 按 CORS_ALLOW_ORIGINS 检查 WebSocket 握手请求的来源，
+// 浏览器不对 WebSocket 执行跨域检查，需要服务端自行校验
+func CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range allowedOrigins() {
+		if allowed == "*" || strings.TrimSpace(allowed) == origin {
+			return true
+		}
+	}
+	return false
+}
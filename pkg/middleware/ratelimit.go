@@ -1,7 +1,6 @@
 package middleware
 
 import (
-	"net"
 	"net/http"
 	"server/pkg/response"
 	"sync"
@@ -105,63 +104,42 @@ func (ipl *IPRateLimiter) recordViolation(ip string) {
 	}
 }
 
-// getClientIP 获取客户端真实IP
-func getClientIP(c *gin.Context) string {
-	// 检查代理头
-	if ip := c.GetHeader("X-Forwarded-For"); ip != "" {
-		// X-Forwarded-For 可能包含多个IP，取第一个
-		if i := len(ip); i > 0 {
-			if idx := 0; idx < i {
-				for idx < i && ip[idx] == ' ' {
-					idx++
-				}
-				for end := idx; end < i && ip[end] != ',' && ip[end] != ' '; end++ {
-					if end > idx {
-						return ip[idx:end]
-					}
-				}
-			}
-		}
-		return ip
-	}
-	
-	if ip := c.GetHeader("X-Real-IP"); ip != "" {
-		return ip
-	}
-	
-	// 获取远程地址
-	if ip, _, err := net.SplitHostPort(c.Request.RemoteAddr); err == nil {
-		return ip
-	}
-	
-	return c.Request.RemoteAddr
+// GetClientIP 获取客户端真实IP。只有来自可信代理（router.SetTrustedProxies）的请求才采用
+// X-Forwarded-For / X-Real-IP，否则使用连接的远程地址，防止客户端伪造请求头绕过限流
+func GetClientIP(c *gin.Context) string {
+	return c.ClientIP()
 }
 
 // RateLimitMiddleware 限流中间件
 func (ipl *IPRateLimiter) RateLimitMiddleware() gin.HandlerFunc {
+	return ipl.RateLimitByKey(GetClientIP)
+}
+
+// RateLimitByKey 按自定义键限流的中间件，例如按“资源ID + 客户端IP”限制敏感操作的尝试次数
+func (ipl *IPRateLimiter) RateLimitByKey(keyFunc func(c *gin.Context) string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ip := getClientIP(c)
-		
-		// 检查IP是否被封禁
-		if ipl.isIPBanned(ip) {
+		key := keyFunc(c)
+
+		// 检查是否被封禁
+		if ipl.isIPBanned(key) {
 			c.JSON(http.StatusTooManyRequests, response.Error(response.StatusTooManyRequests, "IP temporarily banned due to too many violations"))
 			c.Abort()
 			return
 		}
-		
-		// 获取该IP的限流器
-		limiter := ipl.getLimiter(ip)
-		
+
+		// 获取该键对应的限流器
+		limiter := ipl.getLimiter(key)
+
 		// 检查是否允许请求
 		if !limiter.Allow() {
 			// 记录违规
-			ipl.recordViolation(ip)
-			
+			ipl.recordViolation(key)
+
 			c.JSON(http.StatusTooManyRequests, response.Error(response.StatusTooManyRequests, "Rate limit exceeded"))
 			c.Abort()
 			return
 		}
-		
+
 		c.Next()
 	}
 }
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetClientIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name       string
+		trusted    []string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{
+			name:       "no proxy",
+			remoteAddr: "203.0.113.7:52100",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "spoofed header without trusted proxy",
+			remoteAddr: "203.0.113.7:52100",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "spoofed X-Real-IP without trusted proxy",
+			remoteAddr: "203.0.113.7:52100",
+			headers:    map[string]string{"X-Real-IP": "198.51.100.1"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "trusted proxy",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.2:40000",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.7"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "trusted proxy chain",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.2:40000",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.7, 10.0.0.1"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "client-supplied entry before real client",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.2:40000",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.7"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "ipv6 client",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.2:40000",
+			headers:    map[string]string{"X-Forwarded-For": "2001:db8::7"},
+			want:       "2001:db8::7",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			router := gin.New()
+			if err := router.SetTrustedProxies(test.trusted); err != nil {
+				t.Fatal(err)
+			}
+			router.GET("/", func(c *gin.Context) {
+				c.String(200, GetClientIP(c))
+			})
+
+			request := httptest.NewRequest("GET", "/", nil)
+			request.RemoteAddr = test.remoteAddr
+			for key, value := range test.headers {
+				request.Header.Set(key, value)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			if got := recorder.Body.String(); got != test.want {
+				t.Errorf("GetClientIP() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"errors"
+	"server/pkg/utils"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebSocket 连接票据的有效期，票据只能使用一次
+const webSocketTicketTTL = 30 * time.Second
+
+var ErrMissingBearerToken = errors.New("bearer token is required")
+
+type webSocketTicket struct {
+	token     string // 换取票据时使用的登录令牌
+	path      string // 票据只能用于该路径的连接
+	expiresAt time.Time
+}
+
+// 已签发未使用的 WebSocket 票据。协同编辑会话本身保存在进程内存中，票据同样只在本进程有效
+var webSocketTickets = struct {
+	sync.Mutex
+	tickets map[string]webSocketTicket
+}{tickets: make(map[string]webSocketTicket)}
+
+// IssueWebSocketTicket 为当前请求的登录令牌签发只能用于 path 的短期票据。
+// 浏览器的 WebSocket API 无法设置请求头，使用票据代替把登录令牌放在 URL 中（URL 会被写入访问日志和代理日志）
+func IssueWebSocketTicket(c *gin.Context, path string) (string, time.Time, error) {
+	token, ok := bearerToken(c)
+	if !ok {
+		return "", time.Time{}, ErrMissingBearerToken
+	}
+	ticket, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(webSocketTicketTTL)
+
+	webSocketTickets.Lock()
+	defer webSocketTickets.Unlock()
+	// 顺便清理过期的票据
+	for key, t := range webSocketTickets.tickets {
+		if now.After(t.expiresAt) {
+			delete(webSocketTickets.tickets, key)
+		}
+	}
+	webSocketTickets.tickets[ticket] = webSocketTicket{token: token, path: path, expiresAt: expiresAt}
+	return ticket, expiresAt, nil
+}
+
+// redeemWebSocketTicket 使用票据换回登录令牌，票据使用后立即删除
+func redeemWebSocketTicket(ticket string, path string) (string, bool) {
+	webSocketTickets.Lock()
+	t, ok := webSocketTickets.tickets[ticket]
+	delete(webSocketTickets.tickets, ticket)
+	webSocketTickets.Unlock()
+
+	if !ok || t.path != path || time.Now().After(t.expiresAt) {
+		return "", false
+	}
+	return t.token, true
+}
+
+// WebSocketTicketMiddleware 未携带 Authorization 时使用查询参数 ticket 换回登录令牌作为 Bearer Token，
+// 需放在 AuthMiddleware 之前；票据无效时由 AuthMiddleware 返回 401
+func WebSocketTicketMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if ticket := c.Query("ticket"); ticket != "" {
+				if token, ok := redeemWebSocketTicket(ticket, c.Request.URL.Path); ok {
+					c.Request.Header.Set("Authorization", "Bearer "+token)
+				}
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,174 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 找不到 kid 时重新拉取 JWKS 的最小间隔，防止伪造的 kid 导致频繁请求
+const jwksRefreshInterval = time.Minute
+
+// JSONWebKey JWKS 中的一个公钥
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// PublicKey 转换为 crypto 公钥
+func (k *JSONWebKey) PublicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("EC point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(buf) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(buf), nil
+}
+
+// keySet 缓存身份提供方的公钥，遇到未知 kid 时重新拉取（密钥轮换）
+type keySet struct {
+	uri    string
+	client *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]interface{}
+	anonymous []interface{} // 没有 kid 的公钥
+	fetchedAt time.Time
+}
+
+func newKeySet(uri string, client *http.Client) *keySet {
+	return &keySet{uri: uri, client: client}
+}
+
+// refresh 拉取 JWKS，跳过不支持或用途不是签名的公钥
+func (s *keySet) refresh(ctx context.Context) error {
+	var jwks struct {
+		Keys []JSONWebKey `json:"keys"`
+	}
+	if err := getJSON(ctx, s.client, s.uri, &jwks); err != nil {
+		return err
+	}
+
+	keys := make(map[string]interface{})
+	var anonymous []interface{}
+	for i := range jwks.Keys {
+		key := &jwks.Keys[i]
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		publicKey, err := key.PublicKey()
+		if err != nil {
+			continue
+		}
+		if key.Kid == "" {
+			anonymous = append(anonymous, publicKey)
+		} else {
+			keys[key.Kid] = publicKey
+		}
+	}
+	s.keys = keys
+	s.anonymous = anonymous
+	s.fetchedAt = time.Now()
+	return nil
+}
+
+// lookup 按 kid 查找公钥；令牌没有 kid 时返回所有没有 kid 的公钥
+func (s *keySet) lookup(ctx context.Context, kid string) ([]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	find := func() []interface{} {
+		if kid == "" {
+			if len(s.anonymous) > 0 {
+				return s.anonymous
+			}
+			// 只有一个公钥时允许令牌省略 kid
+			if len(s.keys) == 1 {
+				for _, key := range s.keys {
+					return []interface{}{key}
+				}
+			}
+			return nil
+		}
+		if key, ok := s.keys[kid]; ok {
+			return []interface{}{key}
+		}
+		return nil
+	}
+
+	if s.keys != nil {
+		if keys := find(); keys != nil {
+			return keys, nil
+		}
+		if time.Since(s.fetchedAt) < jwksRefreshInterval {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+	}
+	if err := s.refresh(ctx); err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	if keys := find(); keys != nil {
+		return keys, nil
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
@@ -0,0 +1,182 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/url"
+	"server/pkg/oidc"
+	"server/pkg/oidc/oidctest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func discover(t *testing.T, idp *oidctest.Provider) *oidc.Provider {
+	t.Helper()
+	provider, err := oidc.Discover(context.Background(), oidc.Config{
+		Issuer:       idp.Server.URL,
+		ClientId:     idp.ClientId,
+		ClientSecret: idp.ClientSecret,
+		RedirectURL:  "https://blog.example.com/oauth/callback/test",
+	}, nil)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	return provider
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// RFC 7636 附录 B 的示例
+	if got := oidc.CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"); got != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Errorf("CodeChallenge = %q", got)
+	}
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	idp := oidctest.NewProvider(t)
+	provider := discover(t, idp)
+	ctx := context.Background()
+
+	verifier, _ := oidc.RandomString()
+	authURL := provider.AuthCodeURL("state-1", "nonce-1", oidc.CodeChallenge(verifier))
+	parsed, err := url.Parse(authURL)
+	if err != nil || !strings.HasPrefix(authURL, idp.Server.URL+"/authorize?") {
+		t.Fatalf("AuthCodeURL = %q", authURL)
+	}
+	if scope := parsed.Query().Get("scope"); scope != "openid email profile" {
+		t.Errorf("scope = %q", scope)
+	}
+
+	code, state, err := idp.Authorize(authURL)
+	if err != nil || state != "state-1" {
+		t.Fatalf("Authorize: %q, %v", state, err)
+	}
+	token, err := provider.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	idToken, err := provider.VerifyIdToken(ctx, token.IdToken, "nonce-1")
+	if err != nil {
+		t.Fatalf("VerifyIdToken: %v", err)
+	}
+	if idToken.Subject != "alice" || idToken.Email != "alice@example.com" || !idToken.Verified() {
+		t.Errorf("id token = %+v", idToken)
+	}
+
+	// 授权码只能使用一次
+	if _, err := provider.Exchange(ctx, code, verifier); !errors.Is(err, oidc.ErrTokenExchange) {
+		t.Errorf("second Exchange: %v, want ErrTokenExchange", err)
+	}
+}
+
+func TestExchangeWrongCodeVerifier(t *testing.T) {
+	idp := oidctest.NewProvider(t)
+	provider := discover(t, idp)
+
+	verifier, _ := oidc.RandomString()
+	code, _, err := idp.Authorize(provider.AuthCodeURL("state", "nonce", oidc.CodeChallenge(verifier)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := oidc.RandomString()
+	_, err = provider.Exchange(context.Background(), code, other)
+	if !errors.Is(err, oidc.ErrTokenExchange) || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Fatalf("Exchange: %v, want invalid_grant", err)
+	}
+}
+
+func TestDiscoverIssuerMismatch(t *testing.T) {
+	idp := oidctest.NewProvider(t)
+	idp.Issuer = "https://evil.example.com"
+
+	_, err := oidc.Discover(context.Background(), oidc.Config{Issuer: idp.Server.URL, ClientId: idp.ClientId}, nil)
+	if !errors.Is(err, oidc.ErrDiscovery) {
+		t.Fatalf("Discover: %v, want ErrDiscovery", err)
+	}
+}
+
+func TestVerifyIdTokenRejects(t *testing.T) {
+	idp := oidctest.NewProvider(t)
+	provider := discover(t, idp)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token func(claims jwt.MapClaims) string
+		nonce string
+	}{
+		{"bad issuer", func(c jwt.MapClaims) string { c["iss"] = "https://evil.example.com"; return idp.Sign(c) }, "nonce"},
+		{"bad audience", func(c jwt.MapClaims) string { c["aud"] = "another-client"; return idp.Sign(c) }, "nonce"},
+		{"azp mismatch", func(c jwt.MapClaims) string {
+			c["aud"] = []string{idp.ClientId, "another-client"}
+			c["azp"] = "another-client"
+			return idp.Sign(c)
+		}, "nonce"},
+		{"expired", func(c jwt.MapClaims) string {
+			c["iat"] = time.Now().Add(-time.Hour).Unix()
+			c["exp"] = time.Now().Add(-10 * time.Minute).Unix()
+			return idp.Sign(c)
+		}, "nonce"},
+		{"missing exp", func(c jwt.MapClaims) string { delete(c, "exp"); return idp.Sign(c) }, "nonce"},
+		{"issued in the future", func(c jwt.MapClaims) string { c["iat"] = time.Now().Add(time.Hour).Unix(); return idp.Sign(c) }, "nonce"},
+		{"wrong nonce", func(c jwt.MapClaims) string { return idp.Sign(c) }, "another-nonce"},
+		{"empty nonce", func(c jwt.MapClaims) string { c["nonce"] = ""; return idp.Sign(c) }, ""},
+		{"missing sub", func(c jwt.MapClaims) string { delete(c, "sub"); return idp.Sign(c) }, "nonce"},
+		{"unknown kid", func(c jwt.MapClaims) string { return oidctest.SignWith(c, otherKey, "rotated-key") }, "nonce"},
+		{"wrong key with known kid", func(c jwt.MapClaims) string { return oidctest.SignWith(c, otherKey, idp.KeyId) }, "nonce"},
+		{"alg none", func(c jwt.MapClaims) string {
+			signed, _ := jwt.NewWithClaims(jwt.SigningMethodNone, c).SignedString(jwt.UnsafeAllowNoneSignatureType)
+			return signed
+		}, "nonce"},
+		{"hmac", func(c jwt.MapClaims) string {
+			signed, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString([]byte(idp.ClientSecret))
+			return signed
+		}, "nonce"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw := test.token(idp.IdTokenClaims("alice", "nonce"))
+			if _, err := provider.VerifyIdToken(context.Background(), raw, test.nonce); !errors.Is(err, oidc.ErrInvalidIdToken) {
+				t.Fatalf("VerifyIdToken: %v, want ErrInvalidIdToken", err)
+			}
+		})
+	}
+
+	// 有效的令牌可以通过，确认上面的失败不是由于配置错误
+	if _, err := provider.VerifyIdToken(context.Background(), idp.Sign(idp.IdTokenClaims("alice", "nonce")), "nonce"); err != nil {
+		t.Fatalf("valid token: %v", err)
+	}
+}
+
+func TestUnknownKidRefreshLimited(t *testing.T) {
+	idp := oidctest.NewProvider(t)
+	provider := discover(t, idp)
+	ctx := context.Background()
+
+	if _, err := provider.VerifyIdToken(ctx, idp.Sign(idp.IdTokenClaims("alice", "nonce")), "nonce"); err != nil {
+		t.Fatal(err)
+	}
+	if n := idp.JWKSRequests(); n != 1 {
+		t.Fatalf("JWKS requests = %d, want 1", n)
+	}
+
+	// 刚拉取过 JWKS 时，未知 kid 不会触发重新拉取
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	for i := 0; i < 3; i++ {
+		raw := oidctest.SignWith(idp.IdTokenClaims("alice", "nonce"), otherKey, "forged-kid")
+		if _, err := provider.VerifyIdToken(ctx, raw, "nonce"); !errors.Is(err, oidc.ErrInvalidIdToken) {
+			t.Fatalf("forged kid accepted: %v", err)
+		}
+	}
+	if n := idp.JWKSRequests(); n != 1 {
+		t.Errorf("JWKS requests = %d, want 1", n)
+	}
+}
@@ -0,0 +1,226 @@
+// Package oidctest 用于测试的模拟身份提供方：发现文档、JWKS 和令牌端点（校验客户端密钥和 PKCE），
+// ID Token 使用测试生成的 RSA 密钥签名
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"server/pkg/oidc"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Provider 模拟的身份提供方
+type Provider struct {
+	Server       *httptest.Server
+	Issuer       string // 发现文档和 ID Token 中的 iss，默认为服务地址
+	ClientId     string
+	ClientSecret string
+	Key          *rsa.PrivateKey
+	KeyId        string
+	// Claims 令牌端点签发 ID Token 前修改声明，用于构造各种无效的令牌
+	Claims func(claims jwt.MapClaims)
+
+	mu           sync.Mutex
+	grants       map[string]grant
+	jwksRequests int
+}
+
+// grant 用户在授权端点同意后签发的授权码
+type grant struct {
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+}
+
+// NewProvider 启动模拟身份提供方，测试结束时关闭
+func NewProvider(t testing.TB) *Provider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &Provider{
+		ClientId:     "blog",
+		ClientSecret: "client-secret",
+		Key:          key,
+		KeyId:        "test-key",
+		grants:       make(map[string]grant),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/jwks", p.jwks)
+	mux.HandleFunc("/token", p.token)
+	p.Server = httptest.NewServer(mux)
+	p.Issuer = p.Server.URL
+	t.Cleanup(p.Server.Close)
+	return p
+}
+
+// JWKSRequests JWKS 被请求的次数
+func (p *Provider) JWKSRequests() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.jwksRequests
+}
+
+// Authorize 模拟用户在授权页面同意登录：校验授权地址的参数并返回授权码和 state
+func (p *Provider) Authorize(authorizationURL string) (code string, state string, err error) {
+	parsed, err := url.Parse(authorizationURL)
+	if err != nil {
+		return "", "", err
+	}
+	query := parsed.Query()
+	switch {
+	case query.Get("response_type") != "code":
+		return "", "", errors.New("response_type must be code")
+	case query.Get("client_id") != p.ClientId:
+		return "", "", errors.New("unknown client_id")
+	case query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "":
+		return "", "", errors.New("PKCE S256 code_challenge is required")
+	case query.Get("state") == "" || query.Get("nonce") == "":
+		return "", "", errors.New("state and nonce are required")
+	}
+
+	if code, err = oidc.RandomString(); err != nil {
+		return "", "", err
+	}
+	p.mu.Lock()
+	p.grants[code] = grant{
+		redirectURI:   query.Get("redirect_uri"),
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+	}
+	p.mu.Unlock()
+	return code, query.Get("state"), nil
+}
+
+// IdTokenClaims 有效的 ID Token 声明
+func (p *Provider) IdTokenClaims(subject string, nonce string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            p.Issuer,
+		"aud":            p.ClientId,
+		"sub":            subject,
+		"nonce":          nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"email":          subject + "@example.com",
+		"email_verified": true,
+		"name":           "Test " + subject,
+	}
+}
+
+// Sign 使用身份提供方的密钥签名
+func (p *Provider) Sign(claims jwt.MapClaims) string {
+	return SignWith(claims, p.Key, p.KeyId)
+}
+
+// SignWith 使用指定的密钥和 kid 签名（RS256）
+func SignWith(claims jwt.MapClaims, key *rsa.PrivateKey, kid string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.Issuer,
+		"authorization_endpoint":                p.Server.URL + "/authorize",
+		"token_endpoint":                        p.Server.URL + "/token",
+		"jwks_uri":                              p.Server.URL + "/jwks",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, _ *http.Request) {
+	p.mu.Lock()
+	p.jwksRequests++
+	p.mu.Unlock()
+
+	publicKey := p.Key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": p.KeyId,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		}},
+	})
+}
+
+// token 令牌端点：授权码只能使用一次，redirect_uri 必须与授权时一致，code_verifier 必须与 code_challenge 匹配
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	oauthError := func(code string) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+	}
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		oauthError("invalid_request")
+		return
+	}
+	clientId, clientSecret, _ := r.BasicAuth()
+	clientId, _ = url.QueryUnescape(clientId)
+	clientSecret, _ = url.QueryUnescape(clientSecret)
+	if clientId != p.ClientId || clientSecret != p.ClientSecret {
+		oauthError("invalid_client")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		oauthError("unsupported_grant_type")
+		return
+	}
+
+	p.mu.Lock()
+	code := r.PostForm.Get("code")
+	g, ok := p.grants[code]
+	delete(p.grants, code)
+	p.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || g.redirectURI != r.PostForm.Get("redirect_uri") || base64.RawURLEncoding.EncodeToString(sum[:]) != g.codeChallenge {
+		oauthError("invalid_grant")
+		return
+	}
+
+	accessToken, err := oidc.RandomString()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	claims := p.IdTokenClaims("alice", g.nonce)
+	if p.Claims != nil {
+		p.Claims(claims)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     p.Sign(claims),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
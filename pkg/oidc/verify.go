@@ -0,0 +1,117 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidIdToken = errors.New("oidc: invalid id token")
+
+// 支持的签名算法，不接受 none 和 HMAC（客户端无法安全地持有对称密钥）
+var signingAlgs = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// 校验时间时允许的时钟偏差
+const clockSkew = time.Minute
+
+// IdToken ID Token 中的声明
+type IdToken struct {
+	Subject           string   `json:"sub"`
+	Nonce             string   `json:"nonce"`
+	AuthorizedParty   string   `json:"azp"`
+	Email             string   `json:"email"`
+	EmailVerified     flexBool `json:"email_verified"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+	Picture           string   `json:"picture"`
+	jwt.RegisteredClaims
+}
+
+// flexBool 部分身份提供方将 email_verified 返回为字符串 "true"
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case bool:
+		*b = flexBool(v)
+	case string:
+		*b = flexBool(strings.EqualFold(v, "true"))
+	default:
+		*b = false
+	}
+	return nil
+}
+
+// Verified 邮箱是否已被身份提供方验证
+func (t *IdToken) Verified() bool {
+	return bool(t.EmailVerified)
+}
+
+// VerifyIdToken 校验 ID Token 的签名（JWKS）、iss、aud、azp、exp 和 nonce
+func (p *Provider) VerifyIdToken(ctx context.Context, rawIdToken string, nonce string) (*IdToken, error) {
+	algs := signingAlgs
+	if supported := intersect(signingAlgs, p.metadata.SigningAlgs); len(supported) > 0 {
+		algs = supported
+	}
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(algs),
+		jwt.WithIssuer(p.metadata.Issuer),
+		jwt.WithAudience(p.config.ClientId),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+	)
+
+	var claims IdToken
+	_, err := parser.ParseWithClaims(rawIdToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		keys, err := p.keys.lookup(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		set := jwt.VerificationKeySet{}
+		for _, key := range keys {
+			set.Keys = append(set.Keys, key)
+		}
+		return set, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIdToken, err)
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidIdToken)
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.config.ClientId {
+		return nil, fmt.Errorf("%w: azp mismatch", ErrInvalidIdToken)
+	}
+	if claims.AuthorizedParty != "" && claims.AuthorizedParty != p.config.ClientId {
+		return nil, fmt.Errorf("%w: azp mismatch", ErrInvalidIdToken)
+	}
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIdToken)
+	}
+	return &claims, nil
+}
+
+func intersect(allowed []string, supported []string) []string {
+	var result []string
+	for _, a := range allowed {
+		for _, s := range supported {
+			if a == s {
+				result = append(result, a)
+				break
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,306 @@
+// Package ot 实现纯文本的操作转换（Operational Transformation），
+// 数据格式与前端常用的 ot.js 兼容：操作是由保留（正整数）、插入（字符串）、删除（负整数）组成的数组，
+// 长度按 UTF-16 码元计算，与 JavaScript 字符串下标一致
+package ot
+
+import (
+	"encoding/json"
+	"errors"
+	"unicode/utf16"
+)
+
+var (
+	ErrLengthMismatch   = errors.New("operation base length does not match document length")
+	ErrInvalidOperation = errors.New("invalid operation")
+)
+
+// Component 操作中的一个片段，三个字段中只有一个有效
+type Component struct {
+	Retain int    // 保留的长度
+	Insert string // 插入的文本
+	Delete int    // 删除的长度
+}
+
+// Operation 对整篇文档的一次编辑
+type Operation struct {
+	Ops          []Component
+	BaseLength   int // 作用前的文档长度
+	TargetLength int // 作用后的文档长度
+}
+
+// textLength 文本的 UTF-16 长度
+func textLength(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+// Retain 追加保留片段
+func (o *Operation) Retain(n int) *Operation {
+	if n <= 0 {
+		return o
+	}
+	o.BaseLength += n
+	o.TargetLength += n
+	if last := len(o.Ops) - 1; last >= 0 && o.Ops[last].Retain > 0 {
+		o.Ops[last].Retain += n
+		return o
+	}
+	o.Ops = append(o.Ops, Component{Retain: n})
+	return o
+}
+
+// Insert 追加插入片段，紧邻的插入与删除统一规范为先插入后删除
+func (o *Operation) Insert(s string) *Operation {
+	if s == "" {
+		return o
+	}
+	o.TargetLength += textLength(s)
+	last := len(o.Ops) - 1
+	switch {
+	case last >= 0 && o.Ops[last].Insert != "":
+		o.Ops[last].Insert += s
+	case last >= 0 && o.Ops[last].Delete > 0:
+		if last > 0 && o.Ops[last-1].Insert != "" {
+			o.Ops[last-1].Insert += s
+		} else {
+			o.Ops = append(o.Ops, o.Ops[last])
+			o.Ops[last] = Component{Insert: s}
+		}
+	default:
+		o.Ops = append(o.Ops, Component{Insert: s})
+	}
+	return o
+}
+
+// Delete 追加删除片段
+func (o *Operation) Delete(n int) *Operation {
+	if n <= 0 {
+		return o
+	}
+	o.BaseLength += n
+	if last := len(o.Ops) - 1; last >= 0 && o.Ops[last].Delete > 0 {
+		o.Ops[last].Delete += n
+		return o
+	}
+	o.Ops = append(o.Ops, Component{Delete: n})
+	return o
+}
+
+// IsNoop 判断操作是否不改变文档
+func (o *Operation) IsNoop() bool {
+	return len(o.Ops) == 0 || (len(o.Ops) == 1 && o.Ops[0].Retain > 0)
+}
+
+// Apply 将操作作用于文档
+func (o *Operation) Apply(doc string) (string, error) {
+	units := utf16.Encode([]rune(doc))
+	if len(units) != o.BaseLength {
+		return "", ErrLengthMismatch
+	}
+
+	result := make([]uint16, 0, o.TargetLength)
+	index := 0
+	for _, c := range o.Ops {
+		switch {
+		case c.Retain > 0:
+			if index+c.Retain > len(units) {
+				return "", ErrInvalidOperation
+			}
+			result = append(result, units[index:index+c.Retain]...)
+			index += c.Retain
+		case c.Insert != "":
+			result = append(result, utf16.Encode([]rune(c.Insert))...)
+		case c.Delete > 0:
+			index += c.Delete
+		}
+	}
+	if index != len(units) {
+		return "", ErrInvalidOperation
+	}
+
+	return string(utf16.Decode(result)), nil
+}
+
+// Diff 生成把 from 变为 to 的操作：保留公共前缀和后缀，替换中间不同的部分
+func Diff(from string, to string) *Operation {
+	a, b := utf16.Encode([]rune(from)), utf16.Encode([]rune(to))
+	// 不在代理对中间切分，保证插入的文本是合法的字符
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	if prefix > 0 && isHighSurrogate(a[prefix-1]) {
+		prefix--
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	if suffix > 0 && isLowSurrogate(a[len(a)-suffix]) {
+		suffix--
+	}
+
+	o := &Operation{}
+	o.Retain(prefix)
+	o.Insert(string(utf16.Decode(b[prefix : len(b)-suffix])))
+	o.Delete(len(a) - prefix - suffix)
+	o.Retain(suffix)
+	return o
+}
+
+func isHighSurrogate(u uint16) bool { return u >= 0xd800 && u < 0xdc00 }
+
+func isLowSurrogate(u uint16) bool { return u >= 0xdc00 && u < 0xe000 }
+
+// componentLength 片段作用于原文档的长度
+func componentLength(c Component) int {
+	if c.Retain > 0 {
+		return c.Retain
+	}
+	return c.Delete
+}
+
+// Transform 转换两个基于同一文档版本的并发操作，返回 a' 和 b'，
+// 满足 apply(apply(doc, a), b') == apply(apply(doc, b), a')
+func Transform(a *Operation, b *Operation) (*Operation, *Operation, error) {
+	if a.BaseLength != b.BaseLength {
+		return nil, nil, ErrLengthMismatch
+	}
+
+	aPrime := &Operation{}
+	bPrime := &Operation{}
+	opsA, opsB := a.Ops, b.Ops
+	var opA, opB *Component
+	next := func(ops *[]Component) *Component {
+		if len(*ops) == 0 {
+			return nil
+		}
+		c := (*ops)[0]
+		*ops = (*ops)[1:]
+		return &c
+	}
+	opA, opB = next(&opsA), next(&opsB)
+
+	for opA != nil || opB != nil {
+		// 插入优先处理，a 的插入排在 b 的插入之前
+		if opA != nil && opA.Insert != "" {
+			aPrime.Insert(opA.Insert)
+			bPrime.Retain(textLength(opA.Insert))
+			opA = next(&opsA)
+			continue
+		}
+		if opB != nil && opB.Insert != "" {
+			aPrime.Retain(textLength(opB.Insert))
+			bPrime.Insert(opB.Insert)
+			opB = next(&opsB)
+			continue
+		}
+		if opA == nil || opB == nil {
+			return nil, nil, ErrInvalidOperation
+		}
+
+		lenA, lenB := componentLength(*opA), componentLength(*opB)
+		minLength := lenA
+		if lenB < minLength {
+			minLength = lenB
+		}
+
+		switch {
+		case opA.Retain > 0 && opB.Retain > 0:
+			aPrime.Retain(minLength)
+			bPrime.Retain(minLength)
+		case opA.Delete > 0 && opB.Retain > 0:
+			aPrime.Delete(minLength)
+		case opA.Retain > 0 && opB.Delete > 0:
+			bPrime.Delete(minLength)
+		}
+		// 双方都删除时无需输出
+
+		if lenA == minLength {
+			opA = next(&opsA)
+		} else if opA.Retain > 0 {
+			opA.Retain -= minLength
+		} else {
+			opA.Delete -= minLength
+		}
+		if lenB == minLength {
+			opB = next(&opsB)
+		} else if opB.Retain > 0 {
+			opB.Retain -= minLength
+		} else {
+			opB.Delete -= minLength
+		}
+	}
+
+	return aPrime, bPrime, nil
+}
+
+// TransformIndex 将文档中的位置（如光标）按操作进行转换
+func TransformIndex(o *Operation, index int) int {
+	newIndex := index
+	for _, c := range o.Ops {
+		switch {
+		case c.Retain > 0:
+			index -= c.Retain
+		case c.Insert != "":
+			newIndex += textLength(c.Insert)
+		case c.Delete > 0:
+			if index < c.Delete {
+				newIndex -= index
+			} else {
+				newIndex -= c.Delete
+			}
+			index -= c.Delete
+		}
+		if index < 0 {
+			break
+		}
+	}
+	return newIndex
+}
+
+// MarshalJSON 编码为 ot.js 格式，如 [5, "hello", -3]
+func (o *Operation) MarshalJSON() ([]byte, error) {
+	items := make([]interface{}, 0, len(o.Ops))
+	for _, c := range o.Ops {
+		switch {
+		case c.Retain > 0:
+			items = append(items, c.Retain)
+		case c.Insert != "":
+			items = append(items, c.Insert)
+		case c.Delete > 0:
+			items = append(items, -c.Delete)
+		}
+	}
+	return json.Marshal(items)
+}
+
+// UnmarshalJSON 从 ot.js 格式解码
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+
+	*o = Operation{}
+	for _, item := range items {
+		var n int
+		if err := json.Unmarshal(item, &n); err == nil {
+			switch {
+			case n > 0:
+				o.Retain(n)
+			case n < 0:
+				o.Delete(-n)
+			default:
+				return ErrInvalidOperation
+			}
+			continue
+		}
+
+		var s string
+		if err := json.Unmarshal(item, &s); err != nil || s == "" {
+			return ErrInvalidOperation
+		}
+		o.Insert(s)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package ot
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// op 按 ot.js 格式构造操作，如 op(t, 2, "ab", -1)
+func op(t *testing.T, items ...interface{}) *Operation {
+	t.Helper()
+	data, err := json.Marshal(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := &Operation{}
+	if err := json.Unmarshal(data, o); err != nil {
+		t.Fatalf("unmarshal %s: %v", data, err)
+	}
+	return o
+}
+
+func TestTransformConvergence(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		a    []interface{}
+		b    []interface{}
+		want string
+	}{
+		{name: "inserts at same position", doc: "abc", a: []interface{}{1, "X", 2}, b: []interface{}{1, "Y", 2}, want: "aXYbc"},
+		{name: "inserts at different positions", doc: "abc", a: []interface{}{"X", 3}, b: []interface{}{3, "Y"}, want: "XabcY"},
+		{name: "insert inside deleted range", doc: "abcdef", a: []interface{}{1, -4, 1}, b: []interface{}{3, "X", 3}, want: "aXf"},
+		{name: "overlapping deletes", doc: "abcdef", a: []interface{}{1, -3, 2}, b: []interface{}{2, -3, 1}, want: "af"},
+		{name: "identical deletes", doc: "abcdef", a: []interface{}{2, -2, 2}, b: []interface{}{2, -2, 2}, want: "abef"},
+		{name: "delete and retain", doc: "abcdef", a: []interface{}{-2, 4}, b: []interface{}{4, -2}, want: "cd"},
+		{name: "replace whole document", doc: "abc", a: []interface{}{"X", -3}, b: []interface{}{"Y", -3}, want: "XY"},
+		{name: "cjk", doc: "你好世界", a: []interface{}{2, "，", 2}, b: []interface{}{2, -2, "朋友"}, want: "你好，朋友"},
+		{name: "surrogate pairs", doc: "a😀b😀", a: []interface{}{3, "😂", 3}, b: []interface{}{1, -2, 3}, want: "a😂b😀"},
+		{name: "insert before emoji and delete emoji", doc: "😀😀", a: []interface{}{2, "中", 2}, b: []interface{}{2, -2}, want: "😀中"},
+		{name: "empty document", doc: "", a: []interface{}{"ab"}, b: []interface{}{"cd"}, want: "abcd"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, b := op(t, test.a...), op(t, test.b...)
+			aPrime, bPrime, err := Transform(a, b)
+			if err != nil {
+				t.Fatalf("Transform: %v", err)
+			}
+
+			apply := func(o *Operation, doc string) string {
+				t.Helper()
+				result, err := o.Apply(doc)
+				if err != nil {
+					t.Fatalf("Apply %v to %q: %v", o.Ops, doc, err)
+				}
+				return result
+			}
+			ab := apply(bPrime, apply(a, test.doc))
+			ba := apply(aPrime, apply(b, test.doc))
+			if ab != ba {
+				t.Fatalf("diverged: apply(apply(doc, a), b') = %q, apply(apply(doc, b), a') = %q", ab, ba)
+			}
+			if ab != test.want {
+				t.Errorf("document = %q, want %q", ab, test.want)
+			}
+		})
+	}
+}
+
+func TestTransformLengthMismatch(t *testing.T) {
+	if _, _, err := Transform(op(t, 3), op(t, 4)); err != ErrLengthMismatch {
+		t.Errorf("Transform: %v, want ErrLengthMismatch", err)
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	if _, err := op(t, 4).Apply("abc"); err != ErrLengthMismatch {
+		t.Errorf("Apply to shorter document: %v, want ErrLengthMismatch", err)
+	}
+	// 长度按 UTF-16 计算，emoji 占 2 个码元
+	if _, err := op(t, 1, -1).Apply("😀"); err != nil {
+		t.Errorf("Apply to emoji: %v", err)
+	}
+	if _, err := op(t, 1).Apply("😀"); err != ErrLengthMismatch {
+		t.Errorf("Apply with rune length: %v, want ErrLengthMismatch", err)
+	}
+}
+
+func TestTransformIndex(t *testing.T) {
+	tests := []struct {
+		name  string
+		op    []interface{}
+		index int
+		want  int
+	}{
+		{name: "insert before", op: []interface{}{"ab", 5}, index: 3, want: 5},
+		{name: "insert after", op: []interface{}{4, "ab", 1}, index: 3, want: 3},
+		{name: "insert at index", op: []interface{}{3, "ab", 2}, index: 3, want: 5},
+		{name: "delete before", op: []interface{}{-2, 3}, index: 4, want: 2},
+		{name: "delete containing index", op: []interface{}{1, -3, 1}, index: 3, want: 1},
+		{name: "delete after", op: []interface{}{4, -1}, index: 2, want: 2},
+		{name: "surrogate pair", op: []interface{}{"😀", 2}, index: 1, want: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := TransformIndex(op(t, test.op...), test.index); got != test.want {
+				t.Errorf("TransformIndex = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want string
+	}{
+		{from: "abc", to: "abc", want: "[3]"},
+		{from: "正文含敏感词", to: "正文含**词", want: `[3,"**",-2,1]`},
+		{from: "", to: "abc", want: `["abc"]`},
+		{from: "abc", to: "", want: "[-3]"},
+		// 同一高代理项开头的两个 emoji 不会被拆开
+		{from: "a😀b", to: "a😁b", want: `[1,"😁",-2,1]`},
+	}
+
+	for _, test := range tests {
+		o := Diff(test.from, test.to)
+		data, err := json.Marshal(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != test.want {
+			t.Errorf("Diff(%q, %q) = %s, want %s", test.from, test.to, data, test.want)
+		}
+		if result, err := o.Apply(test.from); err != nil || result != test.to {
+			t.Errorf("applying Diff(%q, %q) = %q, %v", test.from, test.to, result, err)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	o := op(t, 2, "ab", -1, 3)
+	if o.BaseLength != 6 || o.TargetLength != 7 {
+		t.Errorf("lengths = %d, %d, want 6, 7", o.BaseLength, o.TargetLength)
+	}
+	data, err := json.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `[2,"ab",-1,3]` {
+		t.Errorf("Marshal = %s", data)
+	}
+
+	for _, input := range []string{`[0]`, `[""]`, `[1.5]`, `[true]`, `[null]`, `[[1]]`, `{"ops":[1]}`, `"abc"`, `[1,`} {
+		if err := json.Unmarshal([]byte(input), &Operation{}); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded", input)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Package rbac 角色与权限定义。角色只是一组权限的名字，业务代码只检查权限，不直接判断角色
+package rbac
+
+// 角色
+const (
+	RoleAdmin  = "admin"  // 管理员
+	RoleEditor = "editor" // 编辑，可管理置顶、审核他人的内容
+	RoleAuthor = "author" // 普通作者（注册默认角色）
+	RoleReader = "reader" // 读者，只能阅读和举报，不能发布文章
+)
+
+type Permission string
+
+// 权限
+const (
+	PermArticleCreate    Permission = "article:create"     // 发布文章
+	PermArticleEditAny   Permission = "article:edit_any"   // 编辑他人的文章
+	PermArticleDeleteAny Permission = "article:delete_any" // 删除他人的文章
+	PermArticleModerate  Permission = "article:moderate"   // 审核队列、隐藏文章、查看被隐藏的文章、垃圾标注
+	PermArticlePin       Permission = "article:pin"        // 置顶文章
+	PermUserManage       Permission = "user:manage"        // 管理用户
+	PermSiteManage       Permission = "site:manage"        // 站点设置：过滤规则、邮件、全站 webhook
+)
+
+// rolePermissions 各角色拥有的权限
+var rolePermissions = map[string][]Permission{
+	RoleAdmin: {
+		PermArticleCreate, PermArticleEditAny, PermArticleDeleteAny, PermArticleModerate, PermArticlePin,
+		PermUserManage, PermSiteManage,
+	},
+	RoleEditor: {
+		PermArticleCreate, PermArticleEditAny, PermArticleDeleteAny, PermArticleModerate, PermArticlePin,
+	},
+	RoleAuthor: {
+		PermArticleCreate,
+	},
+	RoleReader: {},
+}
+
+// ValidRole 角色是否存在
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Roles 所有角色，按权限从高到低排列
+func Roles() []string {
+	return []string{RoleAdmin, RoleEditor, RoleAuthor, RoleReader}
+}
+
+// Has 角色是否拥有权限，未知角色没有任何权限
+func Has(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions 角色拥有的所有权限
+func Permissions(role string) []Permission {
+	return append([]Permission{}, rolePermissions[role]...)
+}
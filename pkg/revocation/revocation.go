@@ -0,0 +1,59 @@
+// Package revocation 保存已注销的令牌（按 jti），令牌过期后记录即可清除
+package revocation
+
+import (
+	"sync"
+	"time"
+)
+
+// Store 令牌注销记录
+type Store interface {
+	// Revoke 注销令牌，expiresAt 为令牌本身的过期时间，之后不再需要保留记录
+	Revoke(jti string, expiresAt time.Time) error
+	// IsRevoked 令牌是否已注销
+	IsRevoked(jti string) (bool, error)
+}
+
+// 内存清理间隔
+const sweepInterval = time.Minute
+
+// MemoryStore 内存实现，重启后记录丢失，只适合单实例部署
+type MemoryStore struct {
+	mu        sync.RWMutex
+	revoked   map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		revoked:   make(map[string]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryStore) Revoke(jti string, expiresAt time.Time) error {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if expiresAt.After(now) {
+		s.revoked[jti] = expiresAt
+	}
+	// 顺便清理已过期的记录
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		for id, expires := range s.revoked {
+			if !expires.After(now) {
+				delete(s.revoked, id)
+			}
+		}
+		s.lastSweep = now
+	}
+	return nil
+}
+
+func (s *MemoryStore) IsRevoked(jti string) (bool, error) {
+	s.mu.RLock()
+	expires, ok := s.revoked[jti]
+	s.mu.RUnlock()
+	return ok && expires.After(time.Now()), nil
+}
@@ -0,0 +1,98 @@
+package spam
+
+import (
+	"math"
+	"sync"
+)
+
+// Class 样本类别
+type Class int
+
+const (
+	Ham  Class = 0 // 正常内容
+	Spam Class = 1 // 垃圾内容
+)
+
+// Classifier 朴素贝叶斯分类器，使用多项式模型和拉普拉斯平滑，可并发使用
+type Classifier struct {
+	mu     sync.RWMutex
+	counts [2]map[string]int // 各类别中每个特征出现的次数
+	totals [2]int            // 各类别特征总数
+	docs   [2]int            // 各类别样本数
+}
+
+// NewClassifier 创建空分类器
+func NewClassifier() *Classifier {
+	return &Classifier{
+		counts: [2]map[string]int{make(map[string]int), make(map[string]int)},
+	}
+}
+
+// Learn 学习一个样本
+func (c *Classifier) Learn(features []string, class Class) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, feature := range features {
+		c.counts[class][feature]++
+		c.totals[class]++
+	}
+	c.docs[class]++
+}
+
+// Forget 撤销一个已学习的样本，用于样本重新标注
+func (c *Classifier) Forget(features []string, class Class) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, feature := range features {
+		if c.counts[class][feature] > 0 {
+			c.counts[class][feature]--
+			c.totals[class]--
+			if c.counts[class][feature] == 0 {
+				delete(c.counts[class], feature)
+			}
+		}
+	}
+	if c.docs[class] > 0 {
+		c.docs[class]--
+	}
+}
+
+// Docs 返回两个类别的样本数
+func (c *Classifier) Docs() (ham int, spam int) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.docs[Ham], c.docs[Spam]
+}
+
+// SpamProbability 计算样本属于垃圾类别的后验概率，未训练时返回 0.5
+func (c *Classifier) SpamProbability(features []string) float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	// 词表大小，用于拉普拉斯平滑
+	vocabulary := len(c.counts[Ham])
+	for feature := range c.counts[Spam] {
+		if _, ok := c.counts[Ham][feature]; !ok {
+			vocabulary++
+		}
+	}
+	if vocabulary == 0 {
+		vocabulary = 1
+	}
+
+	totalDocs := c.docs[Ham] + c.docs[Spam]
+	var logProb [2]float64
+	for _, class := range []Class{Ham, Spam} {
+		// 先验概率同样做平滑，避免某一类别没有样本时出现 log(0)
+		logProb[class] = math.Log(float64(c.docs[class]+1) / float64(totalDocs+2))
+		denominator := float64(c.totals[class] + vocabulary)
+		for _, feature := range features {
+			logProb[class] += math.Log(float64(c.counts[class][feature]+1) / denominator)
+		}
+	}
+
+	// 在对数空间中归一化：P(spam) = 1 / (1 + e^(logHam - logSpam))
+	return 1 / (1 + math.Exp(logProb[Ham]-logProb[Spam]))
+}
@@ -0,0 +1,66 @@
+package spam
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSpamProbability(t *testing.T) {
+	c := NewClassifier()
+	if p := c.SpamProbability([]string{"anything"}); p != 0.5 {
+		t.Errorf("untrained probability = %v, want 0.5", p)
+	}
+
+	c.Learn([]string{"cheap", "pills", "buy"}, Spam)
+	c.Learn([]string{"cheap", "casino", "win"}, Spam)
+	c.Learn([]string{"meeting", "notes", "project"}, Ham)
+	c.Learn([]string{"project", "release", "notes"}, Ham)
+	if ham, spam := c.Docs(); ham != 2 || spam != 2 {
+		t.Fatalf("Docs = %d, %d, want 2, 2", ham, spam)
+	}
+
+	spamScore := c.SpamProbability([]string{"cheap", "pills"})
+	hamScore := c.SpamProbability([]string{"project", "notes"})
+	if spamScore < 0.8 || hamScore > 0.2 {
+		t.Errorf("spam score %v, ham score %v", spamScore, hamScore)
+	}
+	// 未见过的特征不影响判断
+	if p := c.SpamProbability([]string{"unknown"}); math.Abs(p-0.5) > 1e-9 {
+		t.Errorf("unknown feature probability = %v, want 0.5", p)
+	}
+
+	// 拉普拉斯平滑：只有一个类别有样本时概率不会变为 0 或 1
+	one := NewClassifier()
+	one.Learn([]string{"cheap"}, Spam)
+	if p := one.SpamProbability([]string{"cheap"}); p <= 0.5 || p >= 1 {
+		t.Errorf("single class probability = %v", p)
+	}
+}
+
+func TestForget(t *testing.T) {
+	c := NewClassifier()
+	c.Learn([]string{"hello", "world"}, Ham)
+	before := c.SpamProbability([]string{"cheap", "hello"})
+
+	// 重新标注：撤销后按新类别学习，再撤销后恢复原状
+	c.Learn([]string{"cheap", "offer"}, Ham)
+	c.Forget([]string{"cheap", "offer"}, Ham)
+	c.Learn([]string{"cheap", "offer"}, Spam)
+	if ham, spam := c.Docs(); ham != 1 || spam != 1 {
+		t.Fatalf("Docs = %d, %d, want 1, 1", ham, spam)
+	}
+	if p := c.SpamProbability([]string{"cheap"}); p <= 0.5 {
+		t.Errorf("relabeled feature probability = %v", p)
+	}
+
+	c.Forget([]string{"cheap", "offer"}, Spam)
+	if after := c.SpamProbability([]string{"cheap", "hello"}); math.Abs(after-before) > 1e-9 {
+		t.Errorf("probability after forget = %v, want %v", after, before)
+	}
+
+	// 撤销未学习过的样本不会出现负数
+	c.Forget([]string{"missing"}, Spam)
+	if ham, spam := c.Docs(); ham != 1 || spam != 0 {
+		t.Errorf("Docs = %d, %d, want 1, 0", ham, spam)
+	}
+}
@@ -0,0 +1,31 @@
+package spam
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "latin words", text: "Buy CHEAP pills, now!", want: []string{"buy", "cheap", "pills", "now"}},
+		{name: "short and long words dropped", text: "a " + strings.Repeat("x", 31) + " ok", want: []string{"ok"}},
+		{name: "han bigrams", text: "免费领取", want: []string{"免费", "费领", "领取"}},
+		{name: "single han kept", text: "买，卖", want: []string{"买", "卖"}},
+		{name: "mixed scripts", text: "点击link领奖100元", want: []string{"点击", "link", "领奖", "100", "元"}},
+		{name: "japanese and korean", text: "カタカナ 안녕", want: []string{"カタ", "タカ", "カナ", "안녕"}},
+		{name: "punctuation only", text: "!!! ...", want: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Tokenize(test.text); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Tokenize(%q) = %q, want %q", test.text, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录 B 的 SHA-1 密钥 "12345678901234567890"
+var rfcSecret = encoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeRFC6238(t *testing.T) {
+	// 附录 B 中为 8 位验证码，6 位验证码为其后 6 位
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, test := range tests {
+		code, err := Code(rfcSecret, Counter(time.Unix(test.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := test.want[len(test.want)-Digits:]; code != want {
+			t.Errorf("Code at %d = %s, want %s", test.unix, code, want)
+		}
+	}
+}
+
+func TestCodeSecretFormat(t *testing.T) {
+	// 身份验证器和用户手动输入的密钥可能是小写或带填充
+	want, _ := Code(rfcSecret, 1)
+	for _, secret := range []string{strings.ToLower(rfcSecret), rfcSecret + "===="} {
+		if got, err := Code(secret, 1); err != nil || got != want {
+			t.Errorf("Code(%q) = %q, %v, want %q", secret, got, err, want)
+		}
+	}
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("invalid secret accepted")
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Counter(now)
+	code := func(counter int64) string {
+		c, err := Code(rfcSecret, counter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		counter int64
+		skew    int
+		ok      bool
+	}{
+		{"current step", current, 1, true},
+		{"previous step", current - 1, 1, true},
+		{"next step", current + 1, 1, true},
+		{"two steps behind", current - 2, 1, false},
+		{"two steps ahead", current + 2, 1, false},
+		{"previous step without skew", current - 1, 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			counter, ok := Validate(rfcSecret, code(test.counter), now, test.skew)
+			if ok != test.ok {
+				t.Fatalf("Validate ok = %v, want %v", ok, test.ok)
+			}
+			if ok && counter != test.counter {
+				t.Errorf("matched counter %d, want %d", counter, test.counter)
+			}
+		})
+	}
+
+	// 允许验证码中间带空格
+	if _, ok := Validate(rfcSecret, code(current)[:3]+" "+code(current)[3:], now, 0); !ok {
+		t.Error("code with space rejected")
+	}
+	for _, invalid := range []string{"", "12345", "1234567", "abcdef"} {
+		if _, ok := Validate(rfcSecret, invalid, now, 1); ok {
+			t.Errorf("Validate(%q) accepted", invalid)
+		}
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("Blog", "alice@example.com", rfcSecret)
+	if !strings.HasPrefix(uri, "otpauth://totp/Blog:alice@example.com?") || !strings.Contains(uri, "secret="+rfcSecret) {
+		t.Errorf("URI = %s", uri)
+	}
+}
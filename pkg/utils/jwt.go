@@ -14,28 +14,44 @@ var (
 )
 
 type JwtClaims struct {
-	UserId int `json:"user_id"`
+	UserId       int    `json:"user_id"`
+	TokenVersion int    `json:"ver"`  // 用户的令牌版本，重置密码等操作后递增，使之前签发的令牌全部失效
+	SessionId    int    `json:"sid"`  // 所属登录会话，会话作废后令牌失效
+	Role         string `json:"role"` // 签发时的角色，角色变更在刷新令牌后生效
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userId int) (string, error) {
+// AccessTokenExpires 登录令牌有效期，由 JWT_EXPIRES 配置，默认15分钟，过期后使用刷新令牌换取新的登录令牌
+func AccessTokenExpires() (time.Duration, error) {
+	expireStrTime := os.Getenv("JWT_EXPIRES")
+	if len(expireStrTime) == 0 {
+		expireStrTime = "15m"
+	}
+	return time.ParseDuration(expireStrTime)
+}
+
+func GenerateToken(userId int, tokenVersion int, sessionId int, role string) (string, error) {
 	SecretKey := os.Getenv("JWT_SECRET")
 	if len(SecretKey) == 0 {
 		return "", errors.New("JWT_SECRET not set")
 	}
 
-	expireStrTime := os.Getenv("JWT_EXPIRES")
-	if len(expireStrTime) == 0 {
-		expireStrTime = "24h"
+	expireTime, err := AccessTokenExpires()
+	if err != nil {
+		return "", err
 	}
-
-	expireTime, err := time.ParseDuration(expireStrTime)
+	// jti 用于注销单个令牌
+	jti, err := GenerateRandomToken(16)
 	if err != nil {
 		return "", err
 	}
 	claims := &JwtClaims{
-		UserId: userId,
+		UserId:       userId,
+		TokenVersion: tokenVersion,
+		SessionId:    sessionId,
+		Role:         role,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expireTime)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -65,8 +81,77 @@ func ParseToken(tokenString string) (*JwtClaims, error) {
 		return nil, ErrInvalidToken
 	}
 
+	// 带 Subject 的是其他用途的令牌（如文章访问令牌），不能当作登录令牌使用
 	claims, ok := token.Claims.(*JwtClaims)
-	if !ok || !token.Valid {
+	if !ok || !token.Valid || claims.Subject != "" || claims.UserId <= 0 {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// ArticleClaims 受密码保护文章的访问令牌，只对单篇文章有效
+type ArticleClaims struct {
+	ArticleId   int    `json:"article_id"`
+	Fingerprint string `json:"fp"` // 文章密码哈希的指纹，修改密码后旧令牌自动失效
+	jwt.RegisteredClaims
+}
+
+// GenerateArticleToken 生成文章访问令牌，有效期由 ARTICLE_TOKEN_EXPIRES 配置，默认30分钟
+func GenerateArticleToken(articleId int, fingerprint string) (string, time.Time, error) {
+	SecretKey := os.Getenv("JWT_SECRET")
+	if len(SecretKey) == 0 {
+		return "", time.Time{}, errors.New("JWT_SECRET not set")
+	}
+
+	expireStrTime := os.Getenv("ARTICLE_TOKEN_EXPIRES")
+	if len(expireStrTime) == 0 {
+		expireStrTime = "30m"
+	}
+
+	expireTime, err := time.ParseDuration(expireStrTime)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := time.Now().Add(expireTime)
+	claims := &ArticleClaims{
+		ArticleId:   articleId,
+		Fingerprint: fingerprint,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "article_access",
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(SecretKey))
+	return signed, expiresAt, err
+}
+
+// ParseArticleToken 解析文章访问令牌
+func ParseArticleToken(tokenString string) (*ArticleClaims, error) {
+	SecretKey := os.Getenv("JWT_SECRET")
+	if len(SecretKey) == 0 {
+		return nil, errors.New("JWT_SECRET not set")
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &ArticleClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrInvalidToken
+		}
+		return []byte(SecretKey), nil
+	})
+
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(*ArticleClaims)
+	if !ok || !token.Valid || claims.Subject != "article_access" {
 		return nil, ErrInvalidToken
 	}
 
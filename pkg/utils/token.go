@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// GenerateRandomToken 生成 URL 安全的随机令牌，n 为随机字节数
+func GenerateRandomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// AccessTokenPrefix 个人访问令牌的前缀，用于和登录令牌（JWT）区分
+const AccessTokenPrefix = "blogpat_"
+
+// IsAccessToken 是否为个人访问令牌
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, AccessTokenPrefix)
+}
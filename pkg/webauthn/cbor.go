@@ -0,0 +1,172 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+var errCBOR = errors.New("webauthn: malformed cbor")
+
+// 嵌套深度上限，防止恶意数据耗尽栈空间
+const cborMaxDepth = 16
+
+// decodeCBOR 解码一个 CBOR 数据项（RFC 8949），返回解码结果和剩余的字节。
+// 只支持 WebAuthn 用到的定长编码：整数为 int64，字节串为 []byte，文本为 string，
+// 数组为 []interface{}，映射为 map[interface{}]interface{}（键只能是整数或文本）
+func decodeCBOR(data []byte) (interface{}, []byte, error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (interface{}, []byte, error) {
+	if depth > cborMaxDepth {
+		return nil, nil, fmt.Errorf("%w: nesting too deep", errCBOR)
+	}
+	if len(data) == 0 {
+		return nil, nil, fmt.Errorf("%w: unexpected end of data", errCBOR)
+	}
+
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+	data = data[1:]
+
+	// 浮点数和简单值的附加信息含义不同，单独处理
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22, 23:
+			return nil, data, nil
+		case 25, 26, 27:
+			size := 1 << (info - 24)
+			if len(data) < size {
+				return nil, nil, fmt.Errorf("%w: unexpected end of data", errCBOR)
+			}
+			var value float64
+			switch size {
+			case 2:
+				value = float64(halfToFloat(binary.BigEndian.Uint16(data)))
+			case 4:
+				value = float64(math.Float32frombits(binary.BigEndian.Uint32(data)))
+			default:
+				value = math.Float64frombits(binary.BigEndian.Uint64(data))
+			}
+			return value, data[size:], nil
+		default:
+			return nil, nil, fmt.Errorf("%w: unsupported simple value %d", errCBOR, info)
+		}
+	}
+
+	arg, data, err := readCBORArgument(info, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case 0:
+		if arg > math.MaxInt64 {
+			return nil, nil, fmt.Errorf("%w: integer overflow", errCBOR)
+		}
+		return int64(arg), data, nil
+	case 1:
+		if arg > math.MaxInt64 {
+			return nil, nil, fmt.Errorf("%w: integer overflow", errCBOR)
+		}
+		return -1 - int64(arg), data, nil
+	case 2, 3:
+		if arg > uint64(len(data)) {
+			return nil, nil, fmt.Errorf("%w: unexpected end of data", errCBOR)
+		}
+		value := data[:arg]
+		if major == 3 {
+			return string(value), data[arg:], nil
+		}
+		return append([]byte(nil), value...), data[arg:], nil
+	case 4:
+		// 每个元素至少占一个字节，长度超过剩余数据的一定是错误的
+		if arg > uint64(len(data)) {
+			return nil, nil, fmt.Errorf("%w: unexpected end of data", errCBOR)
+		}
+		items := make([]interface{}, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			var item interface{}
+			item, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, data, nil
+	case 5:
+		if arg > uint64(len(data))/2 {
+			return nil, nil, fmt.Errorf("%w: unexpected end of data", errCBOR)
+		}
+		items := make(map[interface{}]interface{}, arg)
+		for i := uint64(0); i < arg; i++ {
+			var key, value interface{}
+			key, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, fmt.Errorf("%w: unsupported map key", errCBOR)
+			}
+			if _, exists := items[key]; exists {
+				return nil, nil, fmt.Errorf("%w: duplicate map key", errCBOR)
+			}
+			value, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			items[key] = value
+		}
+		return items, data, nil
+	default:
+		// 标签（6）：忽略标签本身，返回被标记的数据项
+		return decodeCBORItem(data, depth+1)
+	}
+}
+
+// readCBORArgument 读取数据项头部的参数（长度或整数值），不支持不定长编码
+func readCBORArgument(info byte, data []byte) (uint64, []byte, error) {
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info <= 27:
+		size := 1 << (info - 24)
+		if len(data) < size {
+			return 0, nil, fmt.Errorf("%w: unexpected end of data", errCBOR)
+		}
+		var value uint64
+		for _, b := range data[:size] {
+			value = value<<8 | uint64(b)
+		}
+		return value, data[size:], nil
+	default:
+		return 0, nil, fmt.Errorf("%w: indefinite length is not supported", errCBOR)
+	}
+}
+
+// halfToFloat 半精度浮点数转换为 float32
+func halfToFloat(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1f
+	frac := uint32(h) & 0x3ff
+	switch exp {
+	case 0:
+		value := float32(frac) / (1 << 24)
+		if sign != 0 {
+			value = -value
+		}
+		return value
+	case 0x1f:
+		return math.Float32frombits(sign | 0xff<<23 | frac<<13)
+	default:
+		return math.Float32frombits(sign | (exp+112)<<23 | frac<<13)
+	}
+}
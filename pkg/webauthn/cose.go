@@ -0,0 +1,134 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE 算法（RFC 9053），注册时按 SupportedAlgorithms 的顺序声明
+const (
+	AlgES256 = -7   // ECDSA P-256 + SHA-256，绝大多数认证器使用
+	AlgEdDSA = -8   // Ed25519
+	AlgRS256 = -257 // RSASSA-PKCS1-v1_5 + SHA-256，Windows Hello
+)
+
+var SupportedAlgorithms = []int{AlgES256, AlgEdDSA, AlgRS256}
+
+// COSE_Key 的键类型和参数
+const (
+	coseKty = 1
+	coseAlg = 3
+
+	coseKtyOKP = 1
+	coseKtyEC2 = 2
+	coseKtyRSA = 3
+
+	coseCrvP256    = 1
+	coseCrvEd25519 = 6
+)
+
+var errCOSEKey = errors.New("webauthn: invalid credential public key")
+
+// ParsePublicKey 解析 COSE_Key 格式的凭据公钥，返回公钥和算法
+func ParsePublicKey(data []byte) (crypto.PublicKey, int, error) {
+	value, rest, err := decodeCBOR(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(rest) != 0 {
+		return nil, 0, fmt.Errorf("%w: trailing data", errCOSEKey)
+	}
+	return parseCOSEKey(value)
+}
+
+func parseCOSEKey(value interface{}) (crypto.PublicKey, int, error) {
+	key, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, 0, errCOSEKey
+	}
+	kty, _ := key[int64(coseKty)].(int64)
+	alg, _ := key[int64(coseAlg)].(int64)
+	param := func(label int64) []byte {
+		b, _ := key[label].([]byte)
+		return b
+	}
+
+	switch {
+	case kty == coseKtyEC2 && alg == AlgES256:
+		if crv, _ := key[int64(-1)].(int64); crv != coseCrvP256 {
+			return nil, 0, fmt.Errorf("%w: unsupported curve", errCOSEKey)
+		}
+		x, y := param(-2), param(-3)
+		if len(x) != 32 || len(y) != 32 {
+			return nil, 0, fmt.Errorf("%w: invalid ec point", errCOSEKey)
+		}
+		// 借助 ecdh 检查点是否在曲线上
+		raw := append(append([]byte{4}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(raw); err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", errCOSEKey, err)
+		}
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, AlgES256, nil
+
+	case kty == coseKtyOKP && alg == AlgEdDSA:
+		if crv, _ := key[int64(-1)].(int64); crv != coseCrvEd25519 {
+			return nil, 0, fmt.Errorf("%w: unsupported curve", errCOSEKey)
+		}
+		x := param(-2)
+		if len(x) != ed25519.PublicKeySize {
+			return nil, 0, fmt.Errorf("%w: invalid ed25519 key", errCOSEKey)
+		}
+		return ed25519.PublicKey(x), AlgEdDSA, nil
+
+	case kty == coseKtyRSA && alg == AlgRS256:
+		n, e := param(-1), param(-2)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, 0, fmt.Errorf("%w: rsa key too small or invalid", errCOSEKey)
+		}
+		exponent := int(new(big.Int).SetBytes(e).Int64())
+		if exponent < 3 {
+			return nil, 0, fmt.Errorf("%w: invalid rsa exponent", errCOSEKey)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}, AlgRS256, nil
+
+	default:
+		return nil, 0, fmt.Errorf("%w: unsupported algorithm %d", errCOSEKey, alg)
+	}
+}
+
+// verifySignature 使用 COSE_Key 格式的公钥校验签名
+func verifySignature(publicKey []byte, data []byte, signature []byte) error {
+	key, _, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrVerification, err)
+	}
+
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		if ecdsa.VerifyASN1(key, digest[:], signature) {
+			return nil
+		}
+	case ed25519.PublicKey:
+		if ed25519.Verify(key, data, signature) {
+			return nil
+		}
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(data)
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: invalid signature", ErrVerification)
+}
@@ -0,0 +1,416 @@
+// Package webauthn 通行密钥（WebAuthn Level 2/3）的服务端校验：生成注册和登录选项，
+// 校验注册（attestation）和登录（assertion）响应。选项和响应使用 WebAuthn Level 3 的 JSON 格式，
+// 二进制字段为 base64url 编码，前端可直接使用 PublicKeyCredential.parseCreationOptionsFromJSON 等方法
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrVerification = errors.New("webauthn: verification failed")
+	ErrSignCount    = errors.New("webauthn: sign counter did not increase, the authenticator may be cloned")
+)
+
+// 认证器数据中的标志位
+const (
+	flagUserPresent    = 0x01
+	flagUserVerified   = 0x04
+	flagBackupEligible = 0x08
+	flagBackupState    = 0x10
+	flagAttestedData   = 0x40
+	flagExtensionData  = 0x80
+)
+
+// 浏览器等待用户操作的超时时间（毫秒）
+const ceremonyTimeout = 300000
+
+// Config 依赖方（本站）配置
+type Config struct {
+	RPID    string   // 依赖方ID，为前端页面的域名（或其上级域名），不含协议和端口
+	RPName  string   // 认证器中显示的站点名称
+	Origins []string // 允许的前端页面来源，如 https://blog.example.com
+}
+
+type RelyingParty struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// UserEntity 认证器中保存的用户信息，Id 为不含个人信息的用户标识
+type UserEntity struct {
+	Id          string `json:"id"` // base64url
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	Id         string   `json:"id"` // base64url
+	Transports []string `json:"transports,omitempty"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey        string `json:"residentKey"`
+	RequireResidentKey bool   `json:"requireResidentKey"`
+	UserVerification   string `json:"userVerification"`
+}
+
+// CreationOptions navigator.credentials.create 的 publicKey 参数
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     RelyingParty           `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int                    `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions navigator.credentials.get 的 publicKey 参数
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int                    `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// RegistrationResponse navigator.credentials.create 返回的凭据（PublicKeyCredential.toJSON()）
+type RegistrationResponse struct {
+	Id       string `json:"id"`
+	RawId    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON"`
+		AttestationObject string   `json:"attestationObject"`
+		Transports        []string `json:"transports"`
+	} `json:"response"`
+}
+
+// AssertionResponse navigator.credentials.get 返回的凭据（PublicKeyCredential.toJSON()）
+type AssertionResponse struct {
+	Id       string `json:"id"`
+	RawId    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
+
+// Credential 注册成功的凭据
+type Credential struct {
+	Id             []byte
+	PublicKey      []byte // COSE_Key
+	Algorithm      int
+	SignCount      uint32
+	AAGUID         []byte // 认证器型号
+	Transports     []string
+	BackupEligible bool // 是否为可同步的通行密钥
+	BackupState    bool // 当前是否已同步备份
+}
+
+// AssertionResult 登录校验结果
+type AssertionResult struct {
+	SignCount   uint32
+	BackupState bool
+	UserHandle  []byte
+}
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+type authenticatorData struct {
+	rpIdHash  []byte
+	flags     byte
+	signCount uint32
+	// 以下字段仅在注册时存在
+	aaguid       []byte
+	credentialId []byte
+	publicKey    []byte
+}
+
+// NewChallenge 生成随机 challenge
+func NewChallenge() ([]byte, error) {
+	challenge := make([]byte, 32)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// EncodeBase64 base64url 编码（无填充）
+func EncodeBase64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeBase64 base64url 解码，兼容带填充的格式
+func DecodeBase64(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// NewCreationOptions 注册选项：要求创建可发现凭据（通行密钥）并验证用户，不要求证明（attestation）
+func (c *Config) NewCreationOptions(challenge []byte, user UserEntity, exclude []CredentialDescriptor) *CreationOptions {
+	params := make([]CredentialParameter, len(SupportedAlgorithms))
+	for i, alg := range SupportedAlgorithms {
+		params[i] = CredentialParameter{Type: "public-key", Alg: alg}
+	}
+	if exclude == nil {
+		exclude = []CredentialDescriptor{}
+	}
+	return &CreationOptions{
+		Challenge:          EncodeBase64(challenge),
+		RP:                 RelyingParty{Id: c.RPID, Name: c.RPName},
+		User:               user,
+		PubKeyCredParams:   params,
+		Timeout:            ceremonyTimeout,
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:        "required",
+			RequireResidentKey: true,
+			UserVerification:   "required",
+		},
+		Attestation: "none",
+	}
+}
+
+// NewRequestOptions 登录选项，allow 为空时由用户在认证器中选择通行密钥
+func (c *Config) NewRequestOptions(challenge []byte, allow []CredentialDescriptor) *RequestOptions {
+	if allow == nil {
+		allow = []CredentialDescriptor{}
+	}
+	return &RequestOptions{
+		Challenge:        EncodeBase64(challenge),
+		Timeout:          ceremonyTimeout,
+		RPID:             c.RPID,
+		AllowCredentials: allow,
+		UserVerification: "required",
+	}
+}
+
+// ClientChallenge 取出 clientDataJSON 中的 challenge，用于查找服务端保存的 challenge
+func ClientChallenge(clientDataJSON string) ([]byte, error) {
+	raw, err := DecodeBase64(clientDataJSON)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid client data", ErrVerification)
+	}
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("%w: invalid client data", ErrVerification)
+	}
+	return DecodeBase64(data.Challenge)
+}
+
+// verifyClientData 校验 clientDataJSON 的类型、challenge 和来源，返回其 SHA-256
+func (c *Config) verifyClientData(encoded string, ceremony string, challenge []byte) ([]byte, error) {
+	raw, err := DecodeBase64(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid client data", ErrVerification)
+	}
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("%w: invalid client data", ErrVerification)
+	}
+	if data.Type != ceremony {
+		return nil, fmt.Errorf("%w: unexpected client data type %q", ErrVerification, data.Type)
+	}
+	received, err := DecodeBase64(data.Challenge)
+	if err != nil || subtle.ConstantTimeCompare(received, challenge) != 1 {
+		return nil, fmt.Errorf("%w: challenge mismatch", ErrVerification)
+	}
+	allowed := false
+	for _, origin := range c.Origins {
+		if data.Origin == origin {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: origin %q is not allowed", ErrVerification, data.Origin)
+	}
+
+	sum := sha256.Sum256(raw)
+	return sum[:], nil
+}
+
+// parseAuthenticatorData 解析认证器数据，校验依赖方ID哈希以及用户在场和用户验证标志
+func (c *Config) parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, fmt.Errorf("%w: authenticator data too short", ErrVerification)
+	}
+	authData := &authenticatorData{
+		rpIdHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+
+	rpIdHash := sha256.Sum256([]byte(c.RPID))
+	if !bytes.Equal(authData.rpIdHash, rpIdHash[:]) {
+		return nil, fmt.Errorf("%w: rp id mismatch", ErrVerification)
+	}
+	if authData.flags&flagUserPresent == 0 {
+		return nil, fmt.Errorf("%w: user not present", ErrVerification)
+	}
+	if authData.flags&flagUserVerified == 0 {
+		return nil, fmt.Errorf("%w: user not verified", ErrVerification)
+	}
+	if authData.flags&flagBackupState != 0 && authData.flags&flagBackupEligible == 0 {
+		return nil, fmt.Errorf("%w: invalid backup flags", ErrVerification)
+	}
+
+	rest := data[37:]
+	if authData.flags&flagAttestedData != 0 {
+		if len(rest) < 18 {
+			return nil, fmt.Errorf("%w: attested credential data too short", ErrVerification)
+		}
+		authData.aaguid = rest[:16]
+		idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLength == 0 || idLength > 1023 || len(rest) < idLength {
+			return nil, fmt.Errorf("%w: invalid credential id", ErrVerification)
+		}
+		authData.credentialId = rest[:idLength]
+		rest = rest[idLength:]
+
+		_, remaining, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrVerification, err)
+		}
+		authData.publicKey = rest[:len(rest)-len(remaining)]
+		rest = remaining
+	}
+	if authData.flags&flagExtensionData != 0 {
+		_, remaining, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrVerification, err)
+		}
+		rest = remaining
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: trailing authenticator data", ErrVerification)
+	}
+	return authData, nil
+}
+
+// VerifyRegistration 校验注册响应，challenge 为服务端为本次注册生成的 challenge。
+// 选项中要求不提供证明，因此不校验证明声明（attestation statement），只信任其中的凭据公钥
+func (c *Config) VerifyRegistration(response *RegistrationResponse, challenge []byte) (*Credential, error) {
+	if response.Type != "public-key" {
+		return nil, fmt.Errorf("%w: unexpected credential type", ErrVerification)
+	}
+	if _, err := c.verifyClientData(response.Response.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	raw, err := DecodeBase64(response.Response.AttestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid attestation object", ErrVerification)
+	}
+	value, rest, err := decodeCBOR(raw)
+	if err != nil || len(rest) != 0 {
+		return nil, fmt.Errorf("%w: invalid attestation object", ErrVerification)
+	}
+	attestation, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid attestation object", ErrVerification)
+	}
+	if _, ok := attestation["fmt"].(string); !ok {
+		return nil, fmt.Errorf("%w: missing attestation format", ErrVerification)
+	}
+	rawAuthData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing authenticator data", ErrVerification)
+	}
+
+	authData, err := c.parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if authData.flags&flagAttestedData == 0 {
+		return nil, fmt.Errorf("%w: missing attested credential data", ErrVerification)
+	}
+	rawId, err := DecodeBase64(response.RawId)
+	if err != nil || !bytes.Equal(rawId, authData.credentialId) {
+		return nil, fmt.Errorf("%w: credential id mismatch", ErrVerification)
+	}
+	_, alg, err := ParsePublicKey(authData.publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrVerification, err)
+	}
+
+	return &Credential{
+		Id:             authData.credentialId,
+		PublicKey:      authData.publicKey,
+		Algorithm:      alg,
+		SignCount:      authData.signCount,
+		AAGUID:         authData.aaguid,
+		Transports:     response.Response.Transports,
+		BackupEligible: authData.flags&flagBackupEligible != 0,
+		BackupState:    authData.flags&flagBackupState != 0,
+	}, nil
+}
+
+// VerifyAssertion 校验登录响应。publicKey 和 signCount 为注册时保存的凭据公钥和最近一次的签名计数器。
+// 计数器不为 0 时必须递增，否则说明认证器可能被克隆
+func (c *Config) VerifyAssertion(response *AssertionResponse, challenge []byte, publicKey []byte, signCount uint32) (*AssertionResult, error) {
+	if response.Type != "public-key" {
+		return nil, fmt.Errorf("%w: unexpected credential type", ErrVerification)
+	}
+	clientDataHash, err := c.verifyClientData(response.Response.ClientDataJSON, "webauthn.get", challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	rawAuthData, err := DecodeBase64(response.Response.AuthenticatorData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid authenticator data", ErrVerification)
+	}
+	authData, err := c.parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := DecodeBase64(response.Response.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid signature", ErrVerification)
+	}
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash...)
+	if err := verifySignature(publicKey, signed, signature); err != nil {
+		return nil, err
+	}
+
+	if (authData.signCount != 0 || signCount != 0) && authData.signCount <= signCount {
+		return nil, ErrSignCount
+	}
+
+	userHandle, err := DecodeBase64(response.Response.UserHandle)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid user handle", ErrVerification)
+	}
+	return &AssertionResult{
+		SignCount:   authData.signCount,
+		BackupState: authData.flags&flagBackupState != 0,
+		UserHandle:  userHandle,
+	}, nil
+}
@@ -0,0 +1,197 @@
+package webauthn_test
+
+import (
+	"bytes"
+	"errors"
+	"server/pkg/webauthn"
+	"server/pkg/webauthn/webauthntest"
+	"testing"
+)
+
+const (
+	testRPID   = "blog.example.com"
+	testOrigin = "https://blog.example.com"
+)
+
+var testConfig = &webauthn.Config{RPID: testRPID, RPName: "Blog", Origins: []string{testOrigin}}
+
+func newChallenge(t *testing.T) []byte {
+	t.Helper()
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return challenge
+}
+
+// register 使用软件认证器注册凭据
+func register(t *testing.T, authenticator *webauthntest.Authenticator) *webauthn.Credential {
+	t.Helper()
+	challenge := newChallenge(t)
+	credential, err := testConfig.VerifyRegistration(authenticator.Create(webauthn.EncodeBase64(challenge), []byte("42")), challenge)
+	if err != nil {
+		t.Fatalf("VerifyRegistration: %v", err)
+	}
+	return credential
+}
+
+func TestRegistrationAndAssertion(t *testing.T) {
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	credential := register(t, authenticator)
+
+	if !bytes.Equal(credential.Id, authenticator.CredentialId) || !bytes.Equal(credential.PublicKey, authenticator.PublicKey()) {
+		t.Errorf("credential id or public key does not match the authenticator")
+	}
+	if credential.Algorithm != webauthn.AlgES256 || credential.SignCount != 0 || !credential.BackupEligible || !credential.BackupState {
+		t.Errorf("credential = %+v", credential)
+	}
+	if len(credential.Transports) != 2 || len(credential.AAGUID) != 16 {
+		t.Errorf("transports = %v, aaguid = %x", credential.Transports, credential.AAGUID)
+	}
+
+	signCount := credential.SignCount
+	for i := 1; i <= 2; i++ {
+		challenge := newChallenge(t)
+		result, err := testConfig.VerifyAssertion(authenticator.Get(webauthn.EncodeBase64(challenge)), challenge, credential.PublicKey, signCount)
+		if err != nil {
+			t.Fatalf("VerifyAssertion %d: %v", i, err)
+		}
+		if result.SignCount != uint32(i) || string(result.UserHandle) != "42" || !result.BackupState {
+			t.Fatalf("assertion %d: %+v", i, result)
+		}
+		signCount = result.SignCount
+	}
+}
+
+func TestAssertionSignCount(t *testing.T) {
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	credential := register(t, authenticator)
+
+	challenge := newChallenge(t)
+	result, err := testConfig.VerifyAssertion(authenticator.Get(webauthn.EncodeBase64(challenge)), challenge, credential.PublicKey, credential.SignCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 被复制的认证器使用旧的计数器签名
+	for _, stale := range []uint32{0, result.SignCount - 1} {
+		authenticator.SignCount = stale
+		challenge := newChallenge(t)
+		if _, err := testConfig.VerifyAssertion(authenticator.Get(webauthn.EncodeBase64(challenge)), challenge, credential.PublicKey, result.SignCount); err != webauthn.ErrSignCount {
+			t.Errorf("counter %d after %d: %v, want ErrSignCount", stale+1, result.SignCount, err)
+		}
+	}
+
+	// 服务端已记录计数器后，计数器为 0 的响应同样被拒绝
+	authenticator.SignCount = 0
+	authenticator.StaticCounter = true
+	challenge = newChallenge(t)
+	if _, err := testConfig.VerifyAssertion(authenticator.Get(webauthn.EncodeBase64(challenge)), challenge, credential.PublicKey, result.SignCount); err != webauthn.ErrSignCount {
+		t.Errorf("zero counter after %d: %v, want ErrSignCount", result.SignCount, err)
+	}
+}
+
+func TestAssertionStaticCounter(t *testing.T) {
+	// 同步的通行密钥计数器始终为 0，不做递增检查
+	authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	authenticator.StaticCounter = true
+	credential := register(t, authenticator)
+
+	for i := 0; i < 2; i++ {
+		challenge := newChallenge(t)
+		result, err := testConfig.VerifyAssertion(authenticator.Get(webauthn.EncodeBase64(challenge)), challenge, credential.PublicKey, 0)
+		if err != nil || result.SignCount != 0 {
+			t.Fatalf("assertion %d: %+v, %v", i, result, err)
+		}
+	}
+}
+
+func TestRegistrationRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(a *webauthntest.Authenticator)
+		// challenge 返回认证器签名的 challenge，默认为服务端生成的 challenge
+		challenge func(challenge []byte) []byte
+		response  func(r *webauthn.RegistrationResponse)
+	}{
+		{name: "wrong challenge", challenge: func([]byte) []byte { return []byte("another challenge") }},
+		{name: "wrong origin", modify: func(a *webauthntest.Authenticator) { a.Origin = "https://evil.example.com" }},
+		{name: "origin with different scheme", modify: func(a *webauthntest.Authenticator) { a.Origin = "http://blog.example.com" }},
+		{name: "wrong rp id", modify: func(a *webauthntest.Authenticator) { a.RPID = "evil.example.com" }},
+		{name: "user not verified", modify: func(a *webauthntest.Authenticator) { a.SkipUserVerification = true }},
+		{name: "raw id mismatch", response: func(r *webauthn.RegistrationResponse) { r.RawId = webauthn.EncodeBase64([]byte("other")) }},
+		{name: "assertion client data", response: func(r *webauthn.RegistrationResponse) {
+			// 把登录请求的 clientDataJSON 用于注册
+			r.Response.ClientDataJSON = webauthntest.NewAuthenticator(testRPID, testOrigin).Get(webauthn.EncodeBase64([]byte("challenge"))).Response.ClientDataJSON
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+			if test.modify != nil {
+				test.modify(authenticator)
+			}
+			challenge := newChallenge(t)
+			signed := challenge
+			if test.challenge != nil {
+				signed = test.challenge(challenge)
+			}
+			response := authenticator.Create(webauthn.EncodeBase64(signed), []byte("42"))
+			if test.response != nil {
+				test.response(response)
+			}
+			if _, err := testConfig.VerifyRegistration(response, challenge); !errors.Is(err, webauthn.ErrVerification) {
+				t.Fatalf("VerifyRegistration: %v, want ErrVerification", err)
+			}
+		})
+	}
+}
+
+func TestAssertionRejects(t *testing.T) {
+	other := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	tests := []struct {
+		name      string
+		modify    func(a *webauthntest.Authenticator)
+		challenge func(challenge []byte) []byte
+		response  func(r *webauthn.AssertionResponse)
+	}{
+		{name: "wrong challenge", challenge: func([]byte) []byte { return []byte("another challenge") }},
+		{name: "empty challenge", challenge: func([]byte) []byte { return nil }},
+		{name: "wrong origin", modify: func(a *webauthntest.Authenticator) { a.Origin = "https://blog.example.com.evil.com" }},
+		{name: "wrong rp id", modify: func(a *webauthntest.Authenticator) { a.RPID = "example.com" }},
+		{name: "user not verified", modify: func(a *webauthntest.Authenticator) { a.SkipUserVerification = true }},
+		{name: "signed by another key", modify: func(a *webauthntest.Authenticator) { a.Key = other.Key }},
+		{name: "tampered authenticator data", response: func(r *webauthn.AssertionResponse) {
+			data, _ := webauthn.DecodeBase64(r.Response.AuthenticatorData)
+			data[36]++ // 修改计数器
+			r.Response.AuthenticatorData = webauthn.EncodeBase64(data)
+		}},
+		{name: "registration client data", response: func(r *webauthn.AssertionResponse) {
+			r.Response.ClientDataJSON = other.Create(webauthn.EncodeBase64([]byte("challenge")), nil).Response.ClientDataJSON
+		}},
+		{name: "wrong credential type", response: func(r *webauthn.AssertionResponse) { r.Type = "password" }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			authenticator := webauthntest.NewAuthenticator(testRPID, testOrigin)
+			credential := register(t, authenticator)
+			if test.modify != nil {
+				test.modify(authenticator)
+			}
+			challenge := newChallenge(t)
+			signed := challenge
+			if test.challenge != nil {
+				signed = test.challenge(challenge)
+			}
+			response := authenticator.Get(webauthn.EncodeBase64(signed))
+			if test.response != nil {
+				test.response(response)
+			}
+			if _, err := testConfig.VerifyAssertion(response, challenge, credential.PublicKey, credential.SignCount); !errors.Is(err, webauthn.ErrVerification) {
+				t.Fatalf("VerifyAssertion: %v, want ErrVerification", err)
+			}
+		})
+	}
+}
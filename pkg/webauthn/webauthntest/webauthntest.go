@@ -0,0 +1,165 @@
+// Package webauthntest 用于测试的软件认证器：使用 ECDSA P-256 密钥创建凭据（不提供证明）并签名登录请求，
+// 生成与浏览器 PublicKeyCredential.toJSON() 相同格式的响应
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"server/pkg/webauthn"
+)
+
+// 认证器数据中的标志位
+const (
+	flagUserPresent    = 0x01
+	flagUserVerified   = 0x04
+	flagBackupEligible = 0x08
+	flagBackupState    = 0x10
+	flagAttestedData   = 0x40
+)
+
+// Authenticator 软件认证器，只保存一个凭据
+type Authenticator struct {
+	RPID         string // 签名时使用的依赖方ID
+	Origin       string // 模拟浏览器写入 clientDataJSON 的来源
+	Key          *ecdsa.PrivateKey
+	CredentialId []byte
+	UserHandle   []byte
+	SignCount    uint32
+	// StaticCounter 不递增签名计数器，与同步的通行密钥一样计数器始终为 0
+	StaticCounter bool
+	// SkipUserVerification 不设置用户验证标志，模拟没有验证 PIN 或指纹的认证器
+	SkipUserVerification bool
+}
+
+// NewAuthenticator 创建软件认证器并生成凭据密钥
+func NewAuthenticator(rpId string, origin string) *Authenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	credentialId := make([]byte, 16)
+	if _, err := rand.Read(credentialId); err != nil {
+		panic(err)
+	}
+	return &Authenticator{
+		RPID:         rpId,
+		Origin:       origin,
+		Key:          key,
+		CredentialId: credentialId,
+	}
+}
+
+// Create 模拟 navigator.credentials.create，challenge 为选项中的 base64url 字符串
+func (a *Authenticator) Create(challenge string, userHandle []byte) *webauthn.RegistrationResponse {
+	a.UserHandle = userHandle
+
+	authData := a.authenticatorData(flagAttestedData)
+	authData = append(authData, make([]byte, 16)...) // AAGUID 全为 0
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.CredentialId)))
+	authData = append(authData, a.CredentialId...)
+	authData = append(authData, a.PublicKey()...)
+
+	// {"fmt": "none", "attStmt": {}, "authData": h'...'}
+	attestation := []byte{0xa3}
+	attestation = appendCBORText(attestation, "fmt")
+	attestation = appendCBORText(attestation, "none")
+	attestation = appendCBORText(attestation, "attStmt")
+	attestation = append(attestation, 0xa0)
+	attestation = appendCBORText(attestation, "authData")
+	attestation = appendCBORBytes(attestation, authData)
+
+	response := &webauthn.RegistrationResponse{
+		Id:    webauthn.EncodeBase64(a.CredentialId),
+		RawId: webauthn.EncodeBase64(a.CredentialId),
+		Type:  "public-key",
+	}
+	response.Response.ClientDataJSON = a.clientData("webauthn.create", challenge)
+	response.Response.AttestationObject = webauthn.EncodeBase64(attestation)
+	response.Response.Transports = []string{"internal", "hybrid"}
+	return response
+}
+
+// Get 模拟 navigator.credentials.get，每次签名前递增计数器
+func (a *Authenticator) Get(challenge string) *webauthn.AssertionResponse {
+	if !a.StaticCounter {
+		a.SignCount++
+	}
+	authData := a.authenticatorData(0)
+	clientDataJSON := a.clientData("webauthn.get", challenge)
+	raw, _ := webauthn.DecodeBase64(clientDataJSON)
+	clientDataHash := sha256.Sum256(raw)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.Key, digest[:])
+	if err != nil {
+		panic(err)
+	}
+
+	response := &webauthn.AssertionResponse{
+		Id:    webauthn.EncodeBase64(a.CredentialId),
+		RawId: webauthn.EncodeBase64(a.CredentialId),
+		Type:  "public-key",
+	}
+	response.Response.ClientDataJSON = clientDataJSON
+	response.Response.AuthenticatorData = webauthn.EncodeBase64(authData)
+	response.Response.Signature = webauthn.EncodeBase64(signature)
+	response.Response.UserHandle = webauthn.EncodeBase64(a.UserHandle)
+	return response
+}
+
+// PublicKey COSE_Key 格式的凭据公钥
+func (a *Authenticator) PublicKey() []byte {
+	point := a.Key.PublicKey
+	x := point.X.FillBytes(make([]byte, 32))
+	y := point.Y.FillBytes(make([]byte, 32))
+
+	// {1: 2, 3: -7, -1: 1, -2: x, -3: y}
+	key := []byte{0xa5, 0x01, 0x02, 0x03, 0x26, 0x20, 0x01, 0x21}
+	key = appendCBORBytes(key, x)
+	key = append(key, 0x22)
+	return appendCBORBytes(key, y)
+}
+
+// authenticatorData 依赖方ID哈希、标志位和签名计数器
+func (a *Authenticator) authenticatorData(flags byte) []byte {
+	flags |= flagUserPresent | flagBackupEligible | flagBackupState
+	if !a.SkipUserVerification {
+		flags |= flagUserVerified
+	}
+	rpIdHash := sha256.Sum256([]byte(a.RPID))
+	data := append(rpIdHash[:], flags)
+	return binary.BigEndian.AppendUint32(data, a.SignCount)
+}
+
+func (a *Authenticator) clientData(ceremony string, challenge string) string {
+	raw, _ := json.Marshal(map[string]interface{}{
+		"type":        ceremony,
+		"challenge":   challenge,
+		"origin":      a.Origin,
+		"crossOrigin": false,
+	})
+	return webauthn.EncodeBase64(raw)
+}
+
+// appendCBORHead 写入数据项头部，长度不超过 65535
+func appendCBORHead(b []byte, major byte, n int) []byte {
+	switch {
+	case n < 24:
+		return append(b, major<<5|byte(n))
+	case n < 256:
+		return append(b, major<<5|24, byte(n))
+	default:
+		return binary.BigEndian.AppendUint16(append(b, major<<5|25), uint16(n))
+	}
+}
+
+func appendCBORBytes(b []byte, data []byte) []byte {
+	return append(appendCBORHead(b, 2, len(data)), data...)
+}
+
+func appendCBORText(b []byte, text string) []byte {
+	return append(appendCBORHead(b, 3, len(text)), text...)
+}